@@ -0,0 +1,73 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/feedme/order-controller/internal/api"
+	"github.com/feedme/order-controller/internal/auth"
+	"github.com/feedme/order-controller/internal/manager"
+	"github.com/feedme/order-controller/internal/utils"
+)
+
+// controlAPI is the HTTP control API of a running simulation.
+type controlAPI struct {
+	server *http.Server
+	audit  io.Closer
+}
+
+// startAPI serves the control API of sm on addr, authenticating callers with
+// the token file at tokensPath. Calls are audited to auditPath, or only in
+// memory if it is empty.
+func startAPI(sm *manager.SystemManager, addr, tokensPath, auditPath string) (*controlAPI, error) {
+	if tokensPath == "" {
+		return nil, errors.New("-api-tokens is required with -api-addr")
+	}
+	authn, err := auth.LoadTokens(tokensPath)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &controlAPI{}
+	var w io.Writer
+	if auditPath != "" {
+		f, err := os.OpenFile(auditPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("open audit log: %w", err)
+		}
+		w, c.audit = f, f
+	}
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		if c.audit != nil {
+			c.audit.Close()
+		}
+		return nil, err
+	}
+	c.server = &http.Server{
+		Handler:           api.NewServer(sm, authn, auth.NewAuditLog(w)),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		if err := c.server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			utils.LogError("Control API stopped: %v", err)
+		}
+	}()
+	utils.Log("Control API listening on %s", ln.Addr())
+	return c, nil
+}
+
+// Close stops the API and closes the audit log.
+func (c *controlAPI) Close() error {
+	err := c.server.Close()
+	if c.audit != nil {
+		err = errors.Join(err, c.audit.Close())
+	}
+	return err
+}
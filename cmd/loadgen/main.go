@@ -0,0 +1,98 @@
+// Command loadgen runs a restaurant on a virtual clock under a synthetic or
+// recorded arrival process and reports dispatch performance.
+//
+//	go run ./cmd/loadgen -process poisson -rate 20 -duration 1h -fast 300 -slow 200
+//	go run ./cmd/loadgen -process rush -rate 5 -rush-rate 40 -rush-start 1h -rush-length 2h
+//	go run ./cmd/loadgen -process replay -file arrivals.csv
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"time"
+
+	"github.com/feedme/order-controller/internal/bot"
+	"github.com/feedme/order-controller/internal/loadgen"
+	"github.com/feedme/order-controller/internal/manager"
+	"github.com/feedme/order-controller/internal/order"
+	"github.com/feedme/order-controller/internal/report"
+	"github.com/feedme/order-controller/internal/utils"
+)
+
+func main() {
+	process := flag.String("process", "poisson", "arrival process: poisson, rush or replay")
+	rate := flag.Float64("rate", 10, "arrival rate in orders per second")
+	rushRate := flag.Float64("rush-rate", 50, "arrival rate during the rush (rush process)")
+	rushStart := flag.Duration("rush-start", 30*time.Minute, "start of the rush (rush process)")
+	rushLength := flag.Duration("rush-length", time.Hour, "length of the rush (rush process)")
+	duration := flag.Duration("duration", 2*time.Hour, "length of the arrival window")
+	vip := flag.Float64("vip", 0.2, "share of VIP orders")
+	file := flag.String("file", "", "arrivals to replay as offset,type lines (replay process)")
+	fast := flag.Int("fast", 300, "number of FAST bots")
+	slow := flag.Int("slow", 200, "number of SLOW bots")
+	seed := flag.Int64("seed", 1, "random seed")
+	sla := flag.Duration("sla", report.DefaultSLA, "SLA for the report")
+	format := flag.String("format", report.FormatText, "report format: text, csv or json")
+	idle := flag.String("idle-policy", order.FastestFirst.String(), "which idle bot gets a new order: fastest, lru or round-robin")
+	verbose := flag.Bool("v", false, "keep the simulator's log output")
+	flag.Parse()
+
+	if !*verbose {
+		utils.SetOutput(io.Discard)
+	}
+
+	policy, err := order.ParseIdlePolicy(*idle)
+	if err != nil {
+		fail(err)
+	}
+
+	rnd := rand.New(rand.NewSource(*seed))
+	var arrivals []loadgen.Arrival
+	switch *process {
+	case "poisson":
+		arrivals = loadgen.Poisson(rnd, *rate, *vip, *duration)
+	case "rush":
+		rush := loadgen.Rush{Start: *rushStart, Length: *rushLength, Rate: *rushRate}
+		arrivals = loadgen.Bursty(rnd, *rate, rush, *vip, *duration)
+	case "replay":
+		f, err := os.Open(*file)
+		if err != nil {
+			fail(err)
+		}
+		arrivals, err = loadgen.Replay(f)
+		f.Close()
+		if err != nil {
+			fail(err)
+		}
+	default:
+		fail(fmt.Errorf("unknown process %q", *process))
+	}
+
+	bots := make([]bot.BotTypeEnum, 0, *fast+*slow)
+	for i := 0; i < *fast; i++ {
+		bots = append(bots, bot.BotTypeFast)
+	}
+	for i := 0; i < *slow; i++ {
+		bots = append(bots, bot.BotTypeSlow)
+	}
+
+	res, err := loadgen.Run(arrivals, bots, *sla, manager.WithIdlePolicy(policy))
+	if err != nil {
+		fail(err)
+	}
+
+	fmt.Fprintf(os.Stderr, "%d orders, %d bots: simulated %v in %v (%.0f orders/s, %d steps, %d allocs, %.1f MB)\n",
+		res.Arrivals, len(bots), res.Virtual.Round(time.Second), res.Wall.Round(time.Millisecond),
+		float64(res.Completed)/res.Wall.Seconds(), res.Steps, res.Allocs, float64(res.Bytes)/(1<<20))
+	if err := res.Report.Write(os.Stdout, *format); err != nil {
+		fail(err)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "loadgen:", err)
+	os.Exit(1)
+}
@@ -1,20 +1,106 @@
 package main
 
 import (
+	"flag"
+	"os"
 	"strings"
 	"time"
 
 	"github.com/feedme/order-controller/internal/bot"
+	"github.com/feedme/order-controller/internal/clock"
 	"github.com/feedme/order-controller/internal/manager"
 	"github.com/feedme/order-controller/internal/order"
+	"github.com/feedme/order-controller/internal/result"
 	"github.com/feedme/order-controller/internal/utils"
 )
 
 func main() {
+	os.Exit(run())
+}
+
+// run runs the simulation and returns the process exit code. Everything it
+// opens is closed by deferred calls, so it must return rather than exit.
+func run() int {
+	journal := flag.String("journal", "", "order journal to keep, e.g. scripts/orders.jsonl for the report command (empty to disable); an existing journal is resumed")
+	resetJournal := flag.Bool("reset-journal", false, "delete an existing -journal file and start afresh")
+	format := flag.String("output-format", result.FormatJSON, "structured result file alongside result.txt: json, csv or text (none)")
+	output := flag.String("output", "", "structured result file path (default scripts/result.jsonl or scripts/result.csv)")
+	apiAddr := flag.String("api-addr", "", "serve the control API on this address, e.g. :8080 (empty to disable)")
+	apiTokens := flag.String("api-tokens", "", "API token file, required with -api-addr")
+	auditLog := flag.String("audit-log", "", "append API audit entries to this file (default: memory only)")
+	flag.Parse()
+	if *format != result.FormatText && *format != result.FormatJSON && *format != result.FormatCSV {
+		utils.LogError("Unknown output format %q (want text, json or csv)", *format)
+		return 2
+	}
+	if *resetJournal && *journal == "" {
+		utils.LogError("-reset-journal needs -journal")
+		return 2
+	}
+
 	utils.LogRaw("McDonald's Order Controller - Starting Simulation")
 	utils.LogRaw(strings.Repeat(" ", 5))
 
-	sm := manager.NewSystemManager()
+	// The manager and the result recorder stamp records by the same clock.
+	clk := clock.Real
+	opts := []manager.Option{manager.WithClock(clk)}
+	if *journal != "" {
+		if *resetJournal {
+			if err := os.Remove(*journal); err != nil && !os.IsNotExist(err) {
+				utils.LogError("Cannot reset journal: %v", err)
+				return 1
+			}
+		}
+		repo, err := order.OpenFileRepository(*journal)
+		if err != nil {
+			utils.LogError("Cannot open journal: %v", err)
+			return 1
+		}
+		defer func() {
+			if err := repo.Close(); err != nil {
+				utils.LogError("Cannot close journal: %v", err)
+			}
+		}()
+		opts = append(opts, manager.WithOrderRepository(repo))
+	}
+
+	var out *os.File
+	if *format != result.FormatText {
+		path := *output
+		if path == "" {
+			path = result.DefaultPath(*format)
+		}
+		f, err := os.Create(path)
+		if err != nil {
+			utils.LogError("Cannot create result file: %v", err)
+			return 1
+		}
+		defer f.Close()
+		out = f
+	}
+
+	// The manager is stopped before the result file and journal are closed,
+	// so nothing it does on the way out is written to a closed file.
+	sm := manager.NewSystemManager(opts...)
+	defer sm.Stop()
+
+	var recorder *result.Recorder
+	if out != nil {
+		var err error
+		if recorder, err = result.NewRecorder(out, *format, sm.EventBus, result.WithClock(clk)); err != nil {
+			utils.LogError("Cannot record results: %v", err)
+			return 1
+		}
+	}
+
+	if *apiAddr != "" {
+		ctl, err := startAPI(sm, *apiAddr, *apiTokens, *auditLog)
+		if err != nil {
+			utils.LogError("Cannot start control API: %v", err)
+			return 1
+		}
+		defer ctl.Close()
+	}
 
 	// Add a Fast Bot (5s processing)
 	sm.AddBot(bot.BotTypeFast)
@@ -45,4 +131,16 @@ func main() {
 	utils.LogRaw(strings.Repeat(" ", 5))
 	utils.LogRaw(strings.Repeat("=", 50))
 	utils.LogRaw(sm.GetSummary())
+
+	// Bots, shelf timers and notifiers may still publish events or move
+	// orders until the manager is stopped.
+	summary := sm.Summary()
+	sm.Stop()
+	if recorder != nil {
+		if err := recorder.Close(summary); err != nil {
+			utils.LogError("Cannot write result file: %v", err)
+			return 1
+		}
+	}
+	return 0
 }
@@ -0,0 +1,36 @@
+// Command report prints the end-of-day report of a run from its order journal,
+// the JSONL file written by the simulator's -journal flag.
+//
+//	go run ./cmd/report -journal orders.jsonl -format csv -sla 90s
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/feedme/order-controller/internal/order"
+	"github.com/feedme/order-controller/internal/report"
+)
+
+func main() {
+	journal := flag.String("journal", "scripts/orders.jsonl", "order journal to read")
+	format := flag.String("format", report.FormatText, "output format: text, csv or json")
+	sla := flag.Duration("sla", report.DefaultSLA, "longest acceptable time from order to completion")
+	flag.Parse()
+
+	if err := run(*journal, *format, *sla); err != nil {
+		fmt.Fprintln(os.Stderr, "report:", err)
+		os.Exit(1)
+	}
+}
+
+func run(journal, format string, sla time.Duration) error {
+	// The journal is only read, so reporting never creates or changes it.
+	repo, err := order.ReplayFile(journal)
+	if err != nil {
+		return err
+	}
+	return report.FromRepository(repo, sla).Write(os.Stdout, format)
+}
@@ -0,0 +1,595 @@
+// Package api exposes a restaurant's controls over HTTP. Every call must carry
+// an API token ("Authorization: Bearer <token>"), is checked against the
+// caller's role and is recorded in the audit log, allowed or not.
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/feedme/order-controller/internal/auth"
+	"github.com/feedme/order-controller/internal/bot"
+	"github.com/feedme/order-controller/internal/clock"
+	"github.com/feedme/order-controller/internal/manager"
+	"github.com/feedme/order-controller/internal/order"
+	"github.com/feedme/order-controller/internal/webhook"
+)
+
+// errNotAuthorised is reported when a route tries to respond without having
+// checked the caller's permission, so a forgotten check fails closed.
+var errNotAuthorised = errors.New("route did not authorise the call")
+
+// Server is the HTTP API of one restaurant.
+type Server struct {
+	manager *manager.SystemManager
+	auth    *auth.Authenticator
+	audit   *auth.AuditLog
+	clock   clock.Clock
+	mux     *http.ServeMux
+}
+
+// Option configures a Server at construction time.
+type Option func(*Server)
+
+// WithClock sets the clock used to stamp audit entries. The default is clock.Real.
+func WithClock(c clock.Clock) Option {
+	return func(s *Server) {
+		s.clock = c
+	}
+}
+
+// NewServer returns the API of m, authenticating callers with authn and
+// recording every call in audit.
+func NewServer(m *manager.SystemManager, authn *auth.Authenticator, audit *auth.AuditLog, opts ...Option) *Server {
+	s := &Server{
+		manager: m,
+		auth:    authn,
+		audit:   audit,
+		clock:   clock.Real,
+		mux:     http.NewServeMux(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.handle("GET /summary", s.summary)
+	s.handle("GET /audit", s.auditLog)
+	s.handle("GET /queue", s.listQueue)
+	s.handle("POST /queue/pause", s.pauseQueue)
+	s.handle("POST /queue/resume", s.resumeQueue)
+	s.handle("GET /orders/{id}", s.getOrder)
+	s.handle("GET /orders/{id}/eta", s.orderETA)
+	s.handle("POST /orders", s.createOrder)
+	s.handle("POST /orders/batch", s.createBatch)
+	s.handle("POST /orders/pinned", s.createPinnedOrder)
+	s.handle("GET /orders/scheduled", s.listScheduled)
+	s.handle("POST /orders/scheduled", s.createScheduledOrder)
+	s.handle("PATCH /orders/{id}", s.modifyOrder)
+	s.handle("DELETE /orders/{id}", s.cancelOrder)
+	s.handle("POST /orders/{id}/collect", s.collectOrder)
+	s.handle("POST /bots", s.addBot)
+	s.handle("DELETE /bots/{id}", s.removeBot)
+	s.handle("POST /bots/{id}/pause", s.pauseBot)
+	s.handle("POST /bots/{id}/resume", s.resumeBot)
+	s.handle("POST /bots/{id}/dedicate", s.dedicateBot)
+	s.handle("POST /bots/{id}/maintenance", s.startMaintenance)
+	if m.Webhooks != nil {
+		admin := webhook.NewAdminHandler(m.Webhooks)
+		webhooks := func(c *call) { s.webhooks(c, admin) }
+		s.handle("/webhooks", webhooks)
+		s.handle("/webhooks/", webhooks)
+	}
+	return s
+}
+
+// ServeHTTP routes a request to its handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// call is one API request on its way through authentication, authorisation
+// and its handler. entry is recorded in the audit log once it is answered.
+type call struct {
+	w         *statusWriter
+	r         *http.Request
+	principal auth.Principal
+	entry     auth.AuditEntry
+}
+
+// handle registers fn for pattern behind authentication and auditing.
+func (s *Server) handle(pattern string, fn func(*call)) {
+	s.mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		c := &call{
+			w:     &statusWriter{ResponseWriter: w},
+			r:     r,
+			entry: auth.AuditEntry{At: s.clock.Now(), Route: pattern},
+		}
+		defer func() {
+			c.entry.Status = c.w.status
+			s.audit.Record(c.entry)
+		}()
+
+		p, err := s.auth.Authenticate(bearerToken(r))
+		if err != nil {
+			c.fail(err)
+			return
+		}
+		c.principal = p
+		c.entry.Principal = p.Name
+		c.entry.Role = p.Role
+		fn(c)
+		if c.w.status == 0 {
+			c.fail(errNotAuthorised)
+		}
+	})
+}
+
+// bearerToken returns the token of an "Authorization: Bearer" header.
+func bearerToken(r *http.Request) string {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// authorize checks that the caller may perform a on target and answers 403 if
+// not. Handlers must call it before acting.
+func (c *call) authorize(a auth.Action, target string) bool {
+	c.entry.Action = a
+	c.entry.Target = target
+	c.entry.Allowed = false
+	if err := c.principal.Authorize(a); err != nil {
+		c.fail(err)
+		return false
+	}
+	c.entry.Allowed = true
+	return true
+}
+
+// authorizeRequest checks that the caller may create the order req asks for.
+// Tying the order to a bot needs permission to manage orders, and naming a
+// customer webhook, which the restaurant then calls, needs its own permission.
+func (c *call) authorizeRequest(req order.OrderRequest) bool {
+	if !req.Affinity.IsZero() && !c.principal.Can(auth.ActionManageOrders) {
+		return c.authorize(auth.ActionManageOrders, string(req.Type))
+	}
+	if req.Contact.WebhookURL != "" && !c.principal.Can(auth.ActionSetContactWebhook) {
+		return c.authorize(auth.ActionSetContactWebhook, string(req.Type))
+	}
+	return c.authorize(auth.CreateOrderAction(req.Type), string(req.Type))
+}
+
+// respond answers with v as JSON, or with no body if v is nil.
+func (c *call) respond(status int, v interface{}) {
+	if !c.entry.Allowed {
+		c.fail(errNotAuthorised)
+		return
+	}
+	if v == nil {
+		c.w.WriteHeader(status)
+		return
+	}
+	writeJSON(c.w, status, v)
+}
+
+// fail answers with err and records it in the audit entry.
+func (c *call) fail(err error) {
+	c.entry.Error = err.Error()
+	writeJSON(c.w, statusFor(err), map[string]string{"error": err.Error()})
+}
+
+// decode reads the JSON request body into v. An empty body leaves v as is.
+func (c *call) decode(v interface{}) bool {
+	if err := json.NewDecoder(c.r.Body).Decode(v); err != nil && !errors.Is(err, io.EOF) {
+		c.fail(&badRequest{err})
+		return false
+	}
+	return true
+}
+
+// orderID parses the {id} path value.
+func (c *call) orderID() (int, bool) {
+	id, err := strconv.Atoi(c.r.PathValue("id"))
+	if err != nil {
+		c.fail(&badRequest{err})
+		return 0, false
+	}
+	return id, true
+}
+
+func (s *Server) summary(c *call) {
+	if c.authorize(auth.ActionRead, "") {
+		c.respond(http.StatusOK, s.manager.Summary())
+	}
+}
+
+func (s *Server) auditLog(c *call) {
+	if c.authorize(auth.ActionReadAudit, "") {
+		c.respond(http.StatusOK, s.audit.Entries())
+	}
+}
+
+// queueEntry is one order of GET /queue. ETASeconds is omitted while no bot
+// is working.
+type queueEntry struct {
+	Order      order.OrderSnapshot `json:"order"`
+	ETASeconds *float64            `json:"eta_seconds,omitempty"`
+}
+
+func (s *Server) listQueue(c *call) {
+	if !c.authorize(auth.ActionRead, "") {
+		return
+	}
+	etas, err := s.manager.EstimateQueue()
+	if err != nil && !errors.Is(err, manager.ErrNoCapacity) {
+		c.fail(err)
+		return
+	}
+	pending := s.manager.OrderQueue.List()
+	entries := make([]queueEntry, len(pending))
+	for i, o := range pending {
+		entries[i].Order = o
+		if eta, ok := etas[o.ID]; ok {
+			seconds := eta.Seconds()
+			entries[i].ETASeconds = &seconds
+		}
+	}
+	c.respond(http.StatusOK, entries)
+}
+
+func (s *Server) pauseQueue(c *call) {
+	if c.authorize(auth.ActionManageOrders, "") {
+		s.manager.OrderQueue.SetPaused(true)
+		c.respond(http.StatusNoContent, nil)
+	}
+}
+
+func (s *Server) resumeQueue(c *call) {
+	if c.authorize(auth.ActionManageOrders, "") {
+		s.manager.OrderQueue.SetPaused(false)
+		c.respond(http.StatusNoContent, nil)
+	}
+}
+
+func (s *Server) getOrder(c *call) {
+	if !c.authorize(auth.ActionRead, c.r.PathValue("id")) {
+		return
+	}
+	id, ok := c.orderID()
+	if !ok {
+		return
+	}
+	ord := s.manager.Orders.GetOrder(id)
+	if ord == nil {
+		c.fail(manager.ErrOrderNotFound)
+		return
+	}
+	c.respond(http.StatusOK, ord.Snapshot())
+}
+
+// etaResponse is the body answered by GET /orders/{id}/eta.
+type etaResponse struct {
+	OrderID    int     `json:"order_id"`
+	ETASeconds float64 `json:"eta_seconds"`
+	Text       string  `json:"text"`
+}
+
+func (s *Server) orderETA(c *call) {
+	if !c.authorize(auth.ActionRead, c.r.PathValue("id")) {
+		return
+	}
+	id, ok := c.orderID()
+	if !ok {
+		return
+	}
+	eta, err := s.manager.EstimateReady(id)
+	if err != nil {
+		c.fail(err)
+		return
+	}
+	c.respond(http.StatusOK, etaResponse{OrderID: id, ETASeconds: eta.Seconds(), Text: manager.FormatETA(eta)})
+}
+
+// createOrderRequest is the body of POST /orders.
+type createOrderRequest struct {
+	Type    order.OrderTypeEnum `json:"type"`
+	Items   []order.Item        `json:"items"`
+	Contact order.Contact       `json:"contact"`
+}
+
+func (s *Server) createOrder(c *call) {
+	var body createOrderRequest
+	if !c.decode(&body) {
+		return
+	}
+	req := order.OrderRequest{Type: body.Type, Items: body.Items, Contact: body.Contact}
+	if !c.authorizeRequest(req) {
+		return
+	}
+	results, err := s.manager.AddOrders([]order.OrderRequest{req})
+	if err != nil {
+		if len(results) == 1 && results[0].Err != nil {
+			err = results[0].Err
+		}
+		c.fail(err)
+		return
+	}
+	c.entry.Target = strconv.Itoa(results[0].Order.ID)
+	c.respond(http.StatusCreated, results[0].Order.Snapshot())
+}
+
+// batchRequest is the body of POST /orders/batch.
+type batchRequest struct {
+	Orders []order.OrderRequest `json:"orders"`
+}
+
+func (s *Server) createBatch(c *call) {
+	var req batchRequest
+	if !c.decode(&req) {
+		return
+	}
+	if len(req.Orders) == 0 && !c.authorize(auth.ActionCreateNormalOrder, "") {
+		return
+	}
+	for _, r := range req.Orders {
+		if !c.authorizeRequest(r) {
+			return
+		}
+	}
+	results, err := s.manager.AddOrders(req.Orders)
+	if err != nil {
+		c.fail(err)
+		return
+	}
+	snaps := make([]order.OrderSnapshot, len(results))
+	ids := make([]string, len(results))
+	for i, r := range results {
+		snaps[i] = r.Order.Snapshot()
+		ids[i] = strconv.Itoa(r.Order.ID)
+	}
+	c.entry.Target = strings.Join(ids, ",")
+	c.respond(http.StatusCreated, snaps)
+}
+
+// pinnedOrderRequest is the body of POST /orders/pinned.
+type pinnedOrderRequest struct {
+	Type     order.OrderTypeEnum `json:"type"`
+	Affinity order.Affinity      `json:"affinity"`
+}
+
+func (s *Server) createPinnedOrder(c *call) {
+	var req pinnedOrderRequest
+	if !c.decode(&req) || !c.authorize(auth.ActionManageOrders, string(req.Type)) {
+		return
+	}
+	if err := (order.OrderRequest{Type: req.Type, Affinity: req.Affinity}).Validate(); err != nil {
+		c.fail(err)
+		return
+	}
+	ord := s.manager.AddPinnedOrder(req.Type, req.Affinity)
+	c.entry.Target = strconv.Itoa(ord.ID)
+	c.respond(http.StatusCreated, ord.Snapshot())
+}
+
+func (s *Server) listScheduled(c *call) {
+	if c.authorize(auth.ActionRead, "") {
+		c.respond(http.StatusOK, s.manager.ScheduledOrders())
+	}
+}
+
+// scheduledOrderRequest is the body of POST /orders/scheduled.
+type scheduledOrderRequest struct {
+	Type      order.OrderTypeEnum `json:"type"`
+	NotBefore time.Time           `json:"not_before"`
+}
+
+func (s *Server) createScheduledOrder(c *call) {
+	var req scheduledOrderRequest
+	if !c.decode(&req) || !c.authorize(auth.CreateOrderAction(req.Type), string(req.Type)) {
+		return
+	}
+	ord, err := s.manager.AddScheduledOrder(req.Type, req.NotBefore)
+	if err != nil {
+		c.fail(err)
+		return
+	}
+	c.entry.Target = strconv.Itoa(ord.ID)
+	c.respond(http.StatusCreated, ord.Snapshot())
+}
+
+func (s *Server) modifyOrder(c *call) {
+	var changes order.OrderChanges
+	if !c.authorize(auth.ActionManageOrders, c.r.PathValue("id")) || !c.decode(&changes) {
+		return
+	}
+	id, ok := c.orderID()
+	if !ok {
+		return
+	}
+	if _, err := s.manager.ModifyOrder(id, changes); err != nil {
+		c.fail(err)
+		return
+	}
+	c.respond(http.StatusOK, s.manager.Orders.GetOrder(id).Snapshot())
+}
+
+func (s *Server) cancelOrder(c *call) {
+	if !c.authorize(auth.ActionManageOrders, c.r.PathValue("id")) {
+		return
+	}
+	if id, ok := c.orderID(); ok {
+		s.result(c, s.manager.CancelOrder(id))
+	}
+}
+
+func (s *Server) collectOrder(c *call) {
+	if !c.authorize(auth.ActionManageOrders, c.r.PathValue("id")) {
+		return
+	}
+	if id, ok := c.orderID(); ok {
+		s.result(c, s.manager.CollectOrder(id))
+	}
+}
+
+// addBotRequest is the body of POST /bots.
+type addBotRequest struct {
+	Type bot.BotTypeEnum `json:"type"`
+}
+
+func (s *Server) addBot(c *call) {
+	var req addBotRequest
+	if !c.decode(&req) || !c.authorize(auth.ActionManageBots, string(req.Type)) {
+		return
+	}
+	if _, ok := bot.ProcessingTimeMap[req.Type]; !ok {
+		c.fail(&badRequest{errors.New("unknown bot type " + strconv.Quote(string(req.Type)))})
+		return
+	}
+	id, err := s.manager.AddBot(req.Type)
+	if err != nil {
+		c.fail(err)
+		return
+	}
+	c.entry.Target = id
+	c.respond(http.StatusCreated, map[string]string{"id": id})
+}
+
+func (s *Server) removeBot(c *call) {
+	id := c.r.PathValue("id")
+	if !c.authorize(auth.ActionManageBots, id) {
+		return
+	}
+	if s.manager.BotPool.GetBot(id) == nil {
+		c.fail(manager.ErrBotNotFound)
+		return
+	}
+	s.manager.RemoveBot(id)
+	c.respond(http.StatusNoContent, nil)
+}
+
+// pauseBotRequest is the optional body of POST /bots/{id}/pause.
+type pauseBotRequest struct {
+	Immediate bool `json:"immediate"`
+}
+
+func (s *Server) pauseBot(c *call) {
+	id := c.r.PathValue("id")
+	var req pauseBotRequest
+	if !c.authorize(auth.ActionManageBots, id) || !c.decode(&req) {
+		return
+	}
+	s.result(c, s.manager.PauseBot(id, req.Immediate))
+}
+
+func (s *Server) resumeBot(c *call) {
+	id := c.r.PathValue("id")
+	if c.authorize(auth.ActionManageBots, id) {
+		s.result(c, s.manager.ResumeBot(id))
+	}
+}
+
+// dedicateBotRequest is the body of POST /bots/{id}/dedicate.
+type dedicateBotRequest struct {
+	Dedicated bool `json:"dedicated"`
+}
+
+func (s *Server) dedicateBot(c *call) {
+	id := c.r.PathValue("id")
+	var req dedicateBotRequest
+	if !c.authorize(auth.ActionManageBots, id) || !c.decode(&req) {
+		return
+	}
+	s.result(c, s.manager.DedicateBot(id, req.Dedicated))
+}
+
+func (s *Server) startMaintenance(c *call) {
+	id := c.r.PathValue("id")
+	if c.authorize(auth.ActionManageBots, id) {
+		s.result(c, s.manager.StartMaintenance(id))
+	}
+}
+
+// webhooks passes a call on to the webhook admin API: reads need read access,
+// anything else needs permission to manage webhooks.
+func (s *Server) webhooks(c *call, admin http.Handler) {
+	action := auth.ActionManageWebhooks
+	if c.r.Method == http.MethodGet {
+		action = auth.ActionRead
+	}
+	if !c.authorize(action, c.r.URL.Path) {
+		return
+	}
+	admin.ServeHTTP(c.w, c.r)
+	if c.w.status >= http.StatusBadRequest {
+		c.entry.Error = http.StatusText(c.w.status)
+	}
+}
+
+// result answers 204 for a nil error and the matching error status otherwise.
+func (s *Server) result(c *call, err error) {
+	if err != nil {
+		c.fail(err)
+		return
+	}
+	c.respond(http.StatusNoContent, nil)
+}
+
+// badRequest marks a malformed request.
+type badRequest struct{ err error }
+
+func (e *badRequest) Error() string { return e.err.Error() }
+func (e *badRequest) Unwrap() error { return e.err }
+
+// statusFor maps an error to an HTTP status.
+func statusFor(err error) int {
+	var bad *badRequest
+	var botErr *bot.TransitionError
+	switch {
+	case errors.Is(err, auth.ErrUnauthenticated):
+		return http.StatusUnauthorized
+	case errors.Is(err, auth.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, manager.ErrOrderNotFound), errors.Is(err, manager.ErrBotNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, manager.ErrOrderNotPending), errors.Is(err, manager.ErrOrderNotReady), errors.As(err, &botErr):
+		return http.StatusConflict
+	case errors.As(err, &bad), errors.Is(err, order.ErrUnknownOrderType),
+		errors.Is(err, order.ErrInvalidItem), errors.Is(err, order.ErrInvalidContact),
+		errors.Is(err, order.ErrInvalidAffinity), errors.Is(err, manager.ErrInvalidBatch),
+		errors.Is(err, manager.ErrInvalidSchedule):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// statusWriter remembers the status of the response for the audit log.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
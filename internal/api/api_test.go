@@ -0,0 +1,212 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/feedme/order-controller/internal/auth"
+	"github.com/feedme/order-controller/internal/bot"
+	"github.com/feedme/order-controller/internal/manager"
+	"github.com/feedme/order-controller/internal/order"
+)
+
+func newTestServer(t *testing.T, opts ...manager.Option) (*Server, *manager.SystemManager, *auth.AuditLog) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	tokens := `{"tokens": [
+		{"name": "kiosk-1", "role": "kiosk", "token": "kiosk"},
+		{"name": "vip-1", "role": "vip-kiosk", "token": "vip"},
+		{"name": "alice", "role": "manager", "token": "manager"},
+		{"name": "bob", "role": "auditor", "token": "auditor"}
+	]}`
+	if err := os.WriteFile(path, []byte(tokens), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	authn, err := auth.LoadTokens(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := manager.NewSystemManager(opts...)
+	t.Cleanup(m.Stop)
+	audit := auth.NewAuditLog(nil)
+	return NewServer(m, authn, audit), m, audit
+}
+
+func do(s *Server, token, method, path, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, r)
+	return rec
+}
+
+func TestKiosksCreateOnlyTheirOrderTypes(t *testing.T) {
+	s, m, audit := newTestServer(t)
+
+	rec := do(s, "kiosk", http.MethodPost, "/orders", `{"type":"Normal","contact":{"Phone":"+60123456789"}}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rec.Code, rec.Body)
+	}
+	var snap order.OrderSnapshot
+	if err := json.Unmarshal(rec.Body.Bytes(), &snap); err != nil || snap.Type != order.OrderTypeNormal || snap.Contact.Phone != "+60123456789" {
+		t.Fatalf("Unexpected order: %+v, %v", snap, err)
+	}
+
+	if rec := do(s, "kiosk", http.MethodPost, "/orders", `{"type":"VIP"}`); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected a kiosk to be forbidden VIP orders, got %d", rec.Code)
+	}
+	if rec := do(s, "vip", http.MethodPost, "/orders", `{"type":"VIP"}`); rec.Code != http.StatusCreated {
+		t.Errorf("Expected a VIP kiosk to create VIP orders, got %d", rec.Code)
+	}
+	if rec := do(s, "manager", http.MethodPost, "/orders", `{"type":"Catering"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown type, got %d", rec.Code)
+	}
+	if got := m.Orders.GetTotalCount(); got != 2 {
+		t.Errorf("Expected 2 orders created, got %d", got)
+	}
+
+	entries := audit.Entries()
+	if len(entries) != 4 {
+		t.Fatalf("Expected every call audited, got %d entries", len(entries))
+	}
+	if e := entries[1]; e.Principal != "kiosk-1" || e.Action != "orders.create.vip" || e.Allowed || e.Status != http.StatusForbidden {
+		t.Errorf("Unexpected audit entry for the denied call: %+v", e)
+	}
+	if e := entries[0]; e.Target != "1001" || !e.Allowed || e.Status != http.StatusCreated {
+		t.Errorf("Unexpected audit entry for the created order: %+v", e)
+	}
+}
+
+func TestBotControlNeedsManager(t *testing.T) {
+	s, m, audit := newTestServer(t)
+
+	if rec := do(s, "", http.MethodPost, "/bots", `{"type":"FAST"}`); rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without a token, got %d", rec.Code)
+	}
+	if rec := do(s, "auditor", http.MethodPost, "/bots", `{"type":"FAST"}`); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected an auditor to be forbidden to add bots, got %d", rec.Code)
+	}
+	rec := do(s, "manager", http.MethodPost, "/bots", `{"type":"FAST"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rec.Code, rec.Body)
+	}
+	var added struct{ ID string }
+	if err := json.Unmarshal(rec.Body.Bytes(), &added); err != nil {
+		t.Fatal(err)
+	}
+
+	if rec := do(s, "manager", http.MethodPost, "/bots/"+added.ID+"/pause", `{"immediate":true}`); rec.Code != http.StatusNoContent {
+		t.Errorf("Expected 204 for pause, got %d: %s", rec.Code, rec.Body)
+	}
+	if rec := do(s, "kiosk", http.MethodDelete, "/bots/"+added.ID, ""); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected a kiosk to be forbidden to remove bots, got %d", rec.Code)
+	}
+	if rec := do(s, "manager", http.MethodDelete, "/bots/"+added.ID, ""); rec.Code != http.StatusNoContent {
+		t.Errorf("Expected 204 for remove, got %d", rec.Code)
+	}
+	if rec := do(s, "manager", http.MethodDelete, "/bots/"+added.ID, ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a removed bot, got %d", rec.Code)
+	}
+	if m.BotPool.GetBot(added.ID) != nil {
+		t.Error("Expected the bot removed")
+	}
+
+	if rec := do(s, "auditor", http.MethodGet, "/summary", ""); rec.Code != http.StatusOK {
+		t.Errorf("Expected an auditor to read the summary, got %d", rec.Code)
+	}
+	rec = do(s, "auditor", http.MethodGet, "/audit", "")
+	var entries []auth.AuditEntry
+	if err := json.Unmarshal(rec.Body.Bytes(), &entries); err != nil || len(entries) != 8 {
+		t.Fatalf("Expected 8 earlier calls in the audit log, got %d (%v)", len(entries), err)
+	}
+	if e := entries[0]; e.Principal != "" || e.Status != http.StatusUnauthorized || e.Error == "" {
+		t.Errorf("Unexpected audit entry for the unauthenticated call: %+v", e)
+	}
+	if got := len(audit.Entries()); got != 9 {
+		t.Errorf("Expected the audit read itself audited, got %d entries", got)
+	}
+}
+
+func TestWebhookAdminIsProtected(t *testing.T) {
+	s, _, _ := newTestServer(t, manager.WithWebhooks())
+
+	body := `{"url":"https://partner.example.com/hook","secret":"s3cret"}`
+	if rec := do(s, "auditor", http.MethodPost, "/webhooks", body); rec.Code != http.StatusForbidden {
+		t.Errorf("Expected an auditor to be forbidden to register webhooks, got %d", rec.Code)
+	}
+	if rec := do(s, "manager", http.MethodPost, "/webhooks", body); rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rec.Code, rec.Body)
+	}
+	if rec := do(s, "auditor", http.MethodGet, "/webhooks", ""); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "partner.example.com") {
+		t.Errorf("Expected an auditor to list webhooks, got %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestEveryControlRouteChecksTheRole(t *testing.T) {
+	s, m, _ := newTestServer(t)
+	botID, _ := m.AddBot(bot.BotTypeSlow)
+	m.OrderQueue.SetPaused(true)
+	m.AddOrder(order.OrderTypeNormal)
+	later := time.Now().Add(time.Hour).Format(time.RFC3339)
+
+	routes := []struct {
+		method, path, body string
+		want               int
+	}{
+		{http.MethodGet, "/queue", "", http.StatusOK},
+		{http.MethodPost, "/queue/resume", "", http.StatusNoContent},
+		{http.MethodPost, "/queue/pause", "", http.StatusNoContent},
+		{http.MethodGet, "/orders/1001/eta", "", http.StatusOK},
+		{http.MethodGet, "/orders/scheduled", "", http.StatusOK},
+		{http.MethodPost, "/orders/scheduled", `{"type":"VIP","not_before":"` + later + `"}`, http.StatusCreated},
+		{http.MethodPost, "/orders/pinned", `{"type":"Normal","affinity":{"BotID":"` + botID + `","Hard":true}}`, http.StatusCreated},
+		{http.MethodPost, "/orders/batch", `{"orders":[{"type":"VIP"},{"type":"Normal"}]}`, http.StatusCreated},
+		{http.MethodPatch, "/orders/1001", `{"Type":"VIP"}`, http.StatusOK},
+		{http.MethodPost, "/bots/" + botID + "/dedicate", `{"dedicated":true}`, http.StatusNoContent},
+		{http.MethodPost, "/bots/" + botID + "/maintenance", "", http.StatusNoContent},
+	}
+	for _, r := range routes {
+		if rec := do(s, "kiosk", r.method, r.path, r.body); rec.Code != http.StatusForbidden {
+			t.Errorf("%s %s: expected a kiosk to be forbidden, got %d: %s", r.method, r.path, rec.Code, rec.Body)
+		}
+		if rec := do(s, "manager", r.method, r.path, r.body); rec.Code != r.want {
+			t.Errorf("%s %s: expected %d for a manager, got %d: %s", r.method, r.path, r.want, rec.Code, rec.Body)
+		}
+	}
+	if got := m.Orders.GetTotalCount(); got != 5 {
+		t.Errorf("Expected only the manager's orders created, got %d orders", got)
+	}
+}
+
+func TestKioskOrdersCannotEscalate(t *testing.T) {
+	s, m, _ := newTestServer(t)
+
+	cases := []struct{ path, body string }{
+		{"/orders", `{"type":"Normal","contact":{"WebhookURL":"https://partner.example.com/hook"}}`},
+		{"/orders/batch", `{"orders":[{"type":"Normal"},{"type":"VIP"}]}`},
+		{"/orders/batch", `{"orders":[{"type":"Normal","affinity":{"BotType":"FAST","Hard":true}}]}`},
+		{"/orders/batch", `{"orders":[{"type":"Normal","contact":{"WebhookURL":"https://partner.example.com/hook"}}]}`},
+	}
+	for _, c := range cases {
+		if rec := do(s, "kiosk", http.MethodPost, c.path, c.body); rec.Code != http.StatusForbidden {
+			t.Errorf("POST %s %s: expected 403, got %d", c.path, c.body, rec.Code)
+		}
+	}
+	if rec := do(s, "kiosk", http.MethodPost, "/orders/batch", `{"orders":[{"type":"Normal"},{"type":"Normal"}]}`); rec.Code != http.StatusCreated {
+		t.Errorf("Expected a kiosk to create a batch of Normal orders, got %d: %s", rec.Code, rec.Body)
+	}
+	if rec := do(s, "manager", http.MethodPost, "/orders", `{"type":"Normal","contact":{"WebhookURL":"http://10.0.0.5/hook"}}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a private webhook URL, got %d", rec.Code)
+	}
+	if got := m.Orders.GetTotalCount(); got != 2 {
+		t.Errorf("Expected 2 orders created, got %d", got)
+	}
+}
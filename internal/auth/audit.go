@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"encoding/json"
+	"io"
+	"slices"
+	"sync"
+	"time"
+)
+
+// DefaultAuditHistory is how many entries an AuditLog keeps in memory.
+const DefaultAuditHistory = 1000
+
+// AuditEntry records one API call: who made it, what they tried and how it ended.
+type AuditEntry struct {
+	At time.Time `json:"at"`
+	// Principal and Role are empty if the caller could not be authenticated.
+	Principal string `json:"principal,omitempty"`
+	Role      Role   `json:"role,omitempty"`
+	// Route is the API route called, e.g. "DELETE /bots/{id}".
+	Route string `json:"route"`
+	// Action is empty if the call was rejected before it was authorised.
+	Action Action `json:"action,omitempty"`
+	// Target is the object acted on, e.g. a bot or order ID.
+	Target  string `json:"target,omitempty"`
+	Allowed bool   `json:"allowed"`
+	Status  int    `json:"status"`
+	Error   string `json:"error,omitempty"`
+}
+
+// AuditLog appends entries as JSON lines to a writer, e.g. an audit file,
+// and keeps the latest ones in memory.
+type AuditLog struct {
+	enc     *json.Encoder
+	entries []AuditEntry
+	history int
+	// err is the first write error, reported by Err.
+	err error
+	mu  sync.Mutex
+}
+
+// NewAuditLog returns an AuditLog writing to w, or only keeping entries in
+// memory if w is nil.
+func NewAuditLog(w io.Writer) *AuditLog {
+	l := &AuditLog{history: DefaultAuditHistory}
+	if w != nil {
+		l.enc = json.NewEncoder(w)
+	}
+	return l
+}
+
+// Record appends an entry.
+func (l *AuditLog) Record(e AuditEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, e)
+	if over := len(l.entries) - l.history; over > 0 {
+		l.entries = slices.Delete(l.entries, 0, over)
+	}
+	if l.enc != nil {
+		if err := l.enc.Encode(e); err != nil && l.err == nil {
+			l.err = err
+		}
+	}
+}
+
+// Entries returns the latest entries, oldest first.
+func (l *AuditLog) Entries() []AuditEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]AuditEntry{}, l.entries...)
+}
+
+// Err returns the first error encountered while writing entries.
+func (l *AuditLog) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.err
+}
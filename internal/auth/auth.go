@@ -0,0 +1,173 @@
+// Package auth decides who may call the restaurant's API. Callers present an
+// API token from a local token file; each token belongs to a named principal
+// with one role, and each role is allowed a fixed set of actions.
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/feedme/order-controller/internal/order"
+)
+
+var (
+	// ErrUnauthenticated is returned for a missing or unknown token.
+	ErrUnauthenticated = errors.New("unauthenticated")
+	// ErrForbidden is returned when a principal's role does not allow an action.
+	ErrForbidden = errors.New("forbidden")
+	// ErrInvalidConfig is returned for a malformed token file.
+	ErrInvalidConfig = errors.New("invalid auth config")
+)
+
+// Role is what a principal is for, e.g. a self-service kiosk.
+type Role string
+
+const (
+	// RoleKiosk is a customer kiosk; it may only create Normal orders.
+	RoleKiosk Role = "kiosk"
+	// RoleVIPKiosk is a VIP kiosk; it may create Normal and VIP orders.
+	RoleVIPKiosk Role = "vip-kiosk"
+	// RoleManager runs the restaurant: orders, bots and webhooks.
+	RoleManager Role = "manager"
+	// RoleAuditor may read everything, including the audit log, and change nothing.
+	RoleAuditor Role = "auditor"
+)
+
+// Action is an operation a role may be allowed to perform.
+type Action string
+
+const (
+	ActionRead              Action = "read"
+	ActionReadAudit         Action = "audit.read"
+	ActionCreateNormalOrder Action = "orders.create.normal"
+	ActionCreateVIPOrder    Action = "orders.create.vip"
+	ActionCreateUrgentOrder Action = "orders.create.urgent"
+	// ActionManageOrders covers cancelling, modifying, pinning and handing out
+	// orders, and pausing the order queue.
+	ActionManageOrders Action = "orders.manage"
+	// ActionSetContactWebhook allows an order's customer contact to name a
+	// webhook URL, which the restaurant then calls.
+	ActionSetContactWebhook Action = "orders.contact.webhook"
+	// ActionManageBots covers adding, removing, pausing, resuming, dedicating
+	// and servicing bots.
+	ActionManageBots     Action = "bots.manage"
+	ActionManageWebhooks Action = "webhooks.manage"
+)
+
+// Permissions lists the actions each role is allowed.
+var Permissions = map[Role][]Action{
+	RoleKiosk:    {ActionCreateNormalOrder},
+	RoleVIPKiosk: {ActionCreateNormalOrder, ActionCreateVIPOrder},
+	RoleManager: {
+		ActionRead, ActionReadAudit,
+		ActionCreateNormalOrder, ActionCreateVIPOrder, ActionCreateUrgentOrder,
+		ActionManageOrders, ActionSetContactWebhook, ActionManageBots, ActionManageWebhooks,
+	},
+	RoleAuditor: {ActionRead, ActionReadAudit},
+}
+
+// CreateOrderAction returns the action needed to create an order of type t.
+// Unknown types need the Urgent permission, so only managers get to see the
+// validation error.
+func CreateOrderAction(t order.OrderTypeEnum) Action {
+	switch t {
+	case order.OrderTypeNormal:
+		return ActionCreateNormalOrder
+	case order.OrderTypeVIP:
+		return ActionCreateVIPOrder
+	}
+	return ActionCreateUrgentOrder
+}
+
+// Principal is an authenticated caller.
+type Principal struct {
+	Name string
+	Role Role
+}
+
+// Can reports whether the principal's role allows the action.
+func (p Principal) Can(a Action) bool {
+	return slices.Contains(Permissions[p.Role], a)
+}
+
+// Authorize returns an error wrapping ErrForbidden unless the principal may
+// perform the action.
+func (p Principal) Authorize(a Action) error {
+	if !p.Can(a) {
+		return fmt.Errorf("%w: %s (%s) may not %s", ErrForbidden, p.Name, p.Role, a)
+	}
+	return nil
+}
+
+// tokenFile is the format of the token file:
+//
+//	{"tokens": [{"name": "kiosk-1", "role": "kiosk", "token": "..."}]}
+type tokenFile struct {
+	Tokens []tokenEntry `json:"tokens"`
+}
+
+type tokenEntry struct {
+	Name  string `json:"name"`
+	Role  Role   `json:"role"`
+	Token string `json:"token"`
+}
+
+// Authenticator maps API tokens to principals. Tokens are kept only as
+// SHA-256 hashes.
+type Authenticator struct {
+	principals map[[sha256.Size]byte]Principal
+}
+
+// LoadTokens reads a token file and returns an Authenticator for it.
+func LoadTokens(path string) (*Authenticator, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("load tokens: %w", err)
+	}
+	var f tokenFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidConfig, path, err)
+	}
+	a := &Authenticator{principals: make(map[[sha256.Size]byte]Principal, len(f.Tokens))}
+	for i, e := range f.Tokens {
+		if err := a.add(e); err != nil {
+			return nil, fmt.Errorf("%w: %s: token %d: %v", ErrInvalidConfig, path, i, err)
+		}
+	}
+	return a, nil
+}
+
+// add registers one token file entry.
+func (a *Authenticator) add(e tokenEntry) error {
+	switch {
+	case e.Name == "":
+		return errors.New("missing name")
+	case e.Token == "":
+		return fmt.Errorf("%s: missing token", e.Name)
+	}
+	if _, ok := Permissions[e.Role]; !ok {
+		return fmt.Errorf("%s: unknown role %q", e.Name, e.Role)
+	}
+	h := sha256.Sum256([]byte(e.Token))
+	if prev, ok := a.principals[h]; ok {
+		return fmt.Errorf("%s: token already used by %s", e.Name, prev.Name)
+	}
+	a.principals[h] = Principal{Name: e.Name, Role: e.Role}
+	return nil
+}
+
+// Authenticate returns the principal a token belongs to.
+func (a *Authenticator) Authenticate(token string) (Principal, error) {
+	if token == "" {
+		return Principal{}, fmt.Errorf("%w: no token", ErrUnauthenticated)
+	}
+	p, ok := a.principals[sha256.Sum256([]byte(token))]
+	if !ok {
+		return Principal{}, fmt.Errorf("%w: unknown token", ErrUnauthenticated)
+	}
+	return p, nil
+}
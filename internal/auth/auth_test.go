@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/feedme/order-controller/internal/order"
+)
+
+func writeTokens(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadTokensAndAuthenticate(t *testing.T) {
+	a, err := LoadTokens(writeTokens(t, `{"tokens": [
+		{"name": "kiosk-1", "role": "kiosk", "token": "k1"},
+		{"name": "alice", "role": "manager", "token": "m1"}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := a.Authenticate("m1")
+	if err != nil || p != (Principal{Name: "alice", Role: RoleManager}) {
+		t.Fatalf("Expected alice the manager, got %+v, %v", p, err)
+	}
+	for _, token := range []string{"", "nope"} {
+		if _, err := a.Authenticate(token); !errors.Is(err, ErrUnauthenticated) {
+			t.Errorf("Expected ErrUnauthenticated for %q, got %v", token, err)
+		}
+	}
+
+	invalid := []string{
+		`not json`,
+		`{"tokens": [{"name": "x", "role": "chef", "token": "t"}]}`,
+		`{"tokens": [{"name": "x", "role": "kiosk"}]}`,
+		`{"tokens": [{"name": "x", "role": "kiosk", "token": "t"}, {"name": "y", "role": "manager", "token": "t"}]}`,
+	}
+	for _, content := range invalid {
+		if _, err := LoadTokens(writeTokens(t, content)); !errors.Is(err, ErrInvalidConfig) {
+			t.Errorf("Expected ErrInvalidConfig for %s, got %v", content, err)
+		}
+	}
+}
+
+func TestRolePermissions(t *testing.T) {
+	cases := []struct {
+		role Role
+		typ  order.OrderTypeEnum
+		can  bool
+	}{
+		{RoleKiosk, order.OrderTypeNormal, true},
+		{RoleKiosk, order.OrderTypeVIP, false},
+		{RoleVIPKiosk, order.OrderTypeVIP, true},
+		{RoleVIPKiosk, order.OrderTypeUrgent, false},
+		{RoleManager, order.OrderTypeUrgent, true},
+		{RoleAuditor, order.OrderTypeNormal, false},
+	}
+	for _, tc := range cases {
+		p := Principal{Name: "p", Role: tc.role}
+		if got := p.Can(CreateOrderAction(tc.typ)); got != tc.can {
+			t.Errorf("%s creating %s: expected %v, got %v", tc.role, tc.typ, tc.can, got)
+		}
+	}
+
+	auditor := Principal{Name: "bob", Role: RoleAuditor}
+	if err := auditor.Authorize(ActionManageBots); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Expected an auditor to be forbidden to manage bots, got %v", err)
+	}
+	if err := auditor.Authorize(ActionReadAudit); err != nil {
+		t.Errorf("Expected an auditor to read the audit log, got %v", err)
+	}
+}
+
+func TestAuditLogWritesJSONLines(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewAuditLog(&buf)
+	l.Record(AuditEntry{Principal: "alice", Role: RoleManager, Route: "DELETE /bots/{id}", Action: ActionManageBots, Target: "B1", Allowed: true, Status: 204})
+	l.Record(AuditEntry{Route: "GET /summary", Status: 401, Error: "unauthenticated"})
+
+	if got := l.Entries(); len(got) != 2 || got[0].Target != "B1" {
+		t.Fatalf("Expected both entries kept, got %+v", got)
+	}
+	dec := json.NewDecoder(&buf)
+	var e AuditEntry
+	if err := dec.Decode(&e); err != nil || e.Principal != "alice" || !e.Allowed {
+		t.Errorf("Unexpected first line: %+v, %v", e, err)
+	}
+	e = AuditEntry{}
+	if err := dec.Decode(&e); err != nil || e.Status != 401 || e.Principal != "" {
+		t.Errorf("Unexpected second line: %+v, %v", e, err)
+	}
+}
@@ -2,6 +2,7 @@ package bot
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -12,7 +13,7 @@ func TestPool(t *testing.T) {
 	p := NewPool()
 
 	// Test AddBot
-	b1 := p.AddBot(BotTypeFast)
+	b1, _ := p.AddBot(BotTypeFast)
 	if len(b1.ID) != 3 {
 		t.Errorf("Expected bot ID length 3, got %d", len(b1.ID))
 	}
@@ -20,7 +21,7 @@ func TestPool(t *testing.T) {
 		t.Errorf("Expected 1 active bot, got %d", p.GetActiveBotsCount())
 	}
 
-	b2 := p.AddBot(BotTypeSlow)
+	b2, _ := p.AddBot(BotTypeSlow)
 	if b2.ID == "" {
 		t.Error("Expected b2 ID to not be empty")
 	}
@@ -42,8 +43,8 @@ func TestPool(t *testing.T) {
 }
 
 func TestProcessOrder(t *testing.T) {
-	b := &Bot{ID: "testbot", Status: BotStatusIdle}
-	ord := &order.Order{ID: 100, Priority: 10, Type: order.OrderTypeNormal}
+	b := NewBot("testbot", BotTypeFast)
+	ord := order.NewOrder(100, order.OrderTypeNormal, order.ActorUser)
 
 	ctx := context.Background()
 
@@ -58,7 +59,57 @@ func TestProcessOrder(t *testing.T) {
 	if completed {
 		t.Error("Expected order to be cancelled, but it completed")
 	}
-	if b.Status != BotStatusOffline {
-		t.Errorf("Expected bot status Offline after cancellation, got %v", b.Status)
+	if b.Status() != BotStatusOffline {
+		t.Errorf("Expected bot status Offline after cancellation, got %v", b.Status())
+	}
+}
+
+// fixedIDs returns the same IDs in sequence, simulating a generator that collides.
+type fixedIDs struct {
+	ids []string
+	i   int
+}
+
+func (f *fixedIDs) NextBotID() string {
+	id := f.ids[f.i]
+	f.i++
+	return id
+}
+
+func TestPoolRejectsDuplicateIDs(t *testing.T) {
+	p := NewPool(WithIDGenerator(&fixedIDs{ids: []string{"111", "111", "222"}}))
+
+	b1, _ := p.AddBot(BotTypeFast)
+	b2, _ := p.AddBot(BotTypeSlow)
+	if b1.ID == b2.ID {
+		t.Fatalf("Expected unique bot IDs, both got %s", b1.ID)
+	}
+
+	// Removing by ID must remove exactly the requested bot
+	removed := p.RemoveBot(b1.ID)
+	if removed != b1 {
+		t.Error("Expected RemoveBot to remove the first bot")
+	}
+	if p.GetActiveBotsCount() != 1 {
+		t.Errorf("Expected 1 active bot, got %d", p.GetActiveBotsCount())
+	}
+}
+
+// sameID always returns the same ID.
+type sameID string
+
+func (s sameID) NextBotID() string { return string(s) }
+
+func TestPoolGivesUpOnExhaustedIDGenerator(t *testing.T) {
+	p := NewPool(WithIDGenerator(sameID("111")))
+
+	if _, err := p.AddBot(BotTypeFast); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := p.AddBot(BotTypeFast); !errors.Is(err, ErrNoFreeBotID) || b != nil {
+		t.Fatalf("Expected ErrNoFreeBotID, got %v (%v)", b, err)
+	}
+	if p.GetActiveBotsCount() != 1 {
+		t.Errorf("Expected only the first bot added, got %d", p.GetActiveBotsCount())
 	}
 }
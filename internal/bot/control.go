@@ -0,0 +1,225 @@
+package bot
+
+import (
+	"fmt"
+
+	"github.com/feedme/order-controller/internal/order"
+)
+
+// Pause stops the bot from picking up new orders. A graceful pause lets the bot
+// finish its current order first; an immediate pause suspends the current order
+// on the spot and keeps it, with its remaining time, until Resume is called.
+// Pausing a paused bot is a no-op.
+func (b *Bot) Pause(immediate bool) error {
+	b.mu.Lock()
+	var t *StatusTransition
+	var err error
+	switch b.status {
+	case BotStatusIdle:
+		t, err = b.transitionLocked(BotStatusPaused, "paused", nil)
+	case BotStatusProcessing:
+		if immediate {
+			reason := fmt.Sprintf("suspended order %d", *b.currentOrderID)
+			t, err = b.transitionLocked(BotStatusPaused, reason, b.currentOrderID)
+		} else {
+			b.nextStatus = BotStatusPaused
+		}
+	case BotStatusStalled:
+		// Nothing is cooking, so an immediate pause waits for the hand-off too.
+		b.nextStatus = BotStatusPaused
+	case BotStatusPaused:
+	default:
+		err = &TransitionError{BotID: b.ID, From: b.status, To: BotStatusPaused}
+	}
+	b.mu.Unlock()
+
+	b.notify(t)
+	return err
+}
+
+// StartMaintenance takes the bot out of service for a cleaning cycle. A bot that
+// is processing or stalled finishes its current order first. A bot paused with a
+// suspended order releases that order back to the queue.
+func (b *Bot) StartMaintenance() error {
+	b.mu.Lock()
+	var t *StatusTransition
+	var err error
+	switch b.status {
+	case BotStatusIdle, BotStatusPaused:
+		t, err = b.transitionLocked(BotStatusMaintenance, "maintenance started", nil)
+	case BotStatusProcessing, BotStatusStalled:
+		b.nextStatus = BotStatusMaintenance
+	case BotStatusMaintenance:
+	default:
+		err = &TransitionError{BotID: b.ID, From: b.status, To: BotStatusMaintenance}
+	}
+	b.mu.Unlock()
+
+	b.notify(t)
+	return err
+}
+
+// Resume returns a paused or maintained bot to service. A bot holding a
+// suspended order continues cooking it; otherwise it becomes IDLE. Resuming a
+// processing or stalled bot withdraws any pending graceful pause or maintenance
+// request.
+func (b *Bot) Resume() error {
+	b.mu.Lock()
+	var t *StatusTransition
+	var err error
+	switch b.status {
+	case BotStatusPaused:
+		if b.currentOrderID != nil {
+			reason := fmt.Sprintf("resumed order %d", *b.currentOrderID)
+			t, err = b.transitionLocked(BotStatusProcessing, reason, b.currentOrderID)
+		} else {
+			t, err = b.transitionLocked(BotStatusIdle, "resumed", nil)
+		}
+	case BotStatusMaintenance:
+		t, err = b.transitionLocked(BotStatusIdle, "maintenance finished", nil)
+	case BotStatusProcessing, BotStatusStalled:
+		b.nextStatus = ""
+	case BotStatusIdle:
+	default:
+		err = &TransitionError{BotID: b.ID, From: b.status, To: BotStatusIdle}
+	}
+	b.mu.Unlock()
+
+	b.notify(t)
+	return err
+}
+
+// Preempt makes a processing bot drop its current order and become IDLE, so it
+// can pick up a more urgent one. The worker records the order's progress and
+// returns it to the caller as not completed. Bots with a pending graceful pause
+// or maintenance request cannot be preempted, since they will not take new work.
+func (b *Bot) Preempt() error {
+	b.mu.Lock()
+	var t *StatusTransition
+	var err error
+	if b.status != BotStatusProcessing || b.nextStatus != "" {
+		err = &TransitionError{BotID: b.ID, From: b.status, To: BotStatusIdle}
+	} else {
+		reason := fmt.Sprintf("preempted order %d", *b.currentOrderID)
+		t, err = b.transitionLocked(BotStatusIdle, reason, nil)
+	}
+	b.mu.Unlock()
+
+	b.notify(t)
+	return err
+}
+
+// Stall marks a bot that has finished cooking an order as unable to hand it
+// off, e.g. because the pickup shelf is full. A stalled bot takes no new work;
+// if it was paused or in maintenance, it returns to that status on Unstall.
+func (b *Bot) Stall(orderID int) error {
+	b.mu.Lock()
+	from := b.status
+	t, err := b.transitionLocked(BotStatusStalled, fmt.Sprintf("shelf full, holding order %d", orderID), nil)
+	if t != nil && from != BotStatusIdle {
+		b.nextStatus = from
+	}
+	b.mu.Unlock()
+
+	b.notify(t)
+	return err
+}
+
+// Unstall ends a stall once the order has been handed off, entering any status
+// requested while the bot was stalled, or IDLE otherwise.
+func (b *Bot) Unstall(orderID int) {
+	b.mu.Lock()
+	to := b.nextStatus
+	if to == "" {
+		to = BotStatusIdle
+	}
+	b.nextStatus = ""
+	var t *StatusTransition
+	if b.status == BotStatusStalled {
+		t, _ = b.transitionLocked(to, fmt.Sprintf("handed off order %d", orderID), nil)
+	}
+	b.mu.Unlock()
+
+	b.notify(t)
+}
+
+// SetDedicated reserves the bot for orders pinned or hinted to it (e.g. drive-thru
+// orders during rush hour). A dedicated bot ignores all other orders.
+func (b *Bot) SetDedicated(dedicated bool) {
+	b.mu.Lock()
+	b.dedicated = dedicated
+	b.mu.Unlock()
+	b.signal()
+}
+
+// Worker describes the bot to the order queue, so it only hands out orders the
+// bot is allowed to take.
+func (b *Bot) Worker() order.Worker {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return order.Worker{ID: b.ID, Type: string(b.Type), Dedicated: b.dedicated, ProcessingTime: b.ProcessingTime}
+}
+
+// IsAvailable reports whether the bot may pick up a new order.
+func (b *Bot) IsAvailable() bool {
+	return b.Status() == BotStatusIdle
+}
+
+// Wake returns a channel that receives a value whenever the bot's status
+// changes. Only the bot's own worker goroutine should receive from it.
+func (b *Bot) Wake() <-chan struct{} {
+	return b.wake
+}
+
+// signal wakes the bot's worker without blocking.
+func (b *Bot) signal() {
+	select {
+	case b.wake <- struct{}{}:
+	default:
+		// Signal already pending
+	}
+}
+
+// finishCooking marks ord COMPLETE and leaves PROCESSING in one step under
+// b.mu, so an immediate Pause cannot come between the two and leave the bot
+// PAUSED with an order that is already cooked. If such a Pause got in first,
+// the bot stays PAUSED but lets go of the order, so Resume makes it IDLE.
+func (b *Bot) finishCooking(ord *order.Order) {
+	b.mu.Lock()
+	_ = ord.Transition(order.OrderStatusComplete, order.BotActor(b.ID))
+	var t *StatusTransition
+	switch b.status {
+	case BotStatusProcessing:
+		to := b.nextStatus
+		if to == "" {
+			to = BotStatusIdle
+		}
+		b.nextStatus = ""
+		t, _ = b.transitionLocked(to, fmt.Sprintf("completed order %d", ord.ID), nil)
+	case BotStatusPaused:
+		if b.currentOrderID != nil && *b.currentOrderID == ord.ID {
+			b.currentOrderID = nil
+		}
+	}
+	b.mu.Unlock()
+
+	b.notify(t)
+}
+
+// finishOrder leaves PROCESSING once the current order is done, entering any
+// status requested by a graceful Pause or StartMaintenance, or IDLE otherwise.
+func (b *Bot) finishOrder(reason string) {
+	b.mu.Lock()
+	to := b.nextStatus
+	if to == "" {
+		to = BotStatusIdle
+	}
+	b.nextStatus = ""
+	var t *StatusTransition
+	if b.status == BotStatusProcessing {
+		t, _ = b.transitionLocked(to, reason, nil)
+	}
+	b.mu.Unlock()
+
+	b.notify(t)
+}
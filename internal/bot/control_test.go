@@ -0,0 +1,239 @@
+package bot
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/feedme/order-controller/internal/clock"
+	"github.com/feedme/order-controller/internal/order"
+)
+
+// startProcessing runs ProcessOrder in the background and returns a channel
+// that receives its result.
+func startProcessing(b *Bot, ord *order.Order) <-chan bool {
+	done := make(chan bool, 1)
+	go func() {
+		done <- b.ProcessOrder(context.Background(), ord, nil)
+	}()
+	return done
+}
+
+func waitForStatus(t *testing.T, b *Bot, status BotStatusEnum) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for b.Status() != status {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timeout waiting for status %s, got %s", status, b.Status())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestImmediatePauseKeepsRemainingTime(t *testing.T) {
+	b := NewBot("201", BotTypeFast)
+	b.ProcessingTime = 100 * time.Millisecond
+	ord := order.NewOrder(1, order.OrderTypeNormal, order.ActorUser)
+
+	start := time.Now()
+	done := startProcessing(b, ord)
+	waitForStatus(t, b, BotStatusProcessing)
+	time.Sleep(40 * time.Millisecond)
+
+	if err := b.Pause(true); err != nil {
+		t.Fatal(err)
+	}
+	if id, ok := b.CurrentOrderID(); !ok || id != 1 {
+		t.Errorf("Expected paused bot to hold order 1, got %d (%v)", id, ok)
+	}
+	if b.IsAvailable() {
+		t.Error("Paused bot must not be available")
+	}
+
+	// While suspended the order must not complete
+	select {
+	case <-done:
+		t.Fatal("Order completed while the bot was paused")
+	case <-time.After(150 * time.Millisecond):
+	}
+
+	if err := b.Resume(); err != nil {
+		t.Fatal(err)
+	}
+	resumed := time.Now()
+	select {
+	case completed := <-done:
+		if !completed {
+			t.Fatal("Expected order to complete after resume")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for resumed order")
+	}
+
+	// Only the remaining ~60ms should be cooked after resuming
+	if d := time.Since(resumed); d > 90*time.Millisecond {
+		t.Errorf("Expected resumed order to finish within remaining time, took %v", d)
+	}
+	if d := time.Since(start); d < 250*time.Millisecond {
+		t.Errorf("Expected pause to extend total time, took %v", d)
+	}
+	if b.Status() != BotStatusIdle {
+		t.Errorf("Expected bot to be IDLE after completion, got %s", b.Status())
+	}
+}
+
+func TestGracefulPauseFinishesCurrentOrder(t *testing.T) {
+	b := NewBot("202", BotTypeFast)
+	b.ProcessingTime = 30 * time.Millisecond
+	ord := order.NewOrder(2, order.OrderTypeNormal, order.ActorUser)
+
+	done := startProcessing(b, ord)
+	waitForStatus(t, b, BotStatusProcessing)
+	if err := b.Pause(false); err != nil {
+		t.Fatal(err)
+	}
+	if b.Status() != BotStatusProcessing {
+		t.Errorf("Expected bot to keep processing, got %s", b.Status())
+	}
+
+	if !<-done {
+		t.Fatal("Expected order to complete")
+	}
+	if b.Status() != BotStatusPaused {
+		t.Errorf("Expected bot to be PAUSED after finishing, got %s", b.Status())
+	}
+	if _, ok := b.CurrentOrderID(); ok {
+		t.Error("Expected no current order after graceful pause")
+	}
+}
+
+func TestMaintenanceReleasesSuspendedOrder(t *testing.T) {
+	b := NewBot("203", BotTypeFast)
+	b.ProcessingTime = time.Second
+	ord := order.NewOrder(3, order.OrderTypeNormal, order.ActorUser)
+
+	done := startProcessing(b, ord)
+	waitForStatus(t, b, BotStatusProcessing)
+	if err := b.Pause(true); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.StartMaintenance(); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case completed := <-done:
+		if completed {
+			t.Error("Expected order to be released, not completed")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for order release")
+	}
+	if b.Status() != BotStatusMaintenance {
+		t.Errorf("Expected MAINTENANCE, got %s", b.Status())
+	}
+
+	if err := b.Resume(); err != nil {
+		t.Fatal(err)
+	}
+	if b.Status() != BotStatusIdle {
+		t.Errorf("Expected IDLE after maintenance, got %s", b.Status())
+	}
+}
+
+func TestPoolExcludesPausedBotsFromCapacity(t *testing.T) {
+	p := NewPool()
+	b1, _ := p.AddBot(BotTypeFast)
+	b2, _ := p.AddBot(BotTypeFast)
+	p.AddBot(BotTypeSlow)
+
+	_ = b1.Pause(false)
+	_ = b2.StartMaintenance()
+
+	if got := p.GetActiveBotsCount(); got != 1 {
+		t.Errorf("Expected 1 active bot, got %d", got)
+	}
+	counts := p.CountByStatus()
+	if counts[BotStatusPaused] != 1 || counts[BotStatusMaintenance] != 1 || counts[BotStatusIdle] != 1 {
+		t.Errorf("Unexpected status counts: %v", counts)
+	}
+}
+
+func TestStallKeepsRequestedStatusUntilHandOff(t *testing.T) {
+	b := NewBot("205", BotTypeFast)
+
+	if err := b.Stall(7); err != nil {
+		t.Fatal(err)
+	}
+	if b.Status() != BotStatusStalled || b.IsAvailable() {
+		t.Fatalf("Expected an unavailable STALLED bot, got %s", b.Status())
+	}
+	if err := b.Pause(true); err != nil {
+		t.Fatal(err)
+	}
+	if b.Status() != BotStatusStalled {
+		t.Fatalf("Expected the pause to wait for the hand-off, got %s", b.Status())
+	}
+
+	b.Unstall(7)
+	if b.Status() != BotStatusPaused {
+		t.Errorf("Expected the bot PAUSED after the hand-off, got %s", b.Status())
+	}
+	if err := b.Resume(); err != nil || b.Status() != BotStatusIdle {
+		t.Errorf("Expected the bot IDLE after Resume, got %s (%v)", b.Status(), err)
+	}
+}
+
+func TestImmediatePauseAtCompletionDoesNotStrandBot(t *testing.T) {
+	// Each run pauses the moment the cooking timer fires; the worker may see
+	// the timer, the pause or both first.
+	for i := 0; i < 100; i++ {
+		v := clock.NewVirtual(time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC))
+		b := newBot("206", BotTypeFast, v)
+		b.ProcessingTime = time.Second
+		ord := order.NewOrder(1, order.OrderTypeNormal, order.ActorUser)
+
+		done := startProcessing(b, ord)
+		for v.PendingTimers() == 0 {
+			time.Sleep(time.Microsecond)
+		}
+		v.Advance(time.Second)
+		if err := b.Pause(true); err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case completed := <-done:
+			if !completed {
+				t.Fatal("Expected the cooked order to complete")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Timeout waiting for the order to complete")
+		}
+		if ord.Status() != order.OrderStatusComplete {
+			t.Fatalf("Expected the order COMPLETE, got %s", ord.Status())
+		}
+		if id, ok := b.CurrentOrderID(); ok {
+			t.Fatalf("Expected the bot to let go of the order, still holds %d", id)
+		}
+		if err := b.Resume(); err != nil || b.Status() != BotStatusIdle {
+			t.Fatalf("Expected the bot IDLE after Resume, got %s (%v)", b.Status(), err)
+		}
+	}
+}
+
+func TestStalledBotsCountAsActive(t *testing.T) {
+	p := NewPool()
+	b, _ := p.AddBot(BotTypeFast)
+	p.AddBot(BotTypeSlow)
+	if err := b.Stall(1001); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := p.GetActiveBotsCount(); got != 2 {
+		t.Errorf("Expected the stalled bot to count as active, got %d active", got)
+	}
+	if got := CountActive(p.CountByStatus()); got != p.GetActiveBotsCount() {
+		t.Errorf("Expected CountActive to agree with the pool, got %d", got)
+	}
+}
@@ -6,8 +6,8 @@ func TestForEach(t *testing.T) {
 	p := NewPool()
 	p.AddBot(BotTypeFast) // Idle
 	p.AddBot(BotTypeSlow) // Idle
-	b3 := p.AddBot(BotTypeFast) 
-	b3.Status = BotStatusOffline
+	b3, _ := p.AddBot(BotTypeFast) 
+	b3.Transition(BotStatusOffline, "test")
 
 	// Test case 1: Iterate all bots (empty status)
 	countAll := 0
@@ -22,8 +22,8 @@ func TestForEach(t *testing.T) {
 	countIdle := 0
 	p.ForEach(BotStatusIdle, func(b *Bot) {
 		countIdle++
-		if b.Status != BotStatusIdle {
-			t.Errorf("Expected bot status Idle, got %s", b.Status)
+		if b.Status() != BotStatusIdle {
+			t.Errorf("Expected bot status Idle, got %s", b.Status())
 		}
 	})
 	if countIdle != 2 {
@@ -34,8 +34,8 @@ func TestForEach(t *testing.T) {
 	countOffline := 0
 	p.ForEach(BotStatusOffline, func(b *Bot) {
 		countOffline++
-		if b.Status != BotStatusOffline {
-			t.Errorf("Expected bot status Offline, got %s", b.Status)
+		if b.Status() != BotStatusOffline {
+			t.Errorf("Expected bot status Offline, got %s", b.Status())
 		}
 	})
 	if countOffline != 1 {
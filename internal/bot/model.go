@@ -1,16 +1,36 @@
 package bot
 
-import "time"
+import (
+	"sync"
+	"time"
+
+	"github.com/feedme/order-controller/internal/clock"
+	"github.com/feedme/order-controller/internal/event"
+)
 
 type BotStatusEnum string
 
 const (
-	BotStatusIdle       BotStatusEnum = "IDLE"
-	BotStatusProcessing BotStatusEnum = "PROCESSING"
-	BotStatusOffline    BotStatusEnum = "OFFLINE"
-	BotStatusFaulted    BotStatusEnum = "FAULTED"
+	BotStatusIdle        BotStatusEnum = "IDLE"
+	BotStatusProcessing  BotStatusEnum = "PROCESSING"
+	BotStatusPaused      BotStatusEnum = "PAUSED"
+	BotStatusMaintenance BotStatusEnum = "MAINTENANCE"
+	BotStatusFaulted     BotStatusEnum = "FAULTED"
+	BotStatusStalled     BotStatusEnum = "STALLED"
+	BotStatusOffline     BotStatusEnum = "OFFLINE"
 )
 
+// IsActive reports whether a bot in this status is on duty: IDLE, PROCESSING
+// or STALLED, the last holding a cooked order until the pickup shelf has
+// room. Paused, maintained, faulted and offline bots are not.
+func (s BotStatusEnum) IsActive() bool {
+	switch s {
+	case BotStatusIdle, BotStatusProcessing, BotStatusStalled:
+		return true
+	}
+	return false
+}
+
 type BotTypeEnum string
 
 const (
@@ -24,12 +44,133 @@ var ProcessingTimeMap = map[BotTypeEnum]time.Duration{
 }
 
 type Bot struct {
-	ID             string
-	Status         BotStatusEnum
-	Type           BotTypeEnum
-	CurrentOrderID *int
-	
+	ID   string
+	Type BotTypeEnum
+	// ProcessingTime is how long the bot needs to cook one order. It is fixed
+	// when the bot is created.
+	ProcessingTime time.Duration
+
+	// status, currentOrderID and history are guarded by mu; use the accessor
+	// methods or Snapshot to read them from other goroutines.
+	status         BotStatusEnum
+	currentOrderID *int
+	// nextStatus is the status to enter once the current order completes
+	// (PAUSED or MAINTENANCE after a graceful request). Empty means IDLE.
+	nextStatus BotStatusEnum
+	// cookLeft is the cooking time the current order still needs, measured
+	// from cookingSince. cookingSince is zero while the order is suspended.
+	cookLeft     time.Duration
+	cookingSince time.Time
+	// dedicated bots only take orders whose affinity matches them.
+	dedicated bool
+	history   []StatusTransition
+	mu        sync.Mutex
+	// wake is signalled on every status change so the bot's worker re-evaluates
+	// what it should be doing.
+	wake chan struct{}
+	// bus receives a BotStatusChanged event for every transition. It may be nil.
+	bus *event.EventBus
+	// observer is called with every transition. It may be nil.
+	observer func(StatusTransition)
+	// clock times cooking and stamps transitions.
+	clock clock.Clock
+
 	// Business context consideration
 	// Bot Model
 	// Bot capabilities [Burger, Fench Fries, Fried Chicken]
 }
+
+// BotSnapshot is an immutable copy of a bot's state at a point in time.
+type BotSnapshot struct {
+	ID             string
+	Type           BotTypeEnum
+	ProcessingTime time.Duration
+	Status         BotStatusEnum
+	// CurrentOrderID is nil unless the bot is PROCESSING or PAUSED while
+	// holding a suspended order.
+	CurrentOrderID *int
+	// TimeRemaining is how much longer the current order needs on this bot.
+	TimeRemaining time.Duration
+}
+
+// NewBot returns an Idle bot of the given type. Its processing time is taken from
+// ProcessingTimeMap, and its history starts with the creation transition.
+func NewBot(id string, botType BotTypeEnum) *Bot {
+	return newBot(id, botType, clock.Real)
+}
+
+// newBot is NewBot with the clock the bot runs on.
+func newBot(id string, botType BotTypeEnum, clk clock.Clock) *Bot {
+	duration, ok := ProcessingTimeMap[botType]
+	if !ok {
+		// Fallback to default if type not found (should not happen with proper initialization)
+		duration = 10 * time.Second
+	}
+	return &Bot{
+		ID:             id,
+		Type:           botType,
+		ProcessingTime: duration,
+		status:         BotStatusIdle,
+		wake:           make(chan struct{}, 1),
+		clock:          clk,
+		history: []StatusTransition{{
+			BotID:  id,
+			To:     BotStatusIdle,
+			Reason: "created",
+			At:     clk.Now(),
+		}},
+	}
+}
+
+// CurrentOrderID returns the ID of the order being processed, if any.
+func (b *Bot) CurrentOrderID() (int, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.currentOrderID == nil {
+		return 0, false
+	}
+	return *b.currentOrderID, true
+}
+
+// Snapshot returns a consistent copy of the bot's current state.
+func (b *Bot) Snapshot() BotSnapshot {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s := BotSnapshot{
+		ID:             b.ID,
+		Type:           b.Type,
+		ProcessingTime: b.ProcessingTime,
+		Status:         b.status,
+	}
+	if b.currentOrderID != nil {
+		id := *b.currentOrderID
+		s.CurrentOrderID = &id
+		s.TimeRemaining = b.timeRemainingLocked()
+	}
+	return s
+}
+
+// setCooking records that the current order needs left more cooking time.
+// running is false while the order is suspended, freezing the countdown.
+func (b *Bot) setCooking(left time.Duration, running bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.cookLeft = left
+	b.cookingSince = time.Time{}
+	if running {
+		b.cookingSince = b.clock.Now()
+	}
+}
+
+// timeRemainingLocked returns how much cooking the current order still needs.
+// The caller must hold b.mu.
+func (b *Bot) timeRemainingLocked() time.Duration {
+	left := b.cookLeft
+	if !b.cookingSince.IsZero() {
+		left -= b.clock.Since(b.cookingSince)
+	}
+	if left < 0 {
+		left = 0
+	}
+	return left
+}
@@ -1,39 +1,119 @@
 package bot
 
 import (
+	"errors"
 	"sync"
+	"time"
 
-	"github.com/feedme/order-controller/internal/utils"
+	"github.com/feedme/order-controller/internal/clock"
+	"github.com/feedme/order-controller/internal/event"
+	"github.com/feedme/order-controller/internal/idgen"
 )
 
+// DefaultBotIDLength is the number of digits in bot IDs issued by the default generator.
+const DefaultBotIDLength = 3
+
+// maxBotIDAttempts bounds how many IDs AddBot draws before giving up on a
+// generator that keeps handing out IDs already in use.
+const maxBotIDAttempts = 100
+
+// ErrNoFreeBotID is returned by AddBot when the ID generator only hands out
+// IDs that are already in use.
+var ErrNoFreeBotID = errors.New("bot ID generator returned no unused ID")
+
 // Pool manages a collection of bot workers and provides thread-safe operations
 // for adding, removing, and counting active bots.
 type Pool struct {
 	bots []*Bot
-	mu   sync.Mutex
+	ids  idgen.BotIDGenerator
+	bus  *event.EventBus
+	// observer is called with every transition of the pool's bots. It may be nil.
+	observer func(StatusTransition)
+	// processingTimes overrides ProcessingTimeMap for bots created by this pool.
+	processingTimes map[BotTypeEnum]time.Duration
+	clock           clock.Clock
+	mu              sync.Mutex
+}
+
+// PoolOption configures a Pool at construction time.
+type PoolOption func(*Pool)
+
+// WithIDGenerator overrides the generator used to assign bot IDs.
+func WithIDGenerator(ids idgen.BotIDGenerator) PoolOption {
+	return func(p *Pool) {
+		p.ids = ids
+	}
+}
+
+// WithEventBus makes every bot in the pool publish BotStatusChanged events to bus.
+func WithEventBus(bus *event.EventBus) PoolOption {
+	return func(p *Pool) {
+		p.bus = bus
+	}
+}
+
+// WithObserver calls fn with every status transition of the pool's bots,
+// before the transition's BotStatusChanged event is published. fn runs on the
+// goroutine making the transition, without the bot's lock held.
+func WithObserver(fn func(StatusTransition)) PoolOption {
+	return func(p *Pool) {
+		p.observer = fn
+	}
 }
 
-// NewPool initializes and returns a new empty bot Pool.
-func NewPool() *Pool {
-	return &Pool{
-		bots: make([]*Bot, 0),
+// WithProcessingTimes overrides ProcessingTimeMap for bots created by the pool.
+// Bot types missing from times keep their default processing time.
+func WithProcessingTimes(times map[BotTypeEnum]time.Duration) PoolOption {
+	return func(p *Pool) {
+		p.processingTimes = times
 	}
 }
 
-// AddBot creates a new bot with a random 6-character ID, initializes its status
-// to Idle, and returns the newly created Bot.
-func (p *Pool) AddBot(botType BotTypeEnum) *Bot {
+// WithClock sets the clock the pool's bots cook by. The default is clock.Real.
+func WithClock(c clock.Clock) PoolOption {
+	return func(p *Pool) {
+		p.clock = c
+	}
+}
+
+// NewPool initializes and returns a new empty bot Pool. By default bots receive
+// unique random IDs of DefaultBotIDLength digits.
+func NewPool(opts ...PoolOption) *Pool {
+	p := &Pool{
+		bots:  make([]*Bot, 0),
+		ids:   idgen.NewRandomBotIDs(DefaultBotIDLength),
+		clock: clock.Real,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// AddBot creates a new bot with an ID that is unique within the pool, initializes
+// its status to Idle, and returns the newly created Bot. It returns
+// ErrNoFreeBotID if the ID generator keeps handing out IDs already in use.
+func (p *Pool) AddBot(botType BotTypeEnum) (*Bot, error) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	newID := utils.GenerateRandomID(3)
-	newBot := &Bot{
-		ID:     newID,
-		Status: BotStatusIdle,
-		Type:   botType,
+	// Guard against custom generators handing out an ID already in use,
+	// which would make RemoveBot(id) remove the wrong bot.
+	newID := p.ids.NextBotID()
+	for attempts := 1; p.hasBot(newID); attempts++ {
+		if attempts == maxBotIDAttempts {
+			return nil, ErrNoFreeBotID
+		}
+		newID = p.ids.NextBotID()
+	}
+	b := newBot(newID, botType, p.clock)
+	b.bus = p.bus
+	b.observer = p.observer
+	if d, ok := p.processingTimes[botType]; ok {
+		b.ProcessingTime = d
 	}
-	p.bots = append(p.bots, newBot)
-	return newBot
+	p.bots = append(p.bots, b)
+	return b, nil
 }
 
 // RemoveBot looks for a bot with the specified ID and removes it from the pool.
@@ -71,34 +151,87 @@ func (p *Pool) RemoveBot(id string) *Bot {
 	// Remove from slice
 	p.bots = append(p.bots[:targetIndex], p.bots[targetIndex+1:]...)
 
-	// Set status to Offline to signal stoppage
-	targetBot.Status = BotStatusOffline
+	// Set status to Offline to signal stoppage. Every status may go Offline.
+	_ = targetBot.Transition(BotStatusOffline, "removed from pool")
 	return targetBot
 }
 
-// GetActiveBotsCount returns the number of bots currently in the pool
-// that are not marked as Offline.
-func (p *Pool) GetActiveBotsCount() int {
+// hasBot reports whether a bot with the given ID is in the pool. The caller must hold p.mu.
+func (p *Pool) hasBot(id string) bool {
+	for _, b := range p.bots {
+		if b.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// GetBot returns the bot with the given ID, or nil if it is not in the pool.
+func (p *Pool) GetBot(id string) *Bot {
 	p.mu.Lock()
 	defer p.mu.Unlock()
-
-	count := 0
 	for _, b := range p.bots {
-		if b.Status != BotStatusOffline {
-			count++
+		if b.ID == id {
+			return b
+		}
+	}
+	return nil
+}
+
+// GetActiveBotsCount returns the number of bots currently in the pool that
+// are on duty (see BotStatusEnum.IsActive).
+func (p *Pool) GetActiveBotsCount() int {
+	return CountActive(p.CountByStatus())
+}
+
+// CountActive returns how many of the bots counted by status, as returned by
+// CountByStatus, are on duty.
+func CountActive(counts map[BotStatusEnum]int) int {
+	active := 0
+	for status, n := range counts {
+		if status.IsActive() {
+			active += n
 		}
 	}
-	return count
+	return active
+}
+
+// CountByStatus returns the number of bots in the pool for each status.
+func (p *Pool) CountByStatus() map[BotStatusEnum]int {
+	counts := make(map[BotStatusEnum]int)
+	for _, b := range p.list() {
+		counts[b.Status()]++
+	}
+	return counts
 }
 
 // ForEach executes a function for every bot in the pool (thread-safe).
 // If status is provided, it only iterates over bots with that status.
+// The pool lock is not held while fn runs, so fn may call back into the pool.
 func (p *Pool) ForEach(status BotStatusEnum, fn func(*Bot)) {
-	p.mu.Lock()
-	defer p.mu.Unlock()
-	for _, b := range p.bots {
-		if status == "" || b.Status == status {
+	for _, b := range p.list() {
+		if status == "" || b.Status() == status {
 			fn(b)
 		}
 	}
 }
+
+// Snapshots returns a consistent copy of every bot's state, in the order the
+// bots were added.
+func (p *Pool) Snapshots() []BotSnapshot {
+	bots := p.list()
+	snapshots := make([]BotSnapshot, len(bots))
+	for i, b := range bots {
+		snapshots[i] = b.Snapshot()
+	}
+	return snapshots
+}
+
+// list returns a copy of the pool's bot slice.
+func (p *Pool) list() []*Bot {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	bots := make([]*Bot, len(p.bots))
+	copy(bots, p.bots)
+	return bots
+}
@@ -0,0 +1,154 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/feedme/order-controller/internal/event"
+)
+
+// ErrInvalidTransition is matched (via errors.Is) by every TransitionError.
+var ErrInvalidTransition = errors.New("invalid bot status transition")
+
+// allowedTransitions lists, for every status, the statuses a bot may move to.
+// STALLED is a bot holding a cooked order it cannot hand off because the
+// pickup shelf is full. OFFLINE is terminal: a removed bot never comes back.
+var allowedTransitions = map[BotStatusEnum][]BotStatusEnum{
+	BotStatusIdle:        {BotStatusProcessing, BotStatusPaused, BotStatusMaintenance, BotStatusStalled, BotStatusFaulted, BotStatusOffline},
+	BotStatusProcessing:  {BotStatusIdle, BotStatusPaused, BotStatusFaulted, BotStatusOffline},
+	BotStatusPaused:      {BotStatusIdle, BotStatusProcessing, BotStatusMaintenance, BotStatusStalled, BotStatusFaulted, BotStatusOffline},
+	BotStatusMaintenance: {BotStatusIdle, BotStatusStalled, BotStatusFaulted, BotStatusOffline},
+	BotStatusStalled:     {BotStatusIdle, BotStatusPaused, BotStatusMaintenance, BotStatusFaulted, BotStatusOffline},
+	BotStatusFaulted:     {BotStatusIdle, BotStatusMaintenance, BotStatusOffline},
+	BotStatusOffline:     {},
+}
+
+// StatusTransition records a single change of a bot's status.
+type StatusTransition struct {
+	BotID  string
+	From   BotStatusEnum
+	To     BotStatusEnum
+	Reason string
+	At     time.Time
+}
+
+// TransitionError is returned when a bot is asked to make a transition that
+// is not allowed from its current status.
+type TransitionError struct {
+	BotID string
+	From  BotStatusEnum
+	To    BotStatusEnum
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("bot %s: cannot transition from %s to %s", e.BotID, e.From, e.To)
+}
+
+// Is makes errors.Is(err, ErrInvalidTransition) true for any TransitionError.
+func (e *TransitionError) Is(target error) bool {
+	return target == ErrInvalidTransition
+}
+
+// CanTransition reports whether a bot may move from one status to another.
+func CanTransition(from, to BotStatusEnum) bool {
+	for _, s := range allowedTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Status returns the bot's current status.
+func (b *Bot) Status() BotStatusEnum {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.status
+}
+
+// Transition moves the bot to a new status, records it in the history and
+// publishes a BotStatusChanged event. Transitioning to the current status is a
+// no-op. Disallowed transitions return a *TransitionError and leave the bot unchanged.
+func (b *Bot) Transition(to BotStatusEnum, reason string) error {
+	b.mu.Lock()
+	t, err := b.transitionLocked(to, reason, nil)
+	b.mu.Unlock()
+
+	b.notify(t)
+	return err
+}
+
+// startOrder moves an IDLE bot to PROCESSING and records the order it picked up
+// in the same critical section, so readers never see one without the other.
+// Unlike Transition it refuses to start from any status other than IDLE, so a
+// paused bot never picks up new work.
+func (b *Bot) startOrder(orderID int) error {
+	b.mu.Lock()
+	if b.status != BotStatusIdle {
+		from := b.status
+		b.mu.Unlock()
+		return &TransitionError{BotID: b.ID, From: from, To: BotStatusProcessing}
+	}
+	t, err := b.transitionLocked(BotStatusProcessing, fmt.Sprintf("picked up order %d", orderID), &orderID)
+	b.mu.Unlock()
+
+	b.notify(t)
+	return err
+}
+
+// transitionLocked validates and applies a transition. currentOrder becomes the
+// bot's current order (nil clears it). It returns the recorded transition, or
+// nil if nothing changed. The caller must hold b.mu and pass the result to notify
+// after releasing it.
+func (b *Bot) transitionLocked(to BotStatusEnum, reason string, currentOrder *int) (*StatusTransition, error) {
+	from := b.status
+	if from == to {
+		return nil, nil
+	}
+	if !CanTransition(from, to) {
+		return nil, &TransitionError{BotID: b.ID, From: from, To: to}
+	}
+
+	t := StatusTransition{
+		BotID:  b.ID,
+		From:   from,
+		To:     to,
+		Reason: reason,
+		At:     b.clock.Now(),
+	}
+	b.status = to
+	b.currentOrderID = currentOrder
+	b.history = append(b.history, t)
+	return &t, nil
+}
+
+// notify passes t to the observer, publishes a BotStatusChanged event for it
+// and wakes the bot's worker so it re-evaluates its status. It does nothing if
+// t is nil.
+func (b *Bot) notify(t *StatusTransition) {
+	if t == nil {
+		return
+	}
+	if b.observer != nil {
+		b.observer(*t)
+	}
+	// Publish first so the transition's event precedes any event caused by
+	// the worker reacting to it.
+	if b.bus != nil {
+		b.bus.Publish(event.Event{
+			Type: event.BotStatusChanged,
+			Data: *t,
+		})
+	}
+	b.signal()
+}
+
+// History returns a copy of every status transition the bot has made, oldest first.
+func (b *Bot) History() []StatusTransition {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	history := make([]StatusTransition, len(b.history))
+	copy(history, b.history)
+	return history
+}
@@ -0,0 +1,86 @@
+package bot
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/feedme/order-controller/internal/event"
+)
+
+func TestBotTransitions(t *testing.T) {
+	b := NewBot("101", BotTypeFast)
+
+	steps := []BotStatusEnum{
+		BotStatusProcessing,
+		BotStatusPaused,
+		BotStatusMaintenance,
+		BotStatusIdle,
+		BotStatusFaulted,
+		BotStatusOffline,
+	}
+	for _, to := range steps {
+		if err := b.Transition(to, "test"); err != nil {
+			t.Fatalf("Transition to %s: %v", to, err)
+		}
+	}
+
+	// Offline is terminal
+	err := b.Transition(BotStatusIdle, "revive")
+	if !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("Expected ErrInvalidTransition, got %v", err)
+	}
+	var te *TransitionError
+	if !errors.As(err, &te) || te.From != BotStatusOffline || te.To != BotStatusIdle {
+		t.Errorf("Unexpected transition error: %v", err)
+	}
+	if b.Status() != BotStatusOffline {
+		t.Errorf("Expected status to stay Offline, got %s", b.Status())
+	}
+
+	// Creation plus every successful step is recorded, oldest first
+	history := b.History()
+	if len(history) != len(steps)+1 {
+		t.Fatalf("Expected %d history entries, got %d", len(steps)+1, len(history))
+	}
+	if history[0].To != BotStatusIdle || history[0].Reason != "created" {
+		t.Errorf("Unexpected first history entry: %+v", history[0])
+	}
+	for i, to := range steps {
+		if history[i+1].To != to {
+			t.Errorf("History[%d]: expected %s, got %s", i+1, to, history[i+1].To)
+		}
+	}
+}
+
+func TestMaintenanceCannotProcess(t *testing.T) {
+	b := NewBot("102", BotTypeSlow)
+	if err := b.Transition(BotStatusMaintenance, "cleaning"); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Transition(BotStatusProcessing, "pick up"); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("Expected ErrInvalidTransition, got %v", err)
+	}
+}
+
+func TestBotStatusChangedEvent(t *testing.T) {
+	bus := event.NewEventBus()
+	ch := bus.Subscribe(event.BotStatusChanged)
+	p := NewPool(WithEventBus(bus))
+
+	b, _ := p.AddBot(BotTypeFast)
+	p.RemoveBot(b.ID)
+
+	select {
+	case ev := <-ch:
+		tr, ok := ev.Data.(StatusTransition)
+		if !ok {
+			t.Fatalf("Expected StatusTransition payload, got %T", ev.Data)
+		}
+		if tr.BotID != b.ID || tr.From != BotStatusIdle || tr.To != BotStatusOffline {
+			t.Errorf("Unexpected transition: %+v", tr)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Error("Timeout waiting for BotStatusChanged event")
+	}
+}
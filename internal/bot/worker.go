@@ -2,6 +2,7 @@ package bot
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/feedme/order-controller/internal/order"
@@ -10,42 +11,130 @@ import (
 
 // ProcessOrder handles the simulation of order processing (10 seconds per order).
 // It transitions the bot and order states through PROCESSING and COMPLETE/OFFLINE.
+// An immediate Pause suspends the countdown until Resume; the order is kept by
+// the bot and cooking continues with the time that was left.
+// Cooking progress is recorded on the order whenever it is interrupted, and an
+// order that already has progress only needs the remaining share of this bot's
+// processing time.
 // It returns true if the order was completed, and false if it was cancelled
-// by a context signal (e.g., bot shutdown).
+// by a context signal (e.g., bot shutdown), preempted, or released because the
+// bot went into maintenance while the order was suspended.
 func (b *Bot) ProcessOrder(ctx context.Context, ord *order.Order, onComplete func(*order.Order)) bool {
-	b.Status = BotStatusProcessing
-	b.CurrentOrderID = &ord.ID
-	ord.Status = order.OrderStatusProcessing
-	now := time.Now()
-	ord.ProcessedAt = &now
-
-	utils.Log("Bot #%s picked up Order •%d - Status: PROCESSING", b.ID, ord.ID)
-
-	// Determine processing duration from model map
-	duration, ok := ProcessingTimeMap[b.Type]
-	if !ok {
-		// Fallback to default if type not found (should not happen with proper initialization)
-		duration = 10 * time.Second
+	if err := b.startOrder(ord.ID); err != nil {
+		utils.LogError("Bot #%s cannot process Order •%d: %v", b.ID, ord.ID, err)
+		return false
+	}
+	if err := ord.Transition(order.OrderStatusProcessing, order.BotActor(b.ID)); err != nil {
+		utils.LogError("Bot #%s cannot process Order •%d: %v", b.ID, ord.ID, err)
+		b.finishOrder(fmt.Sprintf("rejected order %d", ord.ID))
+		return false
+	}
+
+	duration := b.ProcessingTime
+	// Orders interrupted on another bot resume with the work left, scaled to this bot's speed.
+	remaining := time.Duration((1 - ord.Progress()) * float64(duration))
+	cookTime := remaining
+
+	if remaining < duration {
+		utils.Log("Bot #%s picked up Order •%d - Status: PROCESSING (Resumed at %.0f%%, Time Remaining: %.2fs)",
+			b.ID, ord.ID, ord.Progress()*100, remaining.Seconds())
+	} else {
+		utils.Log("Bot #%s picked up Order •%d - Status: PROCESSING", b.ID, ord.ID)
 	}
 
 	// Simulate processing
-	select {
-	case <-time.After(duration):
-		ord.Status = order.OrderStatusComplete
-		doneAt := time.Now()
-		ord.CompletedAt = &doneAt
-		b.Status = BotStatusIdle
-		b.CurrentOrderID = nil
-		utils.Log("Bot #%s completed Order •%d - Status: COMPLETE (Processing time: %v)", b.ID, ord.ID, duration)
-		if onComplete != nil {
-			onComplete(ord)
+	for {
+		started := b.clock.Now()
+		b.setCooking(remaining, true)
+		timer := b.clock.NewTimer(remaining)
+
+		select {
+		case <-timer.Chan():
+			b.completeOrder(ord, cookTime, onComplete)
+			return true
+		case <-ctx.Done():
+			timer.Stop()
+			ord.AddProgress(b.progressFor(b.clock.Since(started)))
+			b.cancelOrder(ord)
+			return false
+		case <-b.wake:
+			timer.Stop()
+			elapsed := b.clock.Since(started)
+			remaining -= elapsed
+			ord.AddProgress(b.progressFor(elapsed))
+			if remaining <= 0 {
+				// Woken at the moment the order finished; it is cooked either way.
+				b.completeOrder(ord, cookTime, onComplete)
+				return true
+			}
+			if b.Status() == BotStatusProcessing {
+				// Not an immediate pause (e.g. a graceful request); keep cooking.
+				continue
+			}
+
+			b.setCooking(remaining, false)
+			if b.Status() == BotStatusIdle {
+				utils.Log("Bot #%s preempted Order •%d (Time Remaining: %.2fs)", b.ID, ord.ID, remaining.Seconds())
+				return false
+			}
+			if b.Status() == BotStatusPaused {
+				utils.Log("Bot #%s suspended Order •%d - Status: PAUSED (Time Remaining: %.2fs)", b.ID, ord.ID, remaining.Seconds())
+			}
+			if !b.waitForResume(ctx) {
+				if ctx.Err() != nil {
+					b.cancelOrder(ord)
+				} else {
+					utils.Log("Bot #%s released Order •%d - Status: %s", b.ID, ord.ID, b.Status())
+				}
+				return false
+			}
+			utils.Log("Bot #%s resumed Order •%d - Status: PROCESSING", b.ID, ord.ID)
+		}
+	}
+}
+
+// completeOrder marks ord COMPLETE and hands it to onComplete.
+func (b *Bot) completeOrder(ord *order.Order, cookTime time.Duration, onComplete func(*order.Order)) {
+	b.finishCooking(ord)
+	utils.Log("Bot #%s completed Order •%d - Status: COMPLETE (Processing time: %v)", b.ID, ord.ID, cookTime)
+	if onComplete != nil {
+		onComplete(ord)
+	}
+}
+
+// progressFor converts cooking time on this bot into a fraction of an order's work.
+func (b *Bot) progressFor(elapsed time.Duration) float64 {
+	if b.ProcessingTime <= 0 {
+		return 1
+	}
+	return float64(elapsed) / float64(b.ProcessingTime)
+}
+
+// cancelOrder records that the bot stopped working on ord because it was removed
+// or the system stopped.
+func (b *Bot) cancelOrder(ord *order.Order) {
+	// The pool usually marks the bot Offline before cancelling; this is then a no-op.
+	_ = b.Transition(BotStatusOffline, fmt.Sprintf("cancelled order %d", ord.ID))
+	utils.Log("Bot #%s cancelled Order •%d - Status: CANCELLED", b.ID, ord.ID)
+}
+
+// waitForResume blocks while the bot is PAUSED. It returns true once the bot is
+// PROCESSING again, and false if the context is cancelled or the bot moves to any
+// other status (e.g. MAINTENANCE).
+func (b *Bot) waitForResume(ctx context.Context) bool {
+	for {
+		switch b.Status() {
+		case BotStatusProcessing:
+			return true
+		case BotStatusPaused:
+		default:
+			return false
+		}
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-b.wake:
 		}
-		return true
-	case <-ctx.Done():
-		// Bot was removed or system stopped
-		b.Status = BotStatusOffline
-		b.CurrentOrderID = nil
-		utils.Log("Bot #%s cancelled Order •%d - Status: CANCELLED", b.ID, ord.ID)
-		return false
 	}
 }
@@ -0,0 +1,60 @@
+// Package clock abstracts time so the simulation can run against the wall
+// clock in production and a manually advanced virtual clock in tests.
+package clock
+
+import "time"
+
+// Clock tells the time and creates timers.
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+	// NewTimer returns a timer that fires once after d.
+	NewTimer(d time.Duration) Timer
+	// AfterFunc calls f in its own goroutine after d. The returned timer has a nil channel.
+	AfterFunc(d time.Duration, f func()) Timer
+	// NewTicker returns a ticker that fires every d.
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer is a single-shot timer created by a Clock.
+type Timer interface {
+	// Chan returns the channel the fire time is delivered on.
+	Chan() <-chan time.Time
+	// Stop prevents the timer from firing. It returns false if the timer had
+	// already fired or been stopped.
+	Stop() bool
+}
+
+// Ticker delivers the time at regular intervals.
+type Ticker interface {
+	Chan() <-chan time.Time
+	Stop()
+}
+
+// Real is the wall clock.
+var Real Clock = realClock{}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                  { return time.Now() }
+func (realClock) Since(t time.Time) time.Duration { return time.Since(t) }
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+func (realClock) AfterFunc(d time.Duration, f func()) Timer {
+	return realTimer{time.AfterFunc(d, f)}
+}
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+type realTimer struct{ *time.Timer }
+
+func (t realTimer) Chan() <-chan time.Time { return t.C }
+
+type realTicker struct{ *time.Ticker }
+
+func (t realTicker) Chan() <-chan time.Time { return t.C }
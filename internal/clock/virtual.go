@@ -0,0 +1,170 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Virtual is a Clock that only moves when told to. Timers fire one at a time,
+// earliest first (ties in creation order), so a test can let the system react
+// to each one before the next fires.
+type Virtual struct {
+	now    time.Time
+	timers []*virtualTimer
+	seq    int
+	mu     sync.Mutex
+}
+
+// NewVirtual returns a virtual clock reading start.
+func NewVirtual(start time.Time) *Virtual {
+	return &Virtual{now: start}
+}
+
+// virtualTimer backs timers, tickers and AfterFunc calls of a Virtual clock.
+type virtualTimer struct {
+	v      *Virtual
+	when   time.Time
+	seq    int
+	c      chan time.Time
+	fn     func()
+	period time.Duration
+}
+
+// Now returns the virtual time.
+func (v *Virtual) Now() time.Time {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.now
+}
+
+// Since returns the virtual time elapsed since t.
+func (v *Virtual) Since(t time.Time) time.Duration {
+	return v.Now().Sub(t)
+}
+
+// NewTimer returns a timer firing once the clock has advanced by d.
+func (v *Virtual) NewTimer(d time.Duration) Timer {
+	return v.add(d, 0, nil)
+}
+
+// AfterFunc calls f in its own goroutine once the clock has advanced by d.
+func (v *Virtual) AfterFunc(d time.Duration, f func()) Timer {
+	return v.add(d, 0, f)
+}
+
+// NewTicker returns a ticker firing every d of virtual time.
+func (v *Virtual) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+	return virtualTicker{v.add(d, d, nil)}
+}
+
+func (v *Virtual) add(d, period time.Duration, fn func()) *virtualTimer {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.seq++
+	t := &virtualTimer{v: v, when: v.now.Add(d), seq: v.seq, fn: fn, period: period}
+	if fn == nil {
+		t.c = make(chan time.Time, 1)
+	}
+	v.timers = append(v.timers, t)
+	return t
+}
+
+// Step fires the earliest timer due at or before until, moving the clock to
+// its deadline. It returns false, leaving the clock unchanged, if no timer is due.
+func (v *Virtual) Step(until time.Time) bool {
+	v.mu.Lock()
+	next := -1
+	for i, t := range v.timers {
+		if t.when.After(until) {
+			continue
+		}
+		if next < 0 || t.when.Before(v.timers[next].when) ||
+			(t.when.Equal(v.timers[next].when) && t.seq < v.timers[next].seq) {
+			next = i
+		}
+	}
+	if next < 0 {
+		v.mu.Unlock()
+		return false
+	}
+
+	t := v.timers[next]
+	if t.when.After(v.now) {
+		v.now = t.when
+	}
+	now := v.now
+	if t.period > 0 {
+		t.when = t.when.Add(t.period)
+	} else {
+		v.timers = append(v.timers[:next], v.timers[next+1:]...)
+	}
+	v.mu.Unlock()
+
+	t.fire(now)
+	return true
+}
+
+// Advance moves the clock forward by d, firing every timer that falls due on
+// the way in deadline order.
+func (v *Virtual) Advance(d time.Duration) {
+	until := v.Now().Add(d)
+	for v.Step(until) {
+	}
+	v.mu.Lock()
+	if until.After(v.now) {
+		v.now = until
+	}
+	v.mu.Unlock()
+}
+
+// PendingTimers returns the number of single-shot channel timers that have not
+// fired or been stopped. Tickers and AfterFunc calls are not counted.
+func (v *Virtual) PendingTimers() int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	n := 0
+	for _, t := range v.timers {
+		if t.period == 0 && t.fn == nil {
+			n++
+		}
+	}
+	return n
+}
+
+func (t *virtualTimer) fire(now time.Time) {
+	if t.fn != nil {
+		go t.fn()
+		return
+	}
+	// Like time.Ticker, drop ticks the receiver has not kept up with.
+	select {
+	case t.c <- now:
+	default:
+	}
+}
+
+func (t *virtualTimer) Chan() <-chan time.Time { return t.c }
+
+// Stop removes the timer. It returns false if it had already fired or been stopped.
+func (t *virtualTimer) Stop() bool {
+	v := t.v
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for i, other := range v.timers {
+		if other == t {
+			v.timers = append(v.timers[:i], v.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// virtualTicker adapts a periodic virtualTimer to the Ticker interface.
+type virtualTicker struct{ t *virtualTimer }
+
+func (t virtualTicker) Chan() <-chan time.Time { return t.t.c }
+func (t virtualTicker) Stop()                  { t.t.Stop() }
@@ -0,0 +1,57 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestVirtualFiresInDeadlineOrder(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	v := NewVirtual(start)
+
+	late := v.NewTimer(3 * time.Second)
+	early := v.NewTimer(time.Second)
+	tie := v.NewTimer(time.Second)
+	stopped := v.NewTimer(2 * time.Second)
+	if !stopped.Stop() {
+		t.Fatal("Expected Stop to report a pending timer")
+	}
+	if v.PendingTimers() != 3 {
+		t.Fatalf("Expected 3 pending timers, got %d", v.PendingTimers())
+	}
+
+	for i, want := range []Timer{early, tie, late} {
+		if !v.Step(start.Add(10 * time.Second)) {
+			t.Fatalf("Step %d: expected a timer to fire", i)
+		}
+		select {
+		case <-want.Chan():
+		default:
+			t.Fatalf("Step %d fired the wrong timer", i)
+		}
+	}
+	if v.Step(start.Add(10 * time.Second)) {
+		t.Error("Expected no timers left")
+	}
+	if got := v.Since(start); got != 3*time.Second {
+		t.Errorf("Expected clock at +3s, got +%v", got)
+	}
+}
+
+func TestVirtualAdvance(t *testing.T) {
+	v := NewVirtual(time.Time{})
+	ticker := v.NewTicker(time.Second)
+	defer ticker.Stop()
+	done := make(chan struct{})
+	v.AfterFunc(2*time.Second, func() { close(done) })
+
+	v.Advance(2500 * time.Millisecond)
+	<-done
+	if got := v.Since(time.Time{}); got != 2500*time.Millisecond {
+		t.Errorf("Expected clock at +2.5s, got +%v", got)
+	}
+	// The ticker fired at 1s and 2s but delivers at most one pending tick
+	if tick := <-ticker.Chan(); !tick.Equal(time.Time{}.Add(time.Second)) {
+		t.Errorf("Unexpected first tick %v", tick)
+	}
+}
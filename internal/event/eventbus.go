@@ -4,6 +4,9 @@ package event
 
 import (
 	"sync"
+	"time"
+
+	"github.com/feedme/order-controller/internal/clock"
 )
 
 // EventType defines the type of event being published in the system.
@@ -14,31 +17,72 @@ const (
 	OrderCreated EventType = "ORDER_CREATED"
 	// OrderPending is emitted when an order is queued and available for bots.
 	OrderPending EventType = "ORDER_PENDING"
+	// OrderReleased is emitted when a scheduled pre-order leaves the holding
+	// area and is queued for cooking.
+	OrderReleased EventType = "ORDER_RELEASED"
 	// OrderAssigned is emitted when an order is picked up by a bot worker.
 	OrderAssigned EventType = "ORDER_ASSIGNED"
 	// OrderCompleted is emitted when a bot successfully finishes processing an order.
 	OrderCompleted EventType = "ORDER_COMPLETED"
-	// OrderCancelled is emitted when an order processing is interrupted (e.g., bot removed).
+	// OrderReady is emitted when a completed order is put on the pickup shelf.
+	OrderReady EventType = "ORDER_READY"
+	// OrderCollected is emitted when a customer collects a ready order.
+	OrderCollected EventType = "ORDER_COLLECTED"
+	// OrderExpired is emitted when a cooked order is thrown away because it
+	// was not collected in time.
+	OrderExpired EventType = "ORDER_EXPIRED"
+	// OrderRequeued is emitted when an order processing is interrupted (e.g., bot removed)
+	// and the order is returned to the queue.
+	OrderRequeued EventType = "ORDER_REQUEUED"
+	// OrderPreempted is emitted when a bot drops its order to make room for a
+	// more urgent one. The displaced order is requeued ahead of its peers.
+	OrderPreempted EventType = "ORDER_PREEMPTED"
+	// OrderModified is emitted when the type or items of a pending order are
+	// changed. The payload carries the difference.
+	OrderModified EventType = "ORDER_MODIFIED"
+	// OrderCancelled is emitted when an order is withdrawn and will never be cooked.
 	OrderCancelled EventType = "ORDER_CANCELLED"
+	// BotStatusChanged is emitted whenever a bot moves to a new lifecycle status.
+	BotStatusChanged EventType = "BOT_STATUS_CHANGED"
 )
 
 // Event represents a system-wide notification containing a type and payload.
 type Event struct {
 	Type EventType
 	Data interface{}
+	// At is when the event was published. Publish sets it if left zero.
+	At time.Time
 }
 
 // EventBus handles the subscription and broadcasting of events.
 type EventBus struct {
 	subscribers map[EventType][]chan Event
-	mu          sync.RWMutex
+	// all receive every event, in publish order.
+	all   []chan Event
+	clock clock.Clock
+	mu    sync.RWMutex
+}
+
+// Option configures an EventBus at construction time.
+type Option func(*EventBus)
+
+// WithClock sets the clock used to stamp published events. The default is clock.Real.
+func WithClock(c clock.Clock) Option {
+	return func(eb *EventBus) {
+		eb.clock = c
+	}
 }
 
 // NewEventBus initializes and returns a new thread-safe EventBus.
-func NewEventBus() *EventBus {
-	return &EventBus{
+func NewEventBus(opts ...Option) *EventBus {
+	eb := &EventBus{
 		subscribers: make(map[EventType][]chan Event),
+		clock:       clock.Real,
+	}
+	for _, opt := range opts {
+		opt(eb)
 	}
+	return eb
 }
 
 // Subscribe returns a channel that will receive events of the specified type.
@@ -52,21 +96,41 @@ func (eb *EventBus) Subscribe(eventType EventType) chan Event {
 	return ch
 }
 
+// SubscribeAll returns a channel that receives every event regardless of type,
+// in the order they were published. size sets the channel buffer; recorders
+// that must not miss events should pick one larger than any expected burst.
+func (eb *EventBus) SubscribeAll(size int) chan Event {
+	eb.mu.Lock()
+	defer eb.mu.Unlock()
+
+	ch := make(chan Event, size)
+	eb.all = append(eb.all, ch)
+	return ch
+}
+
 // Publish broadcasts an event to all active subscribers of the event type.
 // If a subscriber's channel is full, the event is skipped for that subscriber
 // to prevent system-wide stalls (non-blocking).
 func (eb *EventBus) Publish(event Event) {
+	if event.At.IsZero() {
+		event.At = eb.clock.Now()
+	}
+
 	eb.mu.RLock()
 	defer eb.mu.RUnlock()
 
-	if subs, ok := eb.subscribers[event.Type]; ok {
-		for _, ch := range subs {
-			// Non-blocking publish to avoid slow consumers stalling the system
-			select {
-			case ch <- event:
-			default:
-				// If channel is full, we skip it or log (depending on requirements)
-			}
+	for _, ch := range eb.subscribers[event.Type] {
+		// Non-blocking publish to avoid slow consumers stalling the system
+		select {
+		case ch <- event:
+		default:
+			// If channel is full, we skip it or log (depending on requirements)
+		}
+	}
+	for _, ch := range eb.all {
+		select {
+		case ch <- event:
+		default:
 		}
 	}
 }
@@ -86,3 +150,17 @@ func (eb *EventBus) Unsubscribe(eventType EventType, ch chan Event) {
 		}
 	}
 }
+
+// UnsubscribeAll removes and closes a channel returned by SubscribeAll.
+func (eb *EventBus) UnsubscribeAll(ch chan Event) {
+	eb.mu.Lock()
+	defer eb.mu.Unlock()
+
+	for i, sub := range eb.all {
+		if sub == ch {
+			close(ch)
+			eb.all = append(eb.all[:i], eb.all[i+1:]...)
+			break
+		}
+	}
+}
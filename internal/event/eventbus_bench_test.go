@@ -0,0 +1,40 @@
+package event
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// BenchmarkPublishFanOut measures publishing one event to n subscribers that
+// drain their channels concurrently.
+func BenchmarkPublishFanOut(b *testing.B) {
+	for _, n := range []int{1, 10, 100} {
+		b.Run(fmt.Sprintf("subscribers=%d", n), func(b *testing.B) {
+			eb := NewEventBus()
+			var wg sync.WaitGroup
+			chs := make([]chan Event, n)
+			for i := range chs {
+				chs[i] = eb.Subscribe(OrderCreated)
+				wg.Add(1)
+				go func(ch chan Event) {
+					defer wg.Done()
+					for range ch {
+					}
+				}(chs[i])
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				eb.Publish(Event{Type: OrderCreated, Data: i})
+			}
+			b.StopTimer()
+
+			for _, ch := range chs {
+				eb.Unsubscribe(OrderCreated, ch)
+			}
+			wg.Wait()
+		})
+	}
+}
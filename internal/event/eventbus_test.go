@@ -185,3 +185,27 @@ func TestEventBusConcurrency(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestSubscribeAll(t *testing.T) {
+	eb := NewEventBus()
+	ch := eb.SubscribeAll(10)
+
+	eb.Publish(Event{Type: OrderCreated, Data: 1})
+	eb.Publish(Event{Type: BotStatusChanged, Data: 2})
+
+	for _, want := range []EventType{OrderCreated, BotStatusChanged} {
+		ev := <-ch
+		if ev.Type != want {
+			t.Errorf("expected %s, got %s", want, ev.Type)
+		}
+		if ev.At.IsZero() {
+			t.Error("expected Publish to stamp the event time")
+		}
+	}
+
+	eb.UnsubscribeAll(ch)
+	if _, ok := <-ch; ok {
+		t.Error("expected channel to be closed after UnsubscribeAll")
+	}
+	eb.Publish(Event{Type: OrderCreated})
+}
@@ -0,0 +1,227 @@
+// Package idgen provides pluggable ID generators for orders and bots.
+//
+// Orders carry two identifiers: an internal ID that is unique across every store
+// in the process, and a customer-facing number that may be prefixed with the
+// store ID and restarts every day. Bot IDs are short random strings that are
+// never handed out twice by the same generator.
+package idgen
+
+import (
+	"math/rand"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// OrderIDGenerator produces identifiers for newly created orders.
+type OrderIDGenerator interface {
+	// NextOrderID returns a unique internal ID and the customer-facing number
+	// to print on the receipt.
+	NextOrderID() (id int, number string)
+}
+
+// BatchOrderIDGenerator is implemented by order ID generators that can number
+// many orders at once, e.g. a catering batch.
+type BatchOrderIDGenerator interface {
+	OrderIDGenerator
+	// NextOrderIDs returns n consecutive internal IDs, starting at first, and
+	// their customer-facing numbers.
+	NextOrderIDs(n int) (first int, numbers []string)
+}
+
+// ResumableOrderIDGenerator is implemented by order ID generators that can
+// carry on a numbering restored from storage, e.g. an order journal replayed
+// after a restart.
+type ResumableOrderIDGenerator interface {
+	OrderIDGenerator
+	// Resume makes every later internal ID greater than lastID and continues
+	// today's customer-facing numbers after the numberedToday already issued.
+	Resume(lastID, numberedToday int)
+}
+
+// BotIDGenerator produces identifiers for newly created bots. Implementations
+// must eventually return an ID that is not already in use.
+type BotIDGenerator interface {
+	NextBotID() string
+}
+
+// Sequence is a thread-safe monotonically increasing counter. Sharing one
+// Sequence between several generators keeps their internal IDs unique.
+type Sequence struct {
+	last atomic.Int64
+}
+
+// NewSequence returns a Sequence whose first value is start.
+func NewSequence(start int) *Sequence {
+	s := &Sequence{}
+	s.last.Store(int64(start) - 1)
+	return s
+}
+
+// Next returns the next value of the sequence.
+func (s *Sequence) Next() int {
+	return int(s.last.Add(1))
+}
+
+// SkipPast makes the sequence return only values greater than v.
+func (s *Sequence) SkipPast(v int) {
+	for {
+		last := s.last.Load()
+		if last >= int64(v) || s.last.CompareAndSwap(last, int64(v)) {
+			return
+		}
+	}
+}
+
+// NextN reserves n consecutive values and returns the first of them.
+func (s *Sequence) NextN(n int) int {
+	return int(s.last.Add(int64(n))) - n + 1
+}
+
+// DailyOrderIDs numbers orders per store. Internal IDs come from a (possibly
+// shared) Sequence while the customer-facing number restarts at start on the
+// first order of each calendar day.
+type DailyOrderIDs struct {
+	prefix   string
+	start    int
+	internal *Sequence
+	now      func() time.Time
+	day      string
+	last     int
+	mu       sync.Mutex
+}
+
+// NewDailyOrderIDs returns a generator whose numbers look like "<prefix>-1001".
+// An empty prefix yields bare numbers. If internal is nil a private Sequence
+// starting at start is used, and if now is nil time.Now is used.
+func NewDailyOrderIDs(prefix string, start int, internal *Sequence, now func() time.Time) *DailyOrderIDs {
+	if internal == nil {
+		internal = NewSequence(start)
+	}
+	if now == nil {
+		now = time.Now
+	}
+	return &DailyOrderIDs{
+		prefix:   prefix,
+		start:    start,
+		internal: internal,
+		now:      now,
+		last:     start - 1,
+	}
+}
+
+// NextOrderID returns the next internal ID and the customer-facing number,
+// rolling the number back to start when the day changes.
+func (g *DailyOrderIDs) NextOrderID() (int, string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.rolloverLocked()
+	g.last++
+	return g.internal.Next(), g.numberLocked()
+}
+
+// NextOrderIDs is like NextOrderID for n orders at once. The internal IDs are
+// reserved as one block, so they are consecutive even when the Sequence is
+// shared with other generators.
+func (g *DailyOrderIDs) NextOrderIDs(n int) (int, []string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.rolloverLocked()
+	numbers := make([]string, n)
+	for i := range numbers {
+		g.last++
+		numbers[i] = g.numberLocked()
+	}
+	return g.internal.NextN(n), numbers
+}
+
+// Resume continues a numbering restored from storage: internal IDs carry on
+// after lastID and, unless more numbers have been issued since, today's
+// customer-facing numbers after the numberedToday already used.
+func (g *DailyOrderIDs) Resume(lastID, numberedToday int) {
+	g.internal.SkipPast(lastID)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.rolloverLocked()
+	g.last = max(g.last, g.start-1+numberedToday)
+}
+
+// rolloverLocked restarts the customer-facing numbers when the day changes.
+// The caller must hold g.mu.
+func (g *DailyOrderIDs) rolloverLocked() {
+	day := g.now().Format("2006-01-02")
+	if day != g.day {
+		g.day = day
+		g.last = g.start - 1
+	}
+}
+
+// numberLocked formats the last issued customer-facing number. The caller
+// must hold g.mu.
+func (g *DailyOrderIDs) numberLocked() string {
+	number := strconv.Itoa(g.last)
+	if g.prefix != "" {
+		number = g.prefix + "-" + number
+	}
+	return number
+}
+
+var botIDDigits = []rune("123456789")
+
+// RandomBotIDs hands out random numeric bot IDs of a fixed length and never
+// repeats one. Once every ID of the current length has been issued, the length
+// grows by one digit.
+type RandomBotIDs struct {
+	length int
+	// remaining is the number of unissued IDs of the current length.
+	remaining int
+	issued    map[string]struct{}
+	rnd       *rand.Rand
+	mu        sync.Mutex
+}
+
+// NewRandomBotIDs returns a generator of random IDs with the given number of digits.
+func NewRandomBotIDs(length int) *RandomBotIDs {
+	return &RandomBotIDs{
+		length:    length,
+		remaining: idSpace(length),
+		issued:    make(map[string]struct{}),
+		rnd:       rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// NextBotID returns an ID that has not been issued by this generator before.
+func (g *RandomBotIDs) NextBotID() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.remaining == 0 {
+		g.length++
+		g.remaining = idSpace(g.length)
+	}
+	for {
+		b := make([]rune, g.length)
+		for i := range b {
+			b[i] = botIDDigits[g.rnd.Intn(len(botIDDigits))]
+		}
+		id := string(b)
+		if _, taken := g.issued[id]; !taken {
+			g.issued[id] = struct{}{}
+			g.remaining--
+			return id
+		}
+	}
+}
+
+// idSpace returns how many distinct IDs of the given length exist.
+func idSpace(length int) int {
+	n := 1
+	for i := 0; i < length; i++ {
+		n *= len(botIDDigits)
+	}
+	return n
+}
@@ -0,0 +1,104 @@
+package idgen
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDailyOrderIDsRollover(t *testing.T) {
+	now := time.Date(2024, 1, 1, 23, 59, 0, 0, time.UTC)
+	g := NewDailyOrderIDs("KL01", 1001, nil, func() time.Time { return now })
+
+	id1, n1 := g.NextOrderID()
+	id2, n2 := g.NextOrderID()
+	if n1 != "KL01-1001" || n2 != "KL01-1002" {
+		t.Errorf("Expected KL01-1001 and KL01-1002, got %s and %s", n1, n2)
+	}
+
+	// Next day: number restarts, internal ID keeps increasing
+	now = now.Add(2 * time.Minute)
+	id3, n3 := g.NextOrderID()
+	if n3 != "KL01-1001" {
+		t.Errorf("Expected number to reset to KL01-1001, got %s", n3)
+	}
+	if !(id1 < id2 && id2 < id3) {
+		t.Errorf("Expected increasing internal IDs, got %d, %d, %d", id1, id2, id3)
+	}
+}
+
+func TestSharedSequenceIsUnique(t *testing.T) {
+	seq := NewSequence(1)
+	a := NewDailyOrderIDs("A", 1, seq, nil)
+	b := NewDailyOrderIDs("B", 1, seq, nil)
+
+	seen := make(map[int]bool)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, g := range []*DailyOrderIDs{a, b} {
+		wg.Add(1)
+		go func(g *DailyOrderIDs) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				id, _ := g.NextOrderID()
+				mu.Lock()
+				if seen[id] {
+					t.Errorf("Duplicate internal ID %d", id)
+				}
+				seen[id] = true
+				mu.Unlock()
+			}
+		}(g)
+	}
+	wg.Wait()
+}
+
+func TestRandomBotIDsNeverRepeat(t *testing.T) {
+	g := NewRandomBotIDs(1)
+	seen := make(map[string]bool)
+
+	// 9 one-digit IDs exist; the tenth must widen to two digits
+	for i := 0; i < 20; i++ {
+		id := g.NextBotID()
+		if seen[id] {
+			t.Fatalf("Duplicate bot ID %s after %d IDs", id, i)
+		}
+		seen[id] = true
+		if i < 9 && len(id) != 1 {
+			t.Errorf("Expected 1-digit ID at draw %d, got %s", i, id)
+		}
+		if i >= 9 && len(id) != 2 {
+			t.Errorf("Expected 2-digit ID at draw %d, got %s", i, id)
+		}
+	}
+}
+
+func TestNextOrderIDsAreConsecutive(t *testing.T) {
+	seq := NewSequence(1001)
+	a := NewDailyOrderIDs("A", 1, seq, nil)
+	b := NewDailyOrderIDs("B", 1, seq, nil)
+
+	a.NextOrderID()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			b.NextOrderID()
+		}
+	}()
+	first, numbers := a.NextOrderIDs(20)
+	wg.Wait()
+
+	if len(numbers) != 20 || numbers[0] != "A-2" || numbers[19] != "A-21" {
+		t.Errorf("Expected numbers A-2 to A-21, got %v", numbers)
+	}
+	// The block is reserved at once, so B's IDs never fall inside it
+	next, _ := a.NextOrderID()
+	if next <= first+19 && next >= first {
+		t.Errorf("Expected ID after the batch outside [%d, %d], got %d", first, first+19, next)
+	}
+	if _, n := a.NextOrderID(); n != "A-23" {
+		t.Errorf("Expected numbering to continue at A-23, got %s", n)
+	}
+}
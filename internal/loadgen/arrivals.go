@@ -0,0 +1,122 @@
+// Package loadgen drives a SystemManager on a virtual clock with synthetic or
+// recorded order arrivals, so dispatch behaviour can be measured at scales
+// (hundreds of bots, 100k orders) that would take days in real time.
+package loadgen
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/feedme/order-controller/internal/order"
+)
+
+// Arrival is one order placed At after the start of the run.
+type Arrival struct {
+	At   time.Duration
+	Type order.OrderTypeEnum
+}
+
+// Rush describes a period of elevated demand, e.g. the lunch rush.
+type Rush struct {
+	Start  time.Duration
+	Length time.Duration
+	// Rate is the arrival rate during the rush, in orders per second.
+	Rate float64
+}
+
+// Poisson returns arrivals with exponentially distributed gaps at rate orders
+// per second over duration. Each order is VIP with probability vipShare.
+func Poisson(rnd *rand.Rand, rate, vipShare float64, duration time.Duration) []Arrival {
+	return Varying(rnd, func(time.Duration) float64 { return rate }, rate, vipShare, duration)
+}
+
+// Bursty returns Poisson arrivals at baseRate, rising to rush.Rate during the rush.
+func Bursty(rnd *rand.Rand, baseRate float64, rush Rush, vipShare float64, duration time.Duration) []Arrival {
+	rate := func(t time.Duration) float64 {
+		if t >= rush.Start && t < rush.Start+rush.Length {
+			return rush.Rate
+		}
+		return baseRate
+	}
+	return Varying(rnd, rate, math.Max(baseRate, rush.Rate), vipShare, duration)
+}
+
+// Varying returns arrivals of a non-homogeneous Poisson process whose rate at
+// time t is rate(t), never above maxRate, generated by thinning.
+func Varying(rnd *rand.Rand, rate func(time.Duration) float64, maxRate, vipShare float64, duration time.Duration) []Arrival {
+	if maxRate <= 0 {
+		return nil
+	}
+	var arrivals []Arrival
+	t := 0.0
+	for {
+		t += rnd.ExpFloat64() / maxRate
+		at := time.Duration(t * float64(time.Second))
+		if at >= duration {
+			return arrivals
+		}
+		if rnd.Float64()*maxRate > rate(at) {
+			continue
+		}
+		typ := order.OrderTypeNormal
+		if rnd.Float64() < vipShare {
+			typ = order.OrderTypeVIP
+		}
+		arrivals = append(arrivals, Arrival{At: at, Type: typ})
+	}
+}
+
+// Replay reads arrivals from lines of "offset,type", where offset is a Go
+// duration ("1.5s") or a number of seconds and type is an order type such as
+// VIP or Normal. Blank lines, lines starting with '#' and a header line are
+// skipped. The result is sorted by offset.
+func Replay(r io.Reader) ([]Arrival, error) {
+	var arrivals []Arrival
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		offset, typ, ok := strings.Cut(text, ",")
+		if !ok {
+			return nil, fmt.Errorf("line %d: want offset,type", line)
+		}
+		offset, typ = strings.TrimSpace(offset), strings.TrimSpace(typ)
+		at, err := parseOffset(offset)
+		if err != nil {
+			if line == 1 {
+				continue // header
+			}
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		t := order.OrderTypeEnum(typ)
+		if _, ok := order.PriorityMap[t]; !ok {
+			return nil, fmt.Errorf("line %d: unknown order type %q", line, typ)
+		}
+		arrivals = append(arrivals, Arrival{At: at, Type: t})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(arrivals, func(i, j int) bool { return arrivals[i].At < arrivals[j].At })
+	return arrivals, nil
+}
+
+func parseOffset(s string) (time.Duration, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return d, nil
+	}
+	secs, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid offset %q", s)
+	}
+	return time.Duration(secs * float64(time.Second)), nil
+}
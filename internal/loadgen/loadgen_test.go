@@ -0,0 +1,107 @@
+package loadgen
+
+import (
+	"io"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/feedme/order-controller/internal/bot"
+	"github.com/feedme/order-controller/internal/order"
+	"github.com/feedme/order-controller/internal/utils"
+)
+
+func TestPoissonRate(t *testing.T) {
+	arrivals := Poisson(rand.New(rand.NewSource(1)), 10, 0.25, time.Hour)
+	if n := len(arrivals); n < 35000 || n > 37000 {
+		t.Errorf("Expected about 36000 arrivals, got %d", n)
+	}
+	vip := 0
+	for i, a := range arrivals {
+		if i > 0 && a.At < arrivals[i-1].At {
+			t.Fatalf("Arrivals out of order at %d", i)
+		}
+		if a.Type == order.OrderTypeVIP {
+			vip++
+		}
+	}
+	if share := float64(vip) / float64(len(arrivals)); share < 0.23 || share > 0.27 {
+		t.Errorf("Expected about 25%% VIP orders, got %.2f", share)
+	}
+}
+
+func TestBurstyRush(t *testing.T) {
+	rush := Rush{Start: time.Hour, Length: time.Hour, Rate: 20}
+	arrivals := Bursty(rand.New(rand.NewSource(1)), 2, rush, 0, 3*time.Hour)
+	inRush := 0
+	for _, a := range arrivals {
+		if a.At >= rush.Start && a.At < rush.Start+rush.Length {
+			inRush++
+		}
+	}
+	// 72000 expected in the rush, 14400 outside it
+	if inRush < 70000 || len(arrivals)-inRush < 13500 || len(arrivals)-inRush > 15300 {
+		t.Errorf("Unexpected split: %d in rush, %d outside", inRush, len(arrivals)-inRush)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	input := "offset,type\n# comment\n2s,VIP\n0.5,Normal\n\n1m,Normal\n"
+	arrivals, err := Replay(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	want := []Arrival{{500 * time.Millisecond, order.OrderTypeNormal}, {2 * time.Second, order.OrderTypeVIP}, {time.Minute, order.OrderTypeNormal}}
+	if len(arrivals) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, arrivals)
+	}
+	for i := range want {
+		if arrivals[i] != want[i] {
+			t.Errorf("Arrival %d: expected %v, got %v", i, want[i], arrivals[i])
+		}
+	}
+
+	if _, err := Replay(strings.NewReader("1s,Gold\n")); err == nil {
+		t.Error("Expected an error for an unknown order type")
+	}
+}
+
+func TestRun(t *testing.T) {
+	utils.SetOutput(io.Discard)
+
+	// Two FAST bots, three orders at once: the VIP order goes first and the
+	// last order waits for a bot to free up.
+	arrivals := []Arrival{{0, order.OrderTypeNormal}, {0, order.OrderTypeNormal}, {time.Second, order.OrderTypeVIP}}
+	res, err := Run(arrivals, []bot.BotTypeEnum{bot.BotTypeFast, bot.BotTypeFast}, time.Minute)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if res.Completed != 3 {
+		t.Errorf("Expected 3 completed orders, got %d", res.Completed)
+	}
+	if res.Virtual != 10*time.Second {
+		t.Errorf("Expected the run to end at 10s, got %v", res.Virtual)
+	}
+	if res.Report.Wait.P99 != 4*time.Second {
+		t.Errorf("Expected the VIP order to wait 4s, got %v", res.Report.Wait.P99)
+	}
+}
+
+func BenchmarkRun(b *testing.B) {
+	utils.SetOutput(io.Discard)
+	arrivals := Poisson(rand.New(rand.NewSource(1)), 5, 0.2, 10*time.Minute)
+	bots := make([]bot.BotTypeEnum, 50)
+	for i := range bots {
+		bots[i] = bot.BotTypeFast
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Run(arrivals, bots, time.Minute); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(len(arrivals)*b.N)/b.Elapsed().Seconds(), "orders/s")
+}
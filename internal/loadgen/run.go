@@ -0,0 +1,167 @@
+package loadgen
+
+import (
+	"errors"
+	"runtime"
+	"time"
+
+	"github.com/feedme/order-controller/internal/bot"
+	"github.com/feedme/order-controller/internal/clock"
+	"github.com/feedme/order-controller/internal/manager"
+	"github.com/feedme/order-controller/internal/order"
+	"github.com/feedme/order-controller/internal/report"
+)
+
+// ErrStalled is returned when the manager stops making progress, e.g. because
+// orders are left that no bot may take.
+var ErrStalled = errors.New("load run stalled")
+
+// settleTimeout bounds how long Run waits, in real time, for the bots to react
+// to a single arrival or timer.
+const settleTimeout = 10 * time.Second
+
+// maxDrain bounds how long (virtual time) Run keeps going after the last arrival.
+const maxDrain = 24 * time.Hour
+
+// Result summarises a load run.
+type Result struct {
+	Arrivals  int
+	Completed int
+	// Virtual is the simulated length of the run, from start until the last
+	// order completed. Wall is how long it took to simulate.
+	Virtual time.Duration
+	Wall    time.Duration
+	// Steps counts clock events (arrivals and timers) the manager reacted to.
+	Steps int
+	// Allocs and Bytes are heap allocations made during the run.
+	Allocs uint64
+	Bytes  uint64
+	Report report.Report
+}
+
+// Run starts a SystemManager on a virtual clock with the given bots, places
+// the arrivals at their offsets and runs until every order is complete. After
+// every arrival and every timer it waits for the bots to react before moving
+// the clock on, so results do not depend on how fast the host is.
+// sla is passed to the report; opts are applied after the clock option.
+func Run(arrivals []Arrival, bots []bot.BotTypeEnum, sla time.Duration, opts ...manager.Option) (Result, error) {
+	start := time.Date(2026, 1, 1, 11, 0, 0, 0, time.UTC)
+	v := clock.NewVirtual(start)
+	m := manager.NewSystemManager(append([]manager.Option{manager.WithClock(v)}, opts...)...)
+	defer m.Stop()
+	d := &driver{m: m, clock: v}
+
+	var before runtime.MemStats
+	runtime.ReadMemStats(&before)
+	began := time.Now()
+
+	for _, typ := range bots {
+		if _, err := m.AddBot(typ); err != nil {
+			return Result{}, err
+		}
+	}
+	if err := d.settle(); err != nil {
+		return Result{}, err
+	}
+	for _, a := range arrivals {
+		if err := d.advanceTo(start.Add(a.At)); err != nil {
+			return Result{}, err
+		}
+		m.AddOrder(a.Type)
+		if err := d.settle(); err != nil {
+			return Result{}, err
+		}
+	}
+	for deadline := v.Now().Add(maxDrain); m.Orders.GetCompletedCount() < m.Orders.GetTotalCount(); {
+		if !v.Now().Before(deadline) {
+			return Result{}, ErrStalled
+		}
+		if err := d.advanceTo(v.Now().Add(time.Minute)); err != nil {
+			return Result{}, err
+		}
+	}
+
+	res := Result{
+		Arrivals:  len(arrivals),
+		Completed: m.Orders.GetCompletedCount(),
+		Wall:      time.Since(began),
+		Steps:     d.steps,
+	}
+	var after runtime.MemStats
+	runtime.ReadMemStats(&after)
+	res.Allocs = after.Mallocs - before.Mallocs
+	res.Bytes = after.TotalAlloc - before.TotalAlloc
+
+	page := m.Orders.Query(order.Query{})
+	orders := make([]*order.Order, 0, len(page.Orders))
+	for _, snap := range page.Orders {
+		orders = append(orders, m.Orders.GetOrder(snap.ID))
+		if snap.CompletedAt.Sub(start) > res.Virtual {
+			res.Virtual = snap.CompletedAt.Sub(start)
+		}
+	}
+	res.Report = report.Build(orders, m.Orders.WorkerHistory(), sla)
+	return res, nil
+}
+
+// driver steps the virtual clock and waits for the manager to settle.
+type driver struct {
+	m     *manager.SystemManager
+	clock *clock.Virtual
+	steps int
+}
+
+// advanceTo fires every timer due up to t, one at a time, then sets the clock to t.
+func (d *driver) advanceTo(t time.Time) error {
+	for d.clock.Step(t) {
+		d.steps++
+		if err := d.settle(); err != nil {
+			return err
+		}
+	}
+	if t.After(d.clock.Now()) {
+		d.clock.Advance(t.Sub(d.clock.Now()))
+	}
+	return nil
+}
+
+// settle spins until every queued order is in the queue, every order being
+// cooked is held by a bot, no idle bot could take a queued order and every
+// cooking bot has started its timer.
+func (d *driver) settle() error {
+	deadline := time.Now().Add(settleTimeout)
+	for stable := 0; stable < 2; runtime.Gosched() {
+		if d.quiescent() {
+			stable++
+			continue
+		}
+		stable = 0
+		if time.Now().After(deadline) {
+			return ErrStalled
+		}
+	}
+	return nil
+}
+
+func (d *driver) quiescent() bool {
+	// Cheap checks first; they fail for most polls while bots are reacting.
+	pending := d.m.Orders.Count(order.Query{Status: order.OrderStatusPending})
+	if pending != d.m.OrderQueue.Len() {
+		return false
+	}
+	counts := d.m.BotPool.CountByStatus()
+	if d.clock.PendingTimers() != counts[bot.BotStatusProcessing] {
+		return false
+	}
+	if counts[bot.BotStatusIdle] > 0 && pending > 0 {
+		return false
+	}
+
+	holding := 0
+	for _, b := range d.m.BotPool.Snapshots() {
+		if b.CurrentOrderID != nil {
+			holding++
+		}
+	}
+	return d.m.Orders.Count(order.Query{Status: order.OrderStatusProcessing}) == holding
+}
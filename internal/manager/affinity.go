@@ -0,0 +1,31 @@
+package manager
+
+import (
+	"github.com/feedme/order-controller/internal/order"
+	"github.com/feedme/order-controller/internal/utils"
+)
+
+// AddPinnedOrder creates an order tied to a bot or bot type. With a hard
+// affinity only matching bots cook it (until its fallback time passes); with a
+// soft affinity matching bots merely prefer it. The queue only wakes an idle bot
+// that may take the order, so a pinned order is never handed to the wrong bot.
+func (m *SystemManager) AddPinnedOrder(orderType order.OrderTypeEnum, affinity order.Affinity) *order.Order {
+	ord := m.Orders.AddOrderWithAffinity(m.OrderQueue, orderType, affinity)
+
+	utils.Log("Order •%d pinned (Bot: %q, Type: %q, Hard: %t)", ord.ID, affinity.BotID, affinity.BotType, affinity.Hard)
+	m.maybePreempt(ord)
+	return ord
+}
+
+// DedicateBot reserves a bot for orders pinned or hinted to it, e.g. a single
+// bot for drive-thru orders during rush hours. Pass false to return it to
+// general duty.
+func (m *SystemManager) DedicateBot(id string, dedicated bool) error {
+	b := m.BotPool.GetBot(id)
+	if b == nil {
+		return ErrBotNotFound
+	}
+	b.SetDedicated(dedicated)
+	utils.Log("Bot #%s dedicated: %t", b.ID, dedicated)
+	return nil
+}
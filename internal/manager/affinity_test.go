@@ -0,0 +1,44 @@
+package manager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/feedme/order-controller/internal/bot"
+	"github.com/feedme/order-controller/internal/order"
+)
+
+func TestPinnedOrderGoesToNamedBot(t *testing.T) {
+	m := NewSystemManager(WithProcessingTimes(map[bot.BotTypeEnum]time.Duration{
+		bot.BotTypeFast: 20 * time.Millisecond,
+		bot.BotTypeSlow: 20 * time.Millisecond,
+	}))
+	defer m.Stop()
+
+	driveThru, _ := m.AddBot(bot.BotTypeFast)
+	m.AddBot(bot.BotTypeSlow)
+	if err := m.DedicateBot(driveThru, true); err != nil {
+		t.Fatal(err)
+	}
+
+	normal := m.Orders.AddOrder(m.OrderQueue, order.OrderTypeNormal)
+	pinned := m.AddPinnedOrder(order.OrderTypeNormal, order.Affinity{BotID: driveThru, Hard: true})
+
+	waitForOrderStatus(t, m, pinned.ID, order.OrderStatusComplete)
+	waitForOrderStatus(t, m, normal.ID, order.OrderStatusComplete)
+
+	for _, tr := range pinned.History() {
+		if tr.To == order.OrderStatusProcessing && tr.Actor != order.BotActor(driveThru) {
+			t.Errorf("Expected pinned order to be cooked by %s, got %s", driveThru, tr.Actor)
+		}
+	}
+	for _, tr := range normal.History() {
+		if tr.To == order.OrderStatusProcessing && tr.Actor == order.BotActor(driveThru) {
+			t.Error("Expected dedicated bot not to cook unpinned orders")
+		}
+	}
+
+	if err := m.DedicateBot("missing", true); err != ErrBotNotFound {
+		t.Errorf("Expected ErrBotNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,52 @@
+package manager
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/feedme/order-controller/internal/order"
+	"github.com/feedme/order-controller/internal/utils"
+)
+
+// ErrInvalidBatch is returned by AddOrders when the batch is empty or any of
+// its requests is invalid.
+var ErrInvalidBatch = errors.New("invalid order batch")
+
+// OrderResult is the outcome of one request of a batch.
+type OrderResult struct {
+	// Order is the created order, or nil if the batch was rejected.
+	Order *order.Order
+	// Err explains why this request is invalid. It is nil for valid requests,
+	// even when the batch was rejected because of another request.
+	Err error
+}
+
+// AddOrders creates a batch of orders, e.g. a catering or group order. Every
+// request is validated first; if any is invalid, no order is created and the
+// error wraps ErrInvalidBatch. Otherwise the orders get consecutive IDs and are
+// queued at once, so no bot starts on a partial batch. Results are returned in
+// request order.
+func (m *SystemManager) AddOrders(reqs []order.OrderRequest) ([]OrderResult, error) {
+	if len(reqs) == 0 {
+		return nil, fmt.Errorf("%w: no orders", ErrInvalidBatch)
+	}
+	results := make([]OrderResult, len(reqs))
+	invalid := 0
+	for i, req := range reqs {
+		if err := req.Validate(); err != nil {
+			results[i].Err = err
+			invalid++
+		}
+	}
+	if invalid > 0 {
+		return results, fmt.Errorf("%w: %d of %d requests invalid", ErrInvalidBatch, invalid, len(reqs))
+	}
+
+	orders := m.Orders.AddOrders(m.OrderQueue, reqs)
+	utils.Log("Batch of %d orders queued (•%d to •%d)", len(orders), orders[0].ID, orders[len(orders)-1].ID)
+	for i, o := range orders {
+		results[i].Order = o
+		m.maybePreempt(o)
+	}
+	return results, nil
+}
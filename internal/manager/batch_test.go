@@ -0,0 +1,98 @@
+package manager
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/feedme/order-controller/internal/bot"
+	"github.com/feedme/order-controller/internal/event"
+	"github.com/feedme/order-controller/internal/order"
+)
+
+func TestAddOrdersQueuesWholeBatch(t *testing.T) {
+	m := NewSystemManager()
+	defer m.Stop()
+	m.AddOrder(order.OrderTypeNormal)
+	events := m.EventBus.SubscribeAll(64)
+
+	reqs := make([]order.OrderRequest, 25)
+	for i := range reqs {
+		reqs[i] = order.OrderRequest{Type: order.OrderTypeNormal}
+	}
+	results, err := m.AddOrders(reqs)
+	if err != nil {
+		t.Fatalf("AddOrders: %v", err)
+	}
+	for i, r := range results {
+		if r.Err != nil || r.Order == nil {
+			t.Fatalf("Result %d: unexpected %+v", i, r)
+		}
+		if want := 1002 + i; r.Order.ID != want {
+			t.Errorf("Result %d: expected consecutive ID %d, got %d", i, want, r.Order.ID)
+		}
+	}
+	if got := m.OrderQueue.Len(); got != 26 {
+		t.Errorf("Expected 26 queued orders, got %d", got)
+	}
+	created := 0
+	for len(events) > 0 {
+		if ev := <-events; ev.Type == event.OrderCreated {
+			created++
+		}
+	}
+	if created != 25 {
+		t.Errorf("Expected 25 ORDER_CREATED events, got %d", created)
+	}
+}
+
+func TestAddOrdersIsAtomic(t *testing.T) {
+	m := NewSystemManager(WithProcessingTimes(map[bot.BotTypeEnum]time.Duration{bot.BotTypeSlow: time.Hour}))
+	defer m.Stop()
+	id, _ := m.AddBot(bot.BotTypeSlow)
+
+	// A bot seeing the batch one order at a time would start on the first Normal
+	results, err := m.AddOrders([]order.OrderRequest{
+		{Type: order.OrderTypeNormal},
+		{Type: order.OrderTypeNormal},
+		{Type: order.OrderTypeVIP},
+	})
+	if err != nil {
+		t.Fatalf("AddOrders: %v", err)
+	}
+	vip := results[2].Order
+	waitForOrderStatus(t, m, vip.ID, order.OrderStatusProcessing)
+	if got, _ := m.BotPool.GetBot(id).CurrentOrderID(); got != vip.ID {
+		t.Errorf("Expected the bot to start on VIP order %d, got %d", vip.ID, got)
+	}
+}
+
+func TestAddOrdersRejectsInvalidBatch(t *testing.T) {
+	m := NewSystemManager()
+	defer m.Stop()
+
+	results, err := m.AddOrders([]order.OrderRequest{
+		{Type: order.OrderTypeNormal},
+		{Type: "Catering"},
+		{Type: order.OrderTypeVIP, Affinity: order.Affinity{Hard: true}},
+	})
+	if !errors.Is(err, ErrInvalidBatch) {
+		t.Fatalf("Expected ErrInvalidBatch, got %v", err)
+	}
+	if results[0].Err != nil || results[0].Order != nil {
+		t.Errorf("Expected valid request without an order, got %+v", results[0])
+	}
+	if !errors.Is(results[1].Err, order.ErrUnknownOrderType) {
+		t.Errorf("Expected ErrUnknownOrderType, got %v", results[1].Err)
+	}
+	if !errors.Is(results[2].Err, order.ErrInvalidAffinity) {
+		t.Errorf("Expected ErrInvalidAffinity, got %v", results[2].Err)
+	}
+	if got := m.Orders.GetTotalCount(); got != 0 {
+		t.Errorf("Expected no orders created, got %d", got)
+	}
+
+	if _, err := m.AddOrders(nil); !errors.Is(err, ErrInvalidBatch) {
+		t.Errorf("Expected ErrInvalidBatch for an empty batch, got %v", err)
+	}
+}
@@ -0,0 +1,45 @@
+package manager
+
+import (
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/feedme/order-controller/internal/bot"
+	"github.com/feedme/order-controller/internal/event"
+	"github.com/feedme/order-controller/internal/order"
+	"github.com/feedme/order-controller/internal/utils"
+)
+
+// BenchmarkDispatchLatency measures the real time from AddOrder until an idle
+// bot picks the order up, with n idle bots waiting.
+func BenchmarkDispatchLatency(b *testing.B) {
+	utils.SetOutput(io.Discard)
+	for _, n := range []int{1, 10, 100, 500} {
+		b.Run(fmt.Sprintf("bots=%d", n), func(b *testing.B) {
+			m := NewSystemManager(WithProcessingTimes(map[bot.BotTypeEnum]time.Duration{
+				bot.BotTypeFast: time.Nanosecond,
+			}))
+			defer m.Stop()
+			for i := 0; i < n; i++ {
+				m.AddBot(bot.BotTypeFast)
+			}
+			assigned := m.EventBus.Subscribe(event.OrderAssigned)
+			completed := m.EventBus.Subscribe(event.OrderCompleted)
+
+			var total time.Duration
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				start := time.Now()
+				m.AddOrder(order.OrderTypeNormal)
+				<-assigned
+				total += time.Since(start)
+				// Let the bot finish so every iteration starts with n idle bots
+				<-completed
+			}
+			b.ReportMetric(float64(total.Nanoseconds())/float64(b.N), "ns/dispatch")
+		})
+	}
+}
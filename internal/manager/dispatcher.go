@@ -0,0 +1,95 @@
+package manager
+
+import (
+	"time"
+
+	"github.com/feedme/order-controller/internal/order"
+)
+
+// affinityPenalty is added to the cost of a bot that does not match an
+// order's affinity hint, so a matching bot always wins if one is idle.
+const affinityPenalty = time.Hour
+
+// DefaultCost is the cost function used unless WithCostFunc is given. The
+// cost is the bot's processing time in seconds, so urgent and VIP orders,
+// which are matched first, go to the fastest idle bots. Bots matching an
+// order's affinity are preferred over faster bots that do not.
+func DefaultCost(o *order.Order, w order.Worker) float64 {
+	cost := w.ProcessingTime
+	if !o.Affinity.IsZero() && !o.Affinity.Matches(w) {
+		cost += affinityPenalty
+	}
+	return cost.Seconds()
+}
+
+// WithCostFunc sets the function the dispatcher uses to pick the idle bot for
+// an order. The default is DefaultCost.
+func WithCostFunc(cost order.CostFunc) Option {
+	return func(m *SystemManager) {
+		m.cost = cost
+	}
+}
+
+// kick wakes the dispatcher without blocking. Kicks arriving while one is
+// already pending are merged, since a single Match serves them all.
+func (m *SystemManager) kick() {
+	select {
+	case m.kicks <- struct{}{}:
+	default:
+	}
+}
+
+// dispatchLoop is the single place where orders are given to bots. Whenever
+// the queue reports that an order and an idle bot may be matched, it asks the
+// queue for the cheapest assignments and delivers each order to its bot.
+func (m *SystemManager) dispatchLoop() {
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-m.kicks:
+		}
+		m.dispatchMu.Lock()
+		for _, a := range m.OrderQueue.Match(m.cost) {
+			m.deliver(a)
+		}
+		m.dispatchMu.Unlock()
+	}
+}
+
+// deliver hands an assigned order to its bot. If the bot has been removed in
+// the meantime, or still holds an undelivered order, the order goes back to
+// its place in the queue to be matched again. The caller must hold
+// m.dispatchMu.
+func (m *SystemManager) deliver(a order.Assignment) {
+	m.mu.Lock()
+	inbox, ok := m.inboxes[a.Worker.ID]
+	if ok {
+		select {
+		case inbox <- a.Order:
+		default:
+			ok = false
+		}
+	}
+	m.mu.Unlock()
+
+	if !ok {
+		m.OrderQueue.Return(a.Order)
+	}
+}
+
+// closeInbox stops deliveries to the bot and returns any order delivered but
+// not yet taken to its place in the queue.
+func (m *SystemManager) closeInbox(botID string) {
+	m.mu.Lock()
+	inbox := m.inboxes[botID]
+	delete(m.inboxes, botID)
+	m.mu.Unlock()
+
+	m.OrderQueue.Forget(botID)
+	select {
+	case ord := <-inbox:
+		m.OrderQueue.Return(ord)
+	default:
+	}
+}
@@ -0,0 +1,50 @@
+package manager
+
+import (
+	"testing"
+
+	"github.com/feedme/order-controller/internal/order"
+)
+
+func TestUndeliveredOrderKeepsItsPlace(t *testing.T) {
+	m := NewSystemManager()
+	defer m.Stop()
+	m.OrderQueue.SetPaused(true)
+	first := m.Orders.AddOrder(m.OrderQueue, order.OrderTypeNormal)
+	second := m.Orders.AddOrder(m.OrderQueue, order.OrderTypeNormal)
+	third := m.Orders.AddOrder(m.OrderQueue, order.OrderTypeNormal)
+
+	// The dispatcher took the second order for a bot that has since gone.
+	taken := m.OrderQueue.Remove(second.ID)
+	m.dispatchMu.Lock()
+	m.deliver(order.Assignment{Order: taken, Worker: order.Worker{ID: "gone"}})
+	m.dispatchMu.Unlock()
+
+	for i, o := range []*order.Order{first, second, third} {
+		if pos, ok := m.OrderQueue.Position(o.ID); !ok || pos != i+1 {
+			t.Errorf("Expected Order %d at position %d, got %d (%v)", o.ID, i+1, pos, ok)
+		}
+	}
+}
+
+func TestUndeliveredRequeuedOrderStaysAhead(t *testing.T) {
+	m := NewSystemManager()
+	defer m.Stop()
+	m.OrderQueue.SetPaused(true)
+	first := m.Orders.AddOrder(m.OrderQueue, order.OrderTypeNormal)
+	second := m.Orders.AddOrder(m.OrderQueue, order.OrderTypeNormal)
+	interrupted := m.Orders.AddOrder(m.OrderQueue, order.OrderTypeNormal)
+	m.OrderQueue.PushFront(m.OrderQueue.Remove(interrupted.ID))
+
+	// The dispatcher took the interrupted order for a bot that has since gone.
+	taken := m.OrderQueue.Remove(interrupted.ID)
+	m.dispatchMu.Lock()
+	m.deliver(order.Assignment{Order: taken, Worker: order.Worker{ID: "gone"}})
+	m.dispatchMu.Unlock()
+
+	for i, o := range []*order.Order{interrupted, first, second} {
+		if pos, ok := m.OrderQueue.Position(o.ID); !ok || pos != i+1 {
+			t.Errorf("Expected Order %d at position %d, got %d (%v)", o.ID, i+1, pos, ok)
+		}
+	}
+}
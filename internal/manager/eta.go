@@ -0,0 +1,101 @@
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/feedme/order-controller/internal/bot"
+	"github.com/feedme/order-controller/internal/order"
+)
+
+// ErrNoCapacity is returned by ETA estimates when no bot is available to cook.
+var ErrNoCapacity = errors.New("no active bots")
+
+// EstimateReady estimates how long until the given order is cooked. It replays
+// the pending queue in dispatch order against the current bots: each order goes
+// to the bot that frees up first, bots busy cooking start with their remaining
+// time, and each order takes the share of the bot's processing time its progress
+// leaves. Affinities and future arrivals are ignored, so this is an estimate.
+// Scheduled pre-orders are expected at their pickup time.
+func (m *SystemManager) EstimateReady(orderID int) (time.Duration, error) {
+	ord := m.Orders.GetOrder(orderID)
+	if ord == nil {
+		return 0, ErrOrderNotFound
+	}
+
+	switch ord.Status() {
+	case order.OrderStatusPending:
+	case order.OrderStatusScheduled:
+		return max(ord.NotBefore.Sub(m.clock.Now()), 0), nil
+	case order.OrderStatusProcessing:
+		for _, b := range m.BotPool.Snapshots() {
+			if b.CurrentOrderID != nil && *b.CurrentOrderID == orderID {
+				return b.TimeRemaining, nil
+			}
+		}
+		return 0, nil
+	default:
+		return 0, nil
+	}
+
+	etas, err := m.EstimateQueue()
+	if err != nil {
+		return 0, err
+	}
+	eta, ok := etas[orderID]
+	if !ok {
+		// Not queued (e.g. being picked up right now)
+		return 0, nil
+	}
+	return eta, nil
+}
+
+// EstimateQueue returns the estimated time until each pending order is cooked,
+// keyed by order ID. See EstimateReady for how the estimate is made.
+func (m *SystemManager) EstimateQueue() (map[int]time.Duration, error) {
+	// freeAt[i] is when bot i can start its next order, relative to now.
+	var freeAt []time.Duration
+	var speeds []time.Duration
+	for _, b := range m.BotPool.Snapshots() {
+		switch b.Status {
+		case bot.BotStatusIdle:
+			freeAt = append(freeAt, 0)
+		case bot.BotStatusProcessing:
+			freeAt = append(freeAt, b.TimeRemaining)
+		default:
+			continue
+		}
+		speeds = append(speeds, b.ProcessingTime)
+	}
+
+	pending := m.OrderQueue.List()
+	etas := make(map[int]time.Duration, len(pending))
+	if len(pending) == 0 {
+		return etas, nil
+	}
+	if len(freeAt) == 0 {
+		return nil, ErrNoCapacity
+	}
+
+	for _, o := range pending {
+		next := 0
+		for i := range freeAt {
+			if freeAt[i] < freeAt[next] {
+				next = i
+			}
+		}
+		freeAt[next] += time.Duration((1 - o.Progress) * float64(speeds[next]))
+		etas[o.ID] = freeAt[next]
+	}
+	return etas, nil
+}
+
+// FormatETA renders an estimate for the customer board, e.g. "ready in ~4 min".
+func FormatETA(d time.Duration) string {
+	if d < time.Minute {
+		return "ready in <1 min"
+	}
+	return fmt.Sprintf("ready in ~%d min", int(math.Ceil(d.Minutes())))
+}
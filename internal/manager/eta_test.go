@@ -0,0 +1,64 @@
+package manager
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/feedme/order-controller/internal/bot"
+	"github.com/feedme/order-controller/internal/order"
+)
+
+func TestEstimateQueue(t *testing.T) {
+	m := NewSystemManager(WithProcessingTimes(map[bot.BotTypeEnum]time.Duration{
+		bot.BotTypeFast: time.Minute,
+		bot.BotTypeSlow: 2 * time.Minute,
+	}))
+	defer m.Stop()
+
+	vip := m.Orders.AddOrder(m.OrderQueue, order.OrderTypeVIP)
+	n1 := m.Orders.AddOrder(m.OrderQueue, order.OrderTypeNormal)
+	n2 := m.Orders.AddOrder(m.OrderQueue, order.OrderTypeNormal)
+	n3 := m.Orders.AddOrder(m.OrderQueue, order.OrderTypeNormal)
+
+	if _, err := m.EstimateReady(vip.ID); !errors.Is(err, ErrNoCapacity) {
+		t.Fatalf("Expected ErrNoCapacity without bots, got %v", err)
+	}
+
+	// Bots added straight to the pool have no worker loop, so the queue stays put
+	m.BotPool.AddBot(bot.BotTypeFast)
+	m.BotPool.AddBot(bot.BotTypeSlow)
+
+	expected := map[int]time.Duration{
+		vip.ID: time.Minute,     // FAST bot
+		n1.ID:  2 * time.Minute, // SLOW bot
+		n2.ID:  2 * time.Minute, // FAST bot again after the VIP
+		n3.ID:  3 * time.Minute, // whichever frees first at 2m
+	}
+	for id, want := range expected {
+		got, err := m.EstimateReady(id)
+		if err != nil {
+			t.Fatalf("EstimateReady(%d): %v", id, err)
+		}
+		if got != want {
+			t.Errorf("EstimateReady(%d): expected %v, got %v", id, want, got)
+		}
+	}
+
+	if _, err := m.EstimateReady(4242); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("Expected ErrOrderNotFound, got %v", err)
+	}
+}
+
+func TestFormatETA(t *testing.T) {
+	cases := map[time.Duration]string{
+		30 * time.Second:               "ready in <1 min",
+		3*time.Minute + 20*time.Second: "ready in ~4 min",
+		4 * time.Minute:                "ready in ~4 min",
+	}
+	for d, want := range cases {
+		if got := FormatETA(d); got != want {
+			t.Errorf("FormatETA(%v): expected %q, got %q", d, want, got)
+		}
+	}
+}
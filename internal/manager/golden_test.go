@@ -0,0 +1,340 @@
+package manager
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/feedme/order-controller/internal/bot"
+	"github.com/feedme/order-controller/internal/clock"
+	"github.com/feedme/order-controller/internal/event"
+	"github.com/feedme/order-controller/internal/order"
+)
+
+// update rewrites the golden files instead of comparing against them:
+//
+//	go test ./internal/manager -run Golden -update
+var update = flag.Bool("update", false, "rewrite golden files in testdata/golden")
+
+// seqBotIDs numbers bots B1, B2, ... so timelines do not depend on random IDs.
+type seqBotIDs struct{ n int }
+
+func (s *seqBotIDs) NextBotID() string {
+	s.n++
+	return fmt.Sprintf("B%d", s.n)
+}
+
+// harness runs a SystemManager on a virtual clock and records its event
+// timeline. Every action and every timer is followed by settle, so the
+// timeline does not depend on goroutine scheduling.
+type harness struct {
+	t        *testing.T
+	clock    *clock.Virtual
+	start    time.Time
+	m        *SystemManager
+	events   chan event.Event
+	timeline []string
+	// recorded holds the events behind timeline, for property checks.
+	recorded []event.Event
+}
+
+func newHarness(t *testing.T, opts ...Option) *harness {
+	t.Helper()
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	v := clock.NewVirtual(start)
+	opts = append([]Option{WithClock(v), WithBotIDGenerator(&seqBotIDs{})}, opts...)
+	m := NewSystemManager(opts...)
+	t.Cleanup(m.Stop)
+
+	return &harness{
+		t:      t,
+		clock:  v,
+		start:  start,
+		m:      m,
+		events: m.EventBus.SubscribeAll(1024),
+	}
+}
+
+// do runs an action against the manager and waits for the system to settle.
+func (h *harness) do(action func(m *SystemManager)) {
+	action(h.m)
+	h.settle()
+}
+
+// advance moves the virtual clock forward by d one timer at a time, letting
+// the system settle after each.
+func (h *harness) advance(d time.Duration) {
+	until := h.clock.Now().Add(d)
+	for h.clock.Step(until) {
+		h.settle()
+	}
+	h.clock.Advance(until.Sub(h.clock.Now()))
+	h.settle()
+}
+
+// settle waits until every bot has reacted to the last change: queued orders
+// are in the queue, scheduled orders are in the holding area, picked-up orders
+// are held by a bot, idle bots have nothing left to take, cooking bots have
+// started their timers, completed orders are on the pickup shelf or held by a
+// bot stalled at a full shelf, and every event has been recorded. The state
+// must hold for a few consecutive polls.
+func (h *harness) settle() {
+	h.t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for stable := 0; stable < 3; {
+		if time.Now().After(deadline) {
+			h.t.Fatalf("System did not settle at +%v; timeline so far:\n%s", h.clock.Since(h.start), strings.Join(h.timeline, "\n"))
+		}
+		time.Sleep(time.Millisecond)
+		h.drain()
+		if h.quiescent() {
+			stable++
+		} else {
+			stable = 0
+		}
+	}
+}
+
+func (h *harness) quiescent() bool {
+	pending := h.m.Orders.Query(order.Query{Status: order.OrderStatusPending}).Total
+	processing := h.m.Orders.Query(order.Query{Status: order.OrderStatusProcessing}).Total
+	if pending != h.m.OrderQueue.Len() {
+		return false
+	}
+	scheduled := h.m.Orders.Query(order.Query{Status: order.OrderStatusScheduled}).Total
+	if scheduled != h.m.Holding.Len() {
+		return false
+	}
+
+	holding, cooking, idle, stalled := 0, 0, 0, 0
+	for _, b := range h.m.BotPool.Snapshots() {
+		if b.CurrentOrderID != nil {
+			holding++
+		}
+		switch b.Status {
+		case bot.BotStatusProcessing:
+			cooking++
+		case bot.BotStatusIdle:
+			idle++
+		case bot.BotStatusStalled:
+			stalled++
+		}
+	}
+	if shelf := h.m.Shelf; shelf != nil {
+		complete := h.m.Orders.Query(order.Query{Status: order.OrderStatusComplete}).Total
+		ready := h.m.Orders.Query(order.Query{Status: order.OrderStatusReady}).Total
+		if complete != stalled || ready != shelf.Len() || (stalled > 0 && shelf.Len() < shelf.Cap()) {
+			return false
+		}
+	}
+	return processing == holding &&
+		(idle == 0 || pending == 0) &&
+		h.clock.PendingTimers() == cooking &&
+		len(h.events) == 0
+}
+
+func (h *harness) drain() {
+	for {
+		select {
+		case ev := <-h.events:
+			h.recorded = append(h.recorded, ev)
+			h.timeline = append(h.timeline, h.format(ev))
+		default:
+			return
+		}
+	}
+}
+
+func (h *harness) format(ev event.Event) string {
+	at := fmt.Sprintf("+%05.1fs %-18s", ev.At.Sub(h.start).Seconds(), ev.Type)
+	switch d := ev.Data.(type) {
+	case order.OrderSnapshot:
+		return fmt.Sprintf("%s order=%d type=%s status=%s progress=%.2f", at, d.ID, d.Type, d.Status, d.Progress)
+	case bot.StatusTransition:
+		return fmt.Sprintf("%s bot=%s %s->%s (%s)", at, d.BotID, d.From, d.To, d.Reason)
+	case Preemption:
+		return fmt.Sprintf("%s bot=%s order=%d preempted-by=%d", at, d.BotID, d.Displaced.ID, d.PreemptedBy)
+	case Modification:
+		return fmt.Sprintf("%s order=%d %s", at, d.Order.ID, d.Diff)
+	}
+	return fmt.Sprintf("%s %v", at, ev.Data)
+}
+
+// check compares the recorded timeline with testdata/golden/<name>.golden.
+func (h *harness) check(name string) {
+	h.t.Helper()
+	got := strings.Join(h.timeline, "\n") + "\n"
+	path := filepath.Join("testdata", "golden", name+".golden")
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			h.t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			h.t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		h.t.Fatalf("Cannot read golden file (run with -update to create it): %v", err)
+	}
+	if got == string(want) {
+		return
+	}
+	gotLines, wantLines := strings.Split(got, "\n"), strings.Split(string(want), "\n")
+	for i := 0; i < len(gotLines) || i < len(wantLines); i++ {
+		var g, w string
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if g != w {
+			h.t.Fatalf("Timeline differs from %s at line %d:\n got: %s\nwant: %s\n\nfull timeline:\n%s", path, i+1, g, w, got)
+		}
+	}
+}
+
+// TestGoldenMainScenario replays the cmd/main.go simulation.
+func TestGoldenMainScenario(t *testing.T) {
+	h := newHarness(t)
+
+	h.do(func(m *SystemManager) { m.AddBot(bot.BotTypeFast) })
+	for _, typ := range []order.OrderTypeEnum{order.OrderTypeNormal, order.OrderTypeNormal, order.OrderTypeVIP, order.OrderTypeNormal} {
+		h.do(func(m *SystemManager) { m.AddOrder(typ) })
+	}
+	h.do(func(m *SystemManager) { m.AddBot(bot.BotTypeSlow) })
+	h.do(func(m *SystemManager) { m.AddOrder(order.OrderTypeVIP) })
+	h.do(func(m *SystemManager) { m.AddOrder(order.OrderTypeNormal) })
+	h.do(func(m *SystemManager) { m.AddBot(bot.BotTypeFast) })
+
+	h.advance(3 * time.Second)
+	h.do(func(m *SystemManager) { m.RemoveBot("") })
+	h.advance(3 * time.Second)
+	h.do(func(m *SystemManager) { m.AddBot(bot.BotTypeSlow) })
+	h.advance(35 * time.Second)
+
+	h.check("main_scenario")
+}
+
+// TestGoldenVIPOvertakesNormal queues Normal orders ahead of VIP ones on a
+// single bot; every VIP order must complete before any Normal order queued
+// before it that has not started yet.
+func TestGoldenVIPOvertakesNormal(t *testing.T) {
+	h := newHarness(t)
+
+	h.do(func(m *SystemManager) { m.AddBot(bot.BotTypeSlow) })
+	for _, typ := range []order.OrderTypeEnum{order.OrderTypeNormal, order.OrderTypeNormal, order.OrderTypeNormal, order.OrderTypeVIP, order.OrderTypeVIP} {
+		h.do(func(m *SystemManager) { m.AddOrder(typ) })
+	}
+	h.advance(time.Minute)
+
+	h.check("vip_overtakes_normal")
+}
+
+// TestGoldenPauseResume suspends a bot mid-order and resumes it later; the
+// order must finish with only the time that was left.
+func TestGoldenPauseResume(t *testing.T) {
+	h := newHarness(t)
+
+	var id string
+	h.do(func(m *SystemManager) { id, _ = m.AddBot(bot.BotTypeSlow) })
+	h.do(func(m *SystemManager) { m.AddOrder(order.OrderTypeNormal) })
+	h.advance(4 * time.Second)
+	h.do(func(m *SystemManager) {
+		if err := m.PauseBot(id, true); err != nil {
+			t.Fatalf("PauseBot: %v", err)
+		}
+	})
+	h.advance(30 * time.Second)
+	h.do(func(m *SystemManager) {
+		if err := m.ResumeBot(id); err != nil {
+			t.Fatalf("ResumeBot: %v", err)
+		}
+	})
+	h.advance(10 * time.Second)
+
+	h.check("pause_resume")
+}
+
+// TestGoldenVIPGoesToFastBot keeps a SLOW and a FAST bot idle; the dispatcher
+// must give the VIP order to the FAST bot even though the SLOW bot has been
+// idle longer, and the Normal order that follows to the SLOW bot.
+func TestGoldenVIPGoesToFastBot(t *testing.T) {
+	h := newHarness(t)
+
+	h.do(func(m *SystemManager) { m.AddBot(bot.BotTypeSlow) })
+	h.do(func(m *SystemManager) { m.AddBot(bot.BotTypeFast) })
+	h.do(func(m *SystemManager) { m.AddOrder(order.OrderTypeVIP) })
+	h.do(func(m *SystemManager) { m.AddOrder(order.OrderTypeNormal) })
+	h.advance(15 * time.Second)
+
+	h.check("vip_goes_to_fast_bot")
+}
+
+// TestGoldenScheduledPreOrder holds a pre-order for pickup in five minutes.
+// It must stay out of the queue until its lead time (the SLOW bot's cook time
+// plus the default margin) before pickup, and be ready before then.
+func TestGoldenScheduledPreOrder(t *testing.T) {
+	h := newHarness(t)
+
+	h.do(func(m *SystemManager) { m.AddBot(bot.BotTypeSlow) })
+	h.do(func(m *SystemManager) {
+		if _, err := m.AddScheduledOrder(order.OrderTypeNormal, h.start.Add(5*time.Minute)); err != nil {
+			t.Fatalf("AddScheduledOrder: %v", err)
+		}
+	})
+	h.do(func(m *SystemManager) { m.AddOrder(order.OrderTypeNormal) })
+	h.advance(5 * time.Minute)
+
+	h.check("scheduled_pre_order")
+}
+
+// TestGoldenModifyUpgradesOrder upgrades the last of three queued Normal
+// orders to VIP while the only bot is busy; it must be cooked next.
+func TestGoldenModifyUpgradesOrder(t *testing.T) {
+	h := newHarness(t)
+
+	h.do(func(m *SystemManager) { m.AddBot(bot.BotTypeSlow) })
+	for i := 0; i < 3; i++ {
+		h.do(func(m *SystemManager) { m.AddOrder(order.OrderTypeNormal) })
+	}
+	h.advance(5 * time.Second)
+	h.do(func(m *SystemManager) {
+		if _, err := m.ModifyOrder(1003, order.OrderChanges{Type: order.OrderTypeVIP}); err != nil {
+			t.Fatalf("ModifyOrder: %v", err)
+		}
+	})
+	h.advance(30 * time.Second)
+
+	h.check("modify_upgrades_order")
+}
+
+// TestGoldenPickupShelf runs two FAST bots against a one-slot pickup shelf.
+// The second bot to finish must stall until the first order is collected;
+// the second order then waits on the shelf until it expires.
+func TestGoldenPickupShelf(t *testing.T) {
+	h := newHarness(t, WithPickupShelf(1, time.Minute))
+
+	h.do(func(m *SystemManager) { m.AddBot(bot.BotTypeFast) })
+	h.do(func(m *SystemManager) { m.AddBot(bot.BotTypeFast) })
+	h.do(func(m *SystemManager) { m.AddOrder(order.OrderTypeNormal) })
+	h.do(func(m *SystemManager) { m.AddOrder(order.OrderTypeNormal) })
+	h.do(func(m *SystemManager) { m.AddOrder(order.OrderTypeNormal) })
+	h.advance(20 * time.Second)
+	h.do(func(m *SystemManager) {
+		if err := m.CollectOrder(1001); err != nil {
+			t.Fatalf("CollectOrder: %v", err)
+		}
+	})
+	h.advance(2 * time.Minute)
+
+	h.check("pickup_shelf")
+}
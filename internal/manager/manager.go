@@ -2,45 +2,246 @@ package manager
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
 	"github.com/feedme/order-controller/internal/bot"
+	"github.com/feedme/order-controller/internal/clock"
 	"github.com/feedme/order-controller/internal/event"
+	"github.com/feedme/order-controller/internal/idgen"
+	"github.com/feedme/order-controller/internal/notify"
 	"github.com/feedme/order-controller/internal/order"
 	"github.com/feedme/order-controller/internal/utils"
+	"github.com/feedme/order-controller/internal/webhook"
 )
 
-// SystemManager orchestrates the order queue and bot pool, handling job assignment
-// and tracking simulation statistics.
+var (
+	// ErrOrderNotFound is returned when an order ID is unknown to the store.
+	ErrOrderNotFound = errors.New("order not found")
+	// ErrBotNotFound is returned when a bot ID is not in the pool.
+	ErrBotNotFound = errors.New("bot not found")
+	// ErrOrderNotPending is returned when an operation requires a queued order
+	// but the order is already being processed or has finished.
+	ErrOrderNotPending = errors.New("order is not pending")
+)
+
+// SystemManager orchestrates the order queue and bot pool of a single restaurant,
+// handling job assignment and tracking simulation statistics. All of its state is
+// instance-scoped, so several SystemManagers can run in the same process.
 type SystemManager struct {
+	StoreID     string
 	OrderQueue  *order.Queue
+	Orders      *order.Store
 	BotPool     *bot.Pool
 	EventBus    *event.EventBus
+	Holding     *order.Holding
+	Shelf       *order.Shelf
+	Notifier    *notify.Notifier
+	Webhooks    *webhook.Service
 	cancelFuncs map[string]context.CancelFunc
-	mu          sync.Mutex
-	wg          sync.WaitGroup
+	// inboxes holds, per bot, the order the dispatcher has delivered to it.
+	// It is guarded by mu.
+	inboxes map[string]chan *order.Order
+	kicks   chan struct{}
+	// dispatchMu makes matching and delivering orders atomic with respect to
+	// a bot checking its inbox and joining the idle set.
+	dispatchMu sync.Mutex
+	mu         sync.Mutex
+	wg         sync.WaitGroup
+	done       chan struct{}
+	stopOnce   sync.Once
+
+	orderIDs        idgen.OrderIDGenerator
+	orderPrefix     string
+	orderSequence   *idgen.Sequence
+	botIDs          idgen.BotIDGenerator
+	processingTimes map[bot.BotTypeEnum]time.Duration
+	progressPolicy  order.ProgressPolicy
+	storeOpts       []order.StoreOption
+	idlePolicy      order.IdlePolicy
+	cost            order.CostFunc
+	clock           clock.Clock
+	preemption      PreemptionPolicy
+	scheduleMargin  time.Duration
+	shelfCapacity   int
+	shelfExpiry     time.Duration
+	notifyChannels  []notify.Channel
+	notifyOpts      []notify.Option
+	webhooks        bool
+	webhookOpts     []webhook.Option
+	// lastPreemption is guarded by mu.
+	lastPreemption time.Time
+}
+
+// Option configures a SystemManager at construction time.
+type Option func(*SystemManager)
+
+// WithStoreID sets the identifier of the restaurant managed by the SystemManager.
+func WithStoreID(id string) Option {
+	return func(m *SystemManager) {
+		m.StoreID = id
+	}
+}
+
+// WithOrderIDGenerator sets the generator used to number new orders.
+func WithOrderIDGenerator(ids idgen.OrderIDGenerator) Option {
+	return func(m *SystemManager) {
+		m.orderIDs = ids
+	}
+}
+
+// WithDailyOrderNumbers numbers orders "<prefix>-<n>", with n restarting every
+// day on the SystemManager's clock and internal IDs drawn from internal, e.g. a
+// Sequence shared by several stores. A nil internal uses a private Sequence.
+// It replaces any generator set by WithOrderIDGenerator, and vice versa.
+func WithDailyOrderNumbers(prefix string, internal *idgen.Sequence) Option {
+	return func(m *SystemManager) {
+		m.orderIDs = nil
+		m.orderPrefix = prefix
+		m.orderSequence = internal
+	}
+}
+
+// WithBotIDGenerator sets the generator used to assign bot IDs.
+func WithBotIDGenerator(ids idgen.BotIDGenerator) Option {
+	return func(m *SystemManager) {
+		m.botIDs = ids
+	}
+}
+
+// WithProcessingTimes overrides how long each bot type takes to cook an order.
+func WithProcessingTimes(times map[bot.BotTypeEnum]time.Duration) Option {
+	return func(m *SystemManager) {
+		m.processingTimes = times
+	}
+}
+
+// WithProgressPolicy sets how much cooking progress an interrupted order keeps
+// when it is returned to the queue. The default is order.KeepProgress.
+func WithProgressPolicy(p order.ProgressPolicy) Option {
+	return func(m *SystemManager) {
+		m.progressPolicy = p
+	}
+}
+
+// WithOrderRepository sets the repository the restaurant's orders are kept in,
+// e.g. an order.FileRepository to keep history across restarts. The default is
+// an in-memory repository.
+func WithOrderRepository(repo order.Repository) Option {
+	return func(m *SystemManager) {
+		m.storeOpts = append(m.storeOpts, order.WithRepository(repo))
+	}
+}
+
+// WithRetention evicts finished orders from the repository once they have been
+// finished for longer than maxAge. Order counts in the summary are unaffected.
+func WithRetention(maxAge time.Duration) Option {
+	return func(m *SystemManager) {
+		m.storeOpts = append(m.storeOpts, order.WithRetention(maxAge))
+	}
 }
 
-// NewSystemManager initializes and returns a new SystemManager with an empty queue and pool.
-func NewSystemManager() *SystemManager {
-	eb := event.NewEventBus()
-	order.Bus = eb // Link the order manager to the bus
+// WithIdlePolicy sets how the dispatcher picks between idle bots that are
+// equally suited to an order. The default is order.FastestFirst.
+func WithIdlePolicy(p order.IdlePolicy) Option {
+	return func(m *SystemManager) {
+		m.idlePolicy = p
+	}
+}
+
+// WithClock runs the restaurant on the given clock, e.g. a clock.Virtual in
+// tests. The default is clock.Real.
+func WithClock(c clock.Clock) Option {
+	return func(m *SystemManager) {
+		m.clock = c
+	}
+}
 
+// NewSystemManager initializes and returns a new SystemManager with an empty queue,
+// order store and pool, each wired to its own event bus.
+func NewSystemManager(opts ...Option) *SystemManager {
 	m := &SystemManager{
-		OrderQueue:  order.NewQueue(),
-		BotPool:     bot.NewPool(),
-		EventBus:    eb,
-		cancelFuncs: make(map[string]context.CancelFunc),
+		cancelFuncs:    make(map[string]context.CancelFunc),
+		inboxes:        make(map[string]chan *order.Order),
+		kicks:          make(chan struct{}, 1),
+		done:           make(chan struct{}),
+		progressPolicy: order.KeepProgress,
+		cost:           DefaultCost,
+		clock:          clock.Real,
+		scheduleMargin: DefaultScheduleMargin,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	eb := event.NewEventBus(event.WithClock(m.clock))
+	m.EventBus = eb
+	m.OrderQueue = order.NewQueue(
+		order.WithQueueClock(m.clock),
+		order.WithIdlePolicy(m.idlePolicy),
+		order.WithReadyFunc(m.kick),
+	)
+	if m.orderIDs == nil {
+		m.orderIDs = idgen.NewDailyOrderIDs(m.orderPrefix, order.DefaultFirstOrderID, m.orderSequence, m.clock.Now)
+	}
+	storeOpts := append([]order.StoreOption{order.WithStoreClock(m.clock)}, m.storeOpts...)
+	m.Orders = order.NewStore(eb, m.orderIDs, storeOpts...)
+	poolOpts := []bot.PoolOption{
+		bot.WithEventBus(eb),
+		bot.WithClock(m.clock),
+		// Journal bot transitions so reports can leave out paused time.
+		bot.WithObserver(func(t bot.StatusTransition) {
+			m.Orders.RecordWorker(order.WorkerTransition{
+				WorkerID: t.BotID,
+				From:     string(t.From),
+				To:       string(t.To),
+				At:       t.At,
+			})
+		}),
+	}
+	if m.botIDs != nil {
+		poolOpts = append(poolOpts, bot.WithIDGenerator(m.botIDs))
 	}
+	if m.processingTimes != nil {
+		poolOpts = append(poolOpts, bot.WithProcessingTimes(m.processingTimes))
+	}
+	m.BotPool = bot.NewPool(poolOpts...)
+	m.Holding = order.NewHolding(m.clock, m.releaseScheduled)
+	if m.shelfCapacity > 0 {
+		m.Shelf = order.NewShelf(m.clock, m.shelfCapacity, m.shelfExpiry, m.expireOrder)
+	}
+	if len(m.notifyChannels) > 0 {
+		notifyOpts := []notify.Option{notify.WithClock(m.clock)}
+		if m.Shelf != nil {
+			notifyOpts = append(notifyOpts, notify.WithPickupShelf())
+		}
+		notifyOpts = append(notifyOpts, m.notifyOpts...)
+		m.Notifier = notify.New(eb, m.notifyChannels, notifyOpts...)
+		m.Notifier.Start()
+	}
+	if m.webhooks {
+		webhookOpts := append([]webhook.Option{webhook.WithClock(m.clock), webhook.WithStoreID(m.StoreID)}, m.webhookOpts...)
+		m.Webhooks = webhook.New(eb, webhookOpts...)
+		m.Webhooks.Start()
+	}
+
+	m.recoverOrders()
+	go m.dispatchLoop()
 
-	// Start background logging
+	// Start background logging and eviction of expired orders
 	go func() {
-		ticker := time.NewTicker(1 * time.Second)
+		ticker := m.clock.NewTicker(1 * time.Second)
 		defer ticker.Stop()
-		for range ticker.C {
-			m.LogProcessingStatus()
+		for {
+			select {
+			case <-m.done:
+				return
+			case now := <-ticker.Chan():
+				m.LogProcessingStatus()
+				m.Orders.EvictExpired(now)
+			}
 		}
 	}()
 
@@ -49,26 +250,55 @@ func NewSystemManager() *SystemManager {
 
 // AddOrder creates a new order of the specified type and adds it to the system queue.
 func (m *SystemManager) AddOrder(orderType order.OrderTypeEnum) {
-	m.OrderQueue.SetPaused(true)
-	order.AddOrder(m.OrderQueue, orderType)
-	m.OrderQueue.SetPaused(false)
+	ord := m.Orders.AddOrder(m.OrderQueue, orderType)
+	m.maybePreempt(ord)
+}
+
+// CancelOrder withdraws a pending order from the queue, or a scheduled one from
+// the holding area, and marks it CANCELLED. Orders that a bot has already picked
+// up cannot be cancelled.
+func (m *SystemManager) CancelOrder(id int) error {
+	ord := m.Orders.GetOrder(id)
+	if ord == nil {
+		return ErrOrderNotFound
+	}
+	if m.Holding.Remove(id) == nil && m.OrderQueue.Remove(id) == nil {
+		return fmt.Errorf("cancel order %d (%s): %w", id, ord.Status(), ErrOrderNotPending)
+	}
+	if err := ord.Transition(order.OrderStatusCancelled, order.ActorUser); err != nil {
+		return err
+	}
+
+	utils.Log("Order •%d cancelled - Status: CANCELLED", ord.ID)
+	m.EventBus.Publish(event.Event{
+		Type: event.OrderCancelled,
+		Data: ord.Snapshot(),
+	})
+	return nil
 }
 
 // AddBot creates a new bot, adds it to the pool, and starts its processing loop.
-// Returns the ID of the newly created bot.
-func (m *SystemManager) AddBot(botType bot.BotTypeEnum) string {
-	b := m.BotPool.AddBot(botType)
+// Returns the ID of the newly created bot, or an error if the pool could not
+// give it an unused ID.
+func (m *SystemManager) AddBot(botType bot.BotTypeEnum) (string, error) {
+	b, err := m.BotPool.AddBot(botType)
+	if err != nil {
+		utils.LogError("Cannot add %s bot: %v", botType, err)
+		return "", err
+	}
 	utils.Log("Bot #%s added into pool - Status: ACTIVE (Type: %s)", b.ID, b.Type)
 
 	// Start the bot worker loop
 	ctx, cancel := context.WithCancel(context.Background())
+	inbox := make(chan *order.Order, 1)
 	m.mu.Lock()
 	m.cancelFuncs[b.ID] = cancel
+	m.inboxes[b.ID] = inbox
 	m.mu.Unlock()
 
 	m.wg.Add(1)
-	go m.botLoop(ctx, b)
-	return b.ID
+	go m.botLoop(ctx, b, inbox)
+	return b.ID, nil
 }
 
 // RemoveBot stops and removes a bot from the system. If id is empty, the last
@@ -89,11 +319,55 @@ func (m *SystemManager) RemoveBot(id string) {
 	utils.Log("Bot #%s removed from pool", b.ID)
 }
 
-// botLoop is the main worker loop for a bot. It waits for order availability
-// signals or context cancellation. It is event-reactive, eliminating the need
-// for periodic polling.
-func (m *SystemManager) botLoop(ctx context.Context, b *bot.Bot) {
+// PauseBot stops a bot from picking up new orders without removing it. With
+// immediate set, the bot's current order is suspended and kept with its remaining
+// time; otherwise the bot finishes the current order first.
+func (m *SystemManager) PauseBot(id string, immediate bool) error {
+	b := m.BotPool.GetBot(id)
+	if b == nil {
+		return ErrBotNotFound
+	}
+	if err := b.Pause(immediate); err != nil {
+		return err
+	}
+	utils.Log("Bot #%s pause requested (immediate: %t)", b.ID, immediate)
+	return nil
+}
+
+// ResumeBot returns a paused or maintained bot to service.
+func (m *SystemManager) ResumeBot(id string) error {
+	b := m.BotPool.GetBot(id)
+	if b == nil {
+		return ErrBotNotFound
+	}
+	if err := b.Resume(); err != nil {
+		return err
+	}
+	utils.Log("Bot #%s resumed - Status: %s", b.ID, b.Status())
+	return nil
+}
+
+// StartMaintenance puts a bot into maintenance (e.g. a cleaning cycle) once it
+// has finished its current order. Use ResumeBot to end maintenance.
+func (m *SystemManager) StartMaintenance(id string) error {
+	b := m.BotPool.GetBot(id)
+	if b == nil {
+		return ErrBotNotFound
+	}
+	if err := b.StartMaintenance(); err != nil {
+		return err
+	}
+	utils.Log("Bot #%s maintenance requested", b.ID)
+	return nil
+}
+
+// botLoop is the main worker loop for a bot. An idle bot joins the queue's
+// idle set and sleeps until the dispatcher delivers an order to its inbox, its
+// status changes or the context is cancelled. It is event-reactive,
+// eliminating the need for periodic polling.
+func (m *SystemManager) botLoop(ctx context.Context, b *bot.Bot, inbox <-chan *order.Order) {
 	defer m.wg.Done()
+	defer m.closeInbox(b.ID)
 
 	for {
 		// Check for cancellation BEFORE picking up work to avoid processing
@@ -104,26 +378,57 @@ func (m *SystemManager) botLoop(ctx context.Context, b *bot.Bot) {
 		default:
 		}
 
-		// First, try to pop any existing orders immediately.
-		ord := m.OrderQueue.Pop()
-		if ord != nil {
-			// Notify the system that an order has been assigned.
-			m.EventBus.Publish(event.Event{
-				Type: event.OrderAssigned,
-				Data: ord,
-			})
-			m.processAndEmit(ctx, b, ord)
+		// Paused and maintained bots leave the idle set and wait until their
+		// status changes. An order delivered as the bot left goes back to its
+		// place in the queue.
+		if !b.IsAvailable() {
+			m.OrderQueue.Leave(b.ID)
+			select {
+			case <-ctx.Done():
+				return
+			case ord := <-inbox:
+				m.OrderQueue.Return(ord)
+			case <-b.Wake():
+			}
 			continue
 		}
 
-		// No orders available, wait for notification from the queue or cancellation.
-		select {
-		case <-ctx.Done():
-			return
-		case <-m.OrderQueue.Notify:
-			// New order might be available, loop back to Pop.
+		ord := m.awaitOrder(ctx, b, inbox)
+		if ord == nil {
 			continue
 		}
+		// Notify the system that an order has been assigned.
+		m.EventBus.Publish(event.Event{
+			Type: event.OrderAssigned,
+			Data: ord.Snapshot(),
+		})
+		m.processAndEmit(ctx, b, ord)
+	}
+}
+
+// awaitOrder joins the idle set unless an order has already been delivered,
+// and waits for one. It returns nil if the bot's status changed (e.g. it was
+// paused or dedicated) or the context was cancelled, so the caller re-evaluates.
+func (m *SystemManager) awaitOrder(ctx context.Context, b *bot.Bot, inbox <-chan *order.Order) *order.Order {
+	m.dispatchMu.Lock()
+	select {
+	case ord := <-inbox:
+		m.dispatchMu.Unlock()
+		return ord
+	default:
+		// No dispatch is in flight, so the bot cannot be handed a second
+		// order before it takes the first.
+		m.OrderQueue.Idle(b.Worker())
+	}
+	m.dispatchMu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return nil
+	case ord := <-inbox:
+		return ord
+	case <-b.Wake():
+		return nil
 	}
 }
 
@@ -134,15 +439,23 @@ func (m *SystemManager) processAndEmit(ctx context.Context, b *bot.Bot, ord *ord
 	if completed {
 		m.EventBus.Publish(event.Event{
 			Type: event.OrderCompleted,
-			Data: ord,
+			Data: ord.Snapshot(),
 		})
+		m.shelve(ctx, b, ord)
 	} else {
-		// Order was cancelled, put it back to the front of the queue
-		ord.Status = order.OrderStatusPending
+		// Processing was interrupted, or never started because the bot was
+		// paused as the order arrived; put the order back to the front of the queue
+		if ord.Status() != order.OrderStatusPending {
+			if err := ord.Transition(order.OrderStatusPending, order.ActorSystem); err != nil {
+				utils.LogError("Cannot requeue Order •%d: %v", ord.ID, err)
+				return
+			}
+		}
+		ord.ApplyProgressPolicy(m.progressPolicy)
 		m.OrderQueue.PushFront(ord)
 		m.EventBus.Publish(event.Event{
-			Type: event.OrderCancelled,
-			Data: ord,
+			Type: event.OrderRequeued,
+			Data: ord.Snapshot(),
 		})
 	}
 }
@@ -152,46 +465,93 @@ func (m *SystemManager) Wait() {
 	m.wg.Wait()
 }
 
+// Stop shuts the restaurant down: the status ticker, pre-order releases,
+// customer notifications and webhooks are halted and every bot loop is cancelled. It blocks until all bot loops have
+// exited and is safe to call more than once.
+func (m *SystemManager) Stop() {
+	m.stopOnce.Do(func() {
+		close(m.done)
+		m.Holding.Stop()
+		if m.Shelf != nil {
+			m.Shelf.Stop()
+		}
+		if m.Notifier != nil {
+			m.Notifier.Stop()
+		}
+		if m.Webhooks != nil {
+			m.Webhooks.Stop()
+		}
+		m.mu.Lock()
+		for id, cancel := range m.cancelFuncs {
+			cancel()
+			delete(m.cancelFuncs, id)
+		}
+		m.mu.Unlock()
+	})
+	m.wg.Wait()
+}
+
+// Summary holds the headline statistics of a single restaurant.
+type Summary struct {
+	StoreID         string `json:"store_id,omitempty"`
+	TotalOrders     int    `json:"total_orders"`
+	VIPOrders       int    `json:"vip_orders"`
+	NormalOrders    int    `json:"normal_orders"`
+	CompletedOrders int    `json:"completed_orders"`
+	ActiveBots      int    `json:"active_bots"`
+	PausedBots      int    `json:"paused_bots"`
+	MaintenanceBots int    `json:"maintenance_bots"`
+	StalledBots     int    `json:"stalled_bots"`
+	PendingOrders   int    `json:"pending_orders"`
+	ScheduledOrders int    `json:"scheduled_orders"`
+	ReadyOrders     int    `json:"ready_orders"`
+	WastedOrders    int    `json:"wasted_orders"`
+}
+
+// Summary returns the current simulation statistics.
+func (m *SystemManager) Summary() Summary {
+	bots := m.BotPool.CountByStatus()
+	ready := 0
+	if m.Shelf != nil {
+		ready = m.Shelf.Len()
+	}
+	return Summary{
+		StoreID:         m.StoreID,
+		TotalOrders:     m.Orders.GetTotalCount(),
+		VIPOrders:       m.Orders.GetCountByType(order.OrderTypeVIP),
+		NormalOrders:    m.Orders.GetCountByType(order.OrderTypeNormal),
+		CompletedOrders: m.Orders.GetCompletedCount(),
+		ActiveBots:      bot.CountActive(bots),
+		PausedBots:      bots[bot.BotStatusPaused],
+		MaintenanceBots: bots[bot.BotStatusMaintenance],
+		StalledBots:     bots[bot.BotStatusStalled],
+		PendingOrders:   m.OrderQueue.Len(),
+		ScheduledOrders: m.Holding.Len(),
+		ReadyOrders:     ready,
+		WastedOrders:    m.Orders.GetWastedCount(),
+	}
+}
+
 // GetSummary compiles and returns a formatted string of the current simulation statistics.
 func (m *SystemManager) GetSummary() string {
-	total := order.GetTotalCount()
-	vip := order.GetCountByType(order.OrderTypeVIP)
-	normal := order.GetCountByType(order.OrderTypeNormal)
-	completed := order.GetCompletedCount()
-	activeBots := m.BotPool.GetActiveBotsCount()
-	pending := m.OrderQueue.Len()
-
-	return fmt.Sprintf("\nFinal Status:\n- Total Orders Processed: %d (%d VIP, %d Normal)\n- Orders Completed: %d\n- Active Bots: %d\n- Pending Orders: %d",
-		total, vip, normal, completed, activeBots, pending)
+	s := m.Summary()
+	return fmt.Sprintf("\nFinal Status:\n- Total Orders Created: %d (%d VIP, %d Normal)\n- Orders Completed: %d\n- Active Bots: %d (%d Stalled; %d Paused, %d Maintenance)\n- Pending Orders: %d\n- Scheduled Orders: %d\n- Ready Orders: %d\n- Wasted Orders: %d",
+		s.TotalOrders, s.VIPOrders, s.NormalOrders, s.CompletedOrders, s.ActiveBots, s.StalledBots, s.PausedBots, s.MaintenanceBots,
+		s.PendingOrders, s.ScheduledOrders, s.ReadyOrders, s.WastedOrders)
 }
 
 // LogProcessingStatus iterates over all active bots and logs the status of orders currently being processed,
-// including the calculated time remaining.
+// including the time remaining. The remaining time accounts for progress made on
+// earlier bots. It only reads immutable snapshots, so it is safe to call while
+// bots are working.
 func (m *SystemManager) LogProcessingStatus() {
-	m.BotPool.ForEach(bot.BotStatusProcessing, func(b *bot.Bot) {
-		// Only check bots that have a current order ID
-		if b.CurrentOrderID != nil {
-			// Retrieve the full order struct
-			ord := order.GetOrder(*b.CurrentOrderID)
-
-			// Safety check: verify order exists and has a start time
-			if ord != nil && ord.ProcessedAt != nil {
-				// 1. Determine Total Duration based on Bot Type
-				totalDuration := bot.ProcessingTimeMap[b.Type]
-
-				// 2. Calculate Elapsed Time
-				elapsed := time.Since(*ord.ProcessedAt)
-
-				// 3. Calculate Remaining Time
-				remaining := totalDuration.Seconds() - elapsed.Seconds()
-				if remaining < 0 {
-					remaining = 0
-				}
-
-				// 4. Log
-				utils.Log("Order •%d processing by Bot #%s (%s). Time Remaining: %.2fs",
-					ord.ID, b.ID, b.Type, remaining)
-			}
+	for _, b := range m.BotPool.Snapshots() {
+		// Only check bots that are processing an order
+		if b.Status != bot.BotStatusProcessing || b.CurrentOrderID == nil {
+			continue
 		}
-	})
+
+		utils.Log("Order •%d processing by Bot #%s (%s). Time Remaining: %.2fs",
+			*b.CurrentOrderID, b.ID, b.Type, b.TimeRemaining.Seconds())
+	}
 }
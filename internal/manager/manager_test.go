@@ -1,6 +1,7 @@
 package manager
 
 import (
+	"errors"
 	"testing"
 	"time"
 
@@ -12,7 +13,7 @@ func TestSystemManager(t *testing.T) {
 	m := NewSystemManager()
 
 	// Test AddBot
-	botID := m.AddBot(bot.BotTypeFast)
+	botID, _ := m.AddBot(bot.BotTypeFast)
 	if len(botID) != 3 {
 		t.Errorf("Expected bot ID length 3, got %d", len(botID))
 	}
@@ -44,3 +45,72 @@ func TestSystemManager(t *testing.T) {
 	// We can't easily wait for the loop to exit without m.Wait() which blocks.
 	// But we can check that it doesn't crash.
 }
+
+func TestCancelOrder(t *testing.T) {
+	m := NewSystemManager()
+	defer m.Stop()
+
+	m.AddOrder(order.OrderTypeNormal)
+	m.AddOrder(order.OrderTypeNormal)
+
+	if err := m.CancelOrder(1001); err != nil {
+		t.Fatalf("CancelOrder: %v", err)
+	}
+	if m.OrderQueue.Len() != 1 {
+		t.Errorf("Expected 1 order left in queue, got %d", m.OrderQueue.Len())
+	}
+	if got := m.Orders.GetOrder(1001).Status(); got != order.OrderStatusCancelled {
+		t.Errorf("Expected CANCELLED, got %s", got)
+	}
+
+	// Cancelling twice is rejected
+	if err := m.CancelOrder(1001); !errors.Is(err, ErrOrderNotPending) {
+		t.Errorf("Expected ErrOrderNotPending, got %v", err)
+	}
+	if err := m.CancelOrder(4242); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("Expected ErrOrderNotFound, got %v", err)
+	}
+}
+
+func TestPauseAndResumeBot(t *testing.T) {
+	m := NewSystemManager(WithProcessingTimes(map[bot.BotTypeEnum]time.Duration{
+		bot.BotTypeFast: 20 * time.Millisecond,
+	}))
+	defer m.Stop()
+
+	id, _ := m.AddBot(bot.BotTypeFast)
+	if err := m.PauseBot(id, false); err != nil {
+		t.Fatal(err)
+	}
+	m.AddOrder(order.OrderTypeNormal)
+
+	time.Sleep(50 * time.Millisecond)
+	if m.OrderQueue.Len() != 1 {
+		t.Errorf("Expected paused bot to leave the order queued, queue length %d", m.OrderQueue.Len())
+	}
+	s := m.Summary()
+	if s.ActiveBots != 0 || s.PausedBots != 1 {
+		t.Errorf("Expected 0 active and 1 paused bot, got %+v", s)
+	}
+
+	if err := m.ResumeBot(id); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for m.Orders.GetCompletedCount() != 1 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if m.Orders.GetCompletedCount() != 1 {
+		t.Error("Expected resumed bot to complete the order")
+	}
+
+	if err := m.StartMaintenance(id); err != nil {
+		t.Fatal(err)
+	}
+	if m.Summary().MaintenanceBots != 1 {
+		t.Errorf("Expected 1 bot in maintenance, got %+v", m.Summary())
+	}
+	if err := m.PauseBot("missing", true); !errors.Is(err, ErrBotNotFound) {
+		t.Errorf("Expected ErrBotNotFound, got %v", err)
+	}
+}
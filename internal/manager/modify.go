@@ -0,0 +1,48 @@
+package manager
+
+import (
+	"fmt"
+
+	"github.com/feedme/order-controller/internal/event"
+	"github.com/feedme/order-controller/internal/order"
+	"github.com/feedme/order-controller/internal/utils"
+)
+
+// Modification is the payload of an OrderModified event.
+type Modification struct {
+	// Order is the order after the change.
+	Order order.OrderSnapshot
+	Diff  order.OrderDiff
+}
+
+// ModifyOrder changes the type or items of a pending order in place, e.g. to
+// upgrade a customer to VIP or add an item, so the order keeps its ID. A new
+// type moves the order to its new priority tier, where it keeps its place by
+// creation time. Orders that a bot has picked up or that have finished cannot
+// be modified; the error then wraps ErrOrderNotPending.
+func (m *SystemManager) ModifyOrder(id int, changes order.OrderChanges) (order.OrderDiff, error) {
+	if err := changes.Validate(); err != nil {
+		return order.OrderDiff{}, err
+	}
+	ord := m.Orders.GetOrder(id)
+	if ord == nil {
+		return order.OrderDiff{}, ErrOrderNotFound
+	}
+	diff, ok := m.Orders.ModifyOrder(m.OrderQueue, id, changes)
+	if !ok {
+		return order.OrderDiff{}, fmt.Errorf("modify order %d (%s): %w", id, ord.Status(), ErrOrderNotPending)
+	}
+	if !diff.Changed() {
+		return diff, nil
+	}
+
+	utils.Log("Order •%d modified (%s) - Status: PENDING", id, diff)
+	m.EventBus.Publish(event.Event{
+		Type: event.OrderModified,
+		Data: Modification{Order: ord.Snapshot(), Diff: diff},
+	})
+	if diff.TypeChanged() {
+		m.maybePreempt(ord)
+	}
+	return diff, nil
+}
@@ -0,0 +1,80 @@
+package manager
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/feedme/order-controller/internal/bot"
+	"github.com/feedme/order-controller/internal/event"
+	"github.com/feedme/order-controller/internal/order"
+)
+
+func TestModifyOrderUpgradesPendingOrder(t *testing.T) {
+	m := NewSystemManager()
+	defer m.Stop()
+	modified := m.EventBus.Subscribe(event.OrderModified)
+
+	n1 := m.Orders.AddOrder(m.OrderQueue, order.OrderTypeNormal)
+	n2 := m.Orders.AddOrder(m.OrderQueue, order.OrderTypeNormal)
+	items := []order.Item{{Name: "Burger", Quantity: 1}, {Name: "Fries", Quantity: 2}}
+
+	diff, err := m.ModifyOrder(n2.ID, order.OrderChanges{Type: order.OrderTypeVIP, Items: items})
+	if err != nil {
+		t.Fatalf("ModifyOrder: %v", err)
+	}
+	if n2.ID != diff.OrderID || !diff.TypeChanged() || !diff.ItemsChanged() {
+		t.Fatalf("Unexpected diff: %+v", diff)
+	}
+	if pos, _ := m.OrderQueue.Position(n2.ID); pos != 1 {
+		t.Errorf("Expected the upgraded order first in line, got position %d", pos)
+	}
+
+	select {
+	case ev := <-modified:
+		mod, ok := ev.Data.(Modification)
+		if !ok || mod.Order.ID != n2.ID || mod.Order.Type != order.OrderTypeVIP || mod.Diff.From.Type != order.OrderTypeNormal {
+			t.Errorf("Unexpected OrderModified payload: %+v", ev.Data)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected an OrderModified event")
+	}
+	if s := m.Summary(); s.VIPOrders != 1 || s.NormalOrders != 1 {
+		t.Errorf("Expected 1 VIP and 1 Normal order, got %+v", s)
+	}
+
+	// Changing nothing publishes nothing.
+	if diff, err := m.ModifyOrder(n1.ID, order.OrderChanges{Type: order.OrderTypeNormal}); err != nil || diff.Changed() {
+		t.Errorf("Expected an empty diff, got %+v, %v", diff, err)
+	}
+	select {
+	case ev := <-modified:
+		t.Errorf("Expected no event for an unchanged order, got %+v", ev.Data)
+	default:
+	}
+}
+
+func TestModifyOrderRejectsStartedOrders(t *testing.T) {
+	m := NewSystemManager(WithProcessingTimes(map[bot.BotTypeEnum]time.Duration{
+		bot.BotTypeFast: time.Minute,
+	}))
+	defer m.Stop()
+
+	ord := m.Orders.AddOrder(m.OrderQueue, order.OrderTypeNormal)
+	m.AddBot(bot.BotTypeFast)
+	waitForOrderStatus(t, m, ord.ID, order.OrderStatusProcessing)
+
+	_, err := m.ModifyOrder(ord.ID, order.OrderChanges{Type: order.OrderTypeVIP})
+	if !errors.Is(err, ErrOrderNotPending) {
+		t.Errorf("Expected ErrOrderNotPending for a PROCESSING order, got %v", err)
+	}
+	if ord.Snapshot().Type != order.OrderTypeNormal {
+		t.Error("Expected the order to be unchanged")
+	}
+	if _, err := m.ModifyOrder(4242, order.OrderChanges{Type: order.OrderTypeVIP}); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("Expected ErrOrderNotFound, got %v", err)
+	}
+	if _, err := m.ModifyOrder(ord.ID, order.OrderChanges{Type: "Catering"}); !errors.Is(err, order.ErrUnknownOrderType) {
+		t.Errorf("Expected ErrUnknownOrderType, got %v", err)
+	}
+}
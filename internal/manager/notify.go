@@ -0,0 +1,15 @@
+package manager
+
+import "github.com/feedme/order-controller/internal/notify"
+
+// WithNotifier tells customers about their orders through the given channels,
+// e.g. SMS for orders placed with a phone number. The notifier runs on the
+// restaurant's event bus and clock and is available as
+// SystemManager.Notifier; without this option it is nil. With a pickup shelf,
+// customers are told their order is ready once it is on the shelf.
+func WithNotifier(channels []notify.Channel, opts ...notify.Option) Option {
+	return func(m *SystemManager) {
+		m.notifyChannels = channels
+		m.notifyOpts = opts
+	}
+}
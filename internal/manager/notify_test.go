@@ -0,0 +1,88 @@
+package manager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/feedme/order-controller/internal/bot"
+	"github.com/feedme/order-controller/internal/notify"
+	"github.com/feedme/order-controller/internal/order"
+)
+
+func TestNotifierTellsCustomerOrderIsReady(t *testing.T) {
+	fake := &notify.FakeChannel{}
+	m := NewSystemManager(
+		WithNotifier([]notify.Channel{fake}),
+		WithProcessingTimes(map[bot.BotTypeEnum]time.Duration{bot.BotTypeFast: 10 * time.Millisecond}),
+	)
+	defer m.Stop()
+
+	contact := order.Contact{Name: "Aisyah", Phone: "+60123456789"}
+	results, err := m.AddOrders([]order.OrderRequest{{Type: order.OrderTypeNormal, Contact: contact}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ord := results[0].Order
+	if ord.Contact != contact {
+		t.Errorf("Expected the order to carry its contact, got %+v", ord.Contact)
+	}
+	m.AddBot(bot.BotTypeFast)
+	waitForOrderStatus(t, m, ord.ID, order.OrderStatusComplete)
+
+	deadline := time.Now().Add(time.Second)
+	for len(fake.Messages()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Timeout waiting for the ready notification")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	msg := fake.Messages()[0]
+	if msg.Kind != notify.KindReady || msg.Order.ID != ord.ID || msg.Order.Contact != contact {
+		t.Errorf("Unexpected notification: %+v", msg)
+	}
+}
+
+func TestNotifierWaitsForPickupShelf(t *testing.T) {
+	fake := &notify.FakeChannel{}
+	m := NewSystemManager(
+		WithNotifier([]notify.Channel{fake}),
+		WithPickupShelf(1, 0),
+		WithProcessingTimes(map[bot.BotTypeEnum]time.Duration{bot.BotTypeFast: 10 * time.Millisecond}),
+	)
+	defer m.Stop()
+
+	first := m.Orders.AddOrder(m.OrderQueue, order.OrderTypeNormal)
+	second := m.Orders.AddOrder(m.OrderQueue, order.OrderTypeNormal)
+	id, _ := m.AddBot(bot.BotTypeFast)
+	waitForOrderStatus(t, m, first.ID, order.OrderStatusReady)
+	waitForBotStatus(t, m, id, bot.BotStatusStalled)
+
+	// The stalled bot's order is cooked but not on the shelf yet.
+	if err := m.CollectOrder(first.ID); err != nil {
+		t.Fatalf("CollectOrder: %v", err)
+	}
+	waitForOrderStatus(t, m, second.ID, order.OrderStatusReady)
+	deadline := time.Now().Add(time.Second)
+	for len(fake.Messages()) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("Timeout waiting for the ready notifications")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	m.Notifier.Stop()
+
+	msgs := fake.Messages()
+	if len(msgs) != 2 {
+		t.Fatalf("Expected one notification per order, got %+v", msgs)
+	}
+	notified := make(map[int]bool)
+	for _, msg := range msgs {
+		if msg.Order.Status != order.OrderStatusReady {
+			t.Errorf("Expected notifications only once orders are shelved, got %+v", msg)
+		}
+		notified[msg.Order.ID] = true
+	}
+	if !notified[first.ID] || !notified[second.ID] {
+		t.Errorf("Expected a notification for each order, got %+v", msgs)
+	}
+}
@@ -0,0 +1,113 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/feedme/order-controller/internal/bot"
+	"github.com/feedme/order-controller/internal/event"
+	"github.com/feedme/order-controller/internal/order"
+	"github.com/feedme/order-controller/internal/utils"
+)
+
+// ErrOrderNotReady is returned by CollectOrder for an order that is not
+// waiting on the pickup shelf.
+var ErrOrderNotReady = errors.New("order is not ready for pickup")
+
+// WithPickupShelf puts cooked orders on a pickup shelf with room for capacity
+// orders, where they are READY until collected. A bot that finishes an order
+// while the shelf is full stalls until a slot frees up. Orders not collected
+// within expireAfter are thrown away and counted as waste; zero keeps them
+// until collected. Without a shelf, or with a capacity below one, orders end
+// at COMPLETE and SystemManager.Shelf is nil.
+func WithPickupShelf(capacity int, expireAfter time.Duration) Option {
+	return func(m *SystemManager) {
+		m.shelfCapacity = capacity
+		m.shelfExpiry = expireAfter
+	}
+}
+
+// CollectOrder hands a READY order to its customer, freeing its shelf slot.
+func (m *SystemManager) CollectOrder(id int) error {
+	ord := m.Orders.GetOrder(id)
+	if ord == nil {
+		return ErrOrderNotFound
+	}
+	var err error
+	collect := func(ord *order.Order) {
+		if err = ord.Transition(order.OrderStatusCollected, order.ActorUser); err != nil {
+			return
+		}
+		utils.Log("Order •%d collected - Status: COLLECTED", ord.ID)
+		m.EventBus.Publish(event.Event{
+			Type: event.OrderCollected,
+			Data: ord.Snapshot(),
+		})
+	}
+	if m.Shelf == nil || !m.Shelf.Take(id, collect) {
+		return fmt.Errorf("collect order %d (%s): %w", id, ord.Status(), ErrOrderNotReady)
+	}
+	return err
+}
+
+// ShelvedOrders returns the orders waiting on the pickup shelf, longest
+// waiting first. It returns nil if the restaurant has no shelf.
+func (m *SystemManager) ShelvedOrders() []order.ShelvedOrder {
+	if m.Shelf == nil {
+		return nil
+	}
+	return m.Shelf.List()
+}
+
+// shelve puts an order the bot has just completed on the pickup shelf. While
+// the shelf is full the bot is STALLED, holding the order until a slot frees
+// up; stalled bots get slots in the order they stalled. If the bot is removed
+// in the meantime, the order is thrown away.
+func (m *SystemManager) shelve(ctx context.Context, b *bot.Bot, ord *order.Order) {
+	if m.Shelf == nil {
+		return
+	}
+	// The order is READY before it can be collected or expire.
+	ready := func(ord *order.Order) {
+		if err := ord.Transition(order.OrderStatusReady, order.BotActor(b.ID)); err != nil {
+			utils.LogError("Cannot shelve Order •%d: %v", ord.ID, err)
+			return
+		}
+		utils.Log("Order •%d on pickup shelf - Status: READY", ord.ID)
+		m.EventBus.Publish(event.Event{
+			Type: event.OrderReady,
+			Data: ord.Snapshot(),
+		})
+	}
+	placed, slot := m.Shelf.Place(ord, ready)
+	if !placed {
+		// This only fails if the bot was removed; ctx is then cancelled.
+		_ = b.Stall(ord.ID)
+		utils.Log("Bot #%s stalled with Order •%d - Status: STALLED (shelf full)", b.ID, ord.ID)
+		select {
+		case <-ctx.Done():
+			if m.Shelf.Withdraw(ord) {
+				m.expireOrder(ord)
+				return
+			}
+			// Placed on the shelf just as the bot was removed.
+		case <-slot:
+		}
+		b.Unstall(ord.ID)
+	}
+}
+
+// expireOrder throws away a cooked order that was not collected in time.
+func (m *SystemManager) expireOrder(ord *order.Order) {
+	if err := ord.Transition(order.OrderStatusExpired, order.ActorSystem); err != nil {
+		utils.LogError("Cannot expire Order •%d: %v", ord.ID, err)
+		return
+	}
+	utils.Log("Order •%d not collected, thrown away - Status: EXPIRED", ord.ID)
+	m.EventBus.Publish(event.Event{
+		Type: event.OrderExpired,
+		Data: ord.Snapshot(),
+	})
+}
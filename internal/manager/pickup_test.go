@@ -0,0 +1,84 @@
+package manager
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/feedme/order-controller/internal/bot"
+	"github.com/feedme/order-controller/internal/order"
+)
+
+func TestCollectOrderFromShelf(t *testing.T) {
+	m := NewSystemManager(
+		WithPickupShelf(1, 0),
+		WithProcessingTimes(map[bot.BotTypeEnum]time.Duration{bot.BotTypeFast: 10 * time.Millisecond}),
+	)
+	defer m.Stop()
+
+	first := m.Orders.AddOrder(m.OrderQueue, order.OrderTypeNormal)
+	second := m.Orders.AddOrder(m.OrderQueue, order.OrderTypeNormal)
+	id, _ := m.AddBot(bot.BotTypeFast)
+	waitForOrderStatus(t, m, first.ID, order.OrderStatusReady)
+	waitForBotStatus(t, m, id, bot.BotStatusStalled)
+
+	if err := m.CollectOrder(second.ID); !errors.Is(err, ErrOrderNotReady) {
+		t.Errorf("Expected ErrOrderNotReady for an order held by a stalled bot, got %v", err)
+	}
+	if s := m.Summary(); s.StalledBots != 1 || s.ReadyOrders != 1 {
+		t.Errorf("Expected 1 stalled bot and 1 ready order, got %+v", s)
+	}
+	if s := m.GetSummary(); !strings.Contains(s, "(1 Stalled;") || !strings.Contains(s, "- Ready Orders: 1") {
+		t.Errorf("Expected the printed summary to show the stalled bot and ready order, got %s", s)
+	}
+
+	if err := m.CollectOrder(first.ID); err != nil {
+		t.Fatalf("CollectOrder: %v", err)
+	}
+	if first.Status() != order.OrderStatusCollected {
+		t.Errorf("Expected the order COLLECTED, got %s", first.Status())
+	}
+	waitForOrderStatus(t, m, second.ID, order.OrderStatusReady)
+	waitForBotStatus(t, m, id, bot.BotStatusIdle)
+
+	if err := m.CollectOrder(first.ID); !errors.Is(err, ErrOrderNotReady) {
+		t.Errorf("Expected ErrOrderNotReady for a collected order, got %v", err)
+	}
+	if err := m.CollectOrder(4242); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("Expected ErrOrderNotFound, got %v", err)
+	}
+}
+
+func TestUncollectedOrderIsWasted(t *testing.T) {
+	m := NewSystemManager(
+		WithPickupShelf(2, 20*time.Millisecond),
+		WithProcessingTimes(map[bot.BotTypeEnum]time.Duration{bot.BotTypeFast: 10 * time.Millisecond}),
+	)
+	defer m.Stop()
+
+	ord := m.Orders.AddOrder(m.OrderQueue, order.OrderTypeNormal)
+	m.AddBot(bot.BotTypeFast)
+	waitForOrderStatus(t, m, ord.ID, order.OrderStatusExpired)
+
+	if len(m.ShelvedOrders()) != 0 {
+		t.Errorf("Expected the expired order off the shelf, got %+v", m.ShelvedOrders())
+	}
+	if s := m.Summary(); s.WastedOrders != 1 || s.ReadyOrders != 0 {
+		t.Errorf("Expected 1 wasted and no ready orders, got %+v", s)
+	}
+	if s := m.GetSummary(); !strings.Contains(s, "- Wasted Orders: 1") {
+		t.Errorf("Expected the printed summary to show the wasted order, got %s", s)
+	}
+}
+
+func waitForBotStatus(t *testing.T, m *SystemManager, id string, status bot.BotStatusEnum) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for m.BotPool.GetBot(id).Status() != status {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timeout waiting for bot %s to be %s, got %s", id, status, m.BotPool.GetBot(id).Status())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,142 @@
+package manager
+
+import (
+	"time"
+
+	"github.com/feedme/order-controller/internal/bot"
+	"github.com/feedme/order-controller/internal/event"
+	"github.com/feedme/order-controller/internal/order"
+	"github.com/feedme/order-controller/internal/utils"
+)
+
+// PreemptionPolicy controls whether urgent orders may interrupt cooking.
+// When enabled, an arriving order whose priority is at least Threshold takes
+// over the bot cooking the lowest-priority order if no bot is idle.
+type PreemptionPolicy struct {
+	Enabled bool
+	// Threshold is the minimum priority an order needs to preempt another.
+	Threshold int
+	// MinInterval is the minimum time between two preemptions, so a burst of
+	// urgent orders does not keep thrashing the kitchen.
+	MinInterval time.Duration
+}
+
+// DefaultPreemptionPolicy lets Urgent orders preempt at most once per second.
+var DefaultPreemptionPolicy = PreemptionPolicy{
+	Enabled:     true,
+	Threshold:   order.OrderPriorityUrgent,
+	MinInterval: time.Second,
+}
+
+// Preemption is the payload of an OrderPreempted event.
+type Preemption struct {
+	BotID string
+	// Displaced is the order that was interrupted and requeued.
+	Displaced order.OrderSnapshot
+	// PreemptedBy is the ID of the urgent order that caused the preemption.
+	PreemptedBy int
+	At          time.Time
+}
+
+// WithPreemption enables priority preemption with the given policy.
+func WithPreemption(p PreemptionPolicy) Option {
+	return func(m *SystemManager) {
+		m.preemption = p
+	}
+}
+
+// maybePreempt interrupts the lowest-priority order being cooked to make room
+// for urgent, if the policy allows it, urgent is still waiting in the queue and
+// no idle bot may take urgent. Only bots that may take urgent, given its
+// affinity and their dedication, are preempted. It returns true if a bot was
+// preempted.
+func (m *SystemManager) maybePreempt(urgent *order.Order) bool {
+	p := m.preemption
+	// The priority is read through a snapshot, since ModifyOrder may change it.
+	priority := urgent.Snapshot().Priority
+	if !p.Enabled || priority < p.Threshold {
+		return false
+	}
+
+	// The dispatcher may already have given urgent to a bot that was idle and
+	// now shows as PROCESSING. Holding dispatchMu keeps it from doing so while
+	// a victim is chosen.
+	m.dispatchMu.Lock()
+	defer m.dispatchMu.Unlock()
+	if urgent.Status() != order.OrderStatusPending {
+		return false
+	}
+	if _, queued := m.OrderQueue.Position(urgent.ID); !queued {
+		return false
+	}
+
+	now := m.clock.Now()
+	var victim *bot.BotSnapshot
+	var victimOrder order.OrderSnapshot
+	for _, b := range m.BotPool.Snapshots() {
+		live := m.BotPool.GetBot(b.ID)
+		if live == nil || !order.Eligible(urgent, live.Worker(), now) {
+			continue
+		}
+		if b.Status == bot.BotStatusIdle {
+			// Free capacity exists; the urgent order will be picked up normally.
+			return false
+		}
+		if b.Status != bot.BotStatusProcessing || b.CurrentOrderID == nil {
+			continue
+		}
+		ord := m.Orders.GetOrder(*b.CurrentOrderID)
+		if ord == nil {
+			continue
+		}
+		snap := ord.Snapshot()
+		if snap.Priority >= priority {
+			continue
+		}
+		// Prefer the lowest priority, then the order with the most work left.
+		if victim == nil || snap.Priority < victimOrder.Priority ||
+			(snap.Priority == victimOrder.Priority && b.TimeRemaining > victim.TimeRemaining) {
+			b := b
+			victim = &b
+			victimOrder = snap
+		}
+	}
+	if victim == nil {
+		return false
+	}
+
+	// The slot is claimed before preempting, so concurrent urgent orders
+	// cannot both pass the rate limit, and given back if the preemption fails.
+	m.mu.Lock()
+	last := m.lastPreemption
+	if !last.IsZero() && now.Sub(last) < p.MinInterval {
+		m.mu.Unlock()
+		utils.Log("Order •%d cannot preempt: rate limited", urgent.ID)
+		return false
+	}
+	m.lastPreemption = now
+	m.mu.Unlock()
+
+	b := m.BotPool.GetBot(victim.ID)
+	if b == nil || b.Preempt() != nil {
+		// The bot finished or changed status in the meantime.
+		m.mu.Lock()
+		if m.lastPreemption.Equal(now) {
+			m.lastPreemption = last
+		}
+		m.mu.Unlock()
+		return false
+	}
+
+	utils.Log("Order •%d preempted Order •%d on Bot #%s", urgent.ID, victimOrder.ID, victim.ID)
+	m.EventBus.Publish(event.Event{
+		Type: event.OrderPreempted,
+		Data: Preemption{
+			BotID:       victim.ID,
+			Displaced:   victimOrder,
+			PreemptedBy: urgent.ID,
+			At:          now,
+		},
+	})
+	return true
+}
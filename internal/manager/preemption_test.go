@@ -0,0 +1,181 @@
+package manager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/feedme/order-controller/internal/bot"
+	"github.com/feedme/order-controller/internal/order"
+)
+
+func waitForOrderStatus(t *testing.T, m *SystemManager, id int, status order.OrderStatusEnum) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for m.Orders.GetOrder(id).Status() != status {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timeout waiting for order %d to be %s, got %s", id, status, m.Orders.GetOrder(id).Status())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// preemptions returns the preemptions recorded by the harness so far.
+func (h *harness) preemptions() []Preemption {
+	var ps []Preemption
+	for _, ev := range h.recorded {
+		if p, ok := ev.Data.(Preemption); ok {
+			ps = append(ps, p)
+		}
+	}
+	return ps
+}
+
+func TestUrgentOrderPreemptsNormal(t *testing.T) {
+	h := newHarness(t, WithPreemption(PreemptionPolicy{Enabled: true, Threshold: order.OrderPriorityUrgent}))
+
+	h.do(func(m *SystemManager) { m.AddBot(bot.BotTypeFast) })
+	h.do(func(m *SystemManager) { m.AddOrder(order.OrderTypeNormal) }) // 1001
+	h.advance(time.Second)
+	h.do(func(m *SystemManager) { m.AddOrder(order.OrderTypeUrgent) }) // 1002
+
+	ps := h.preemptions()
+	if len(ps) != 1 || ps[0].Displaced.ID != 1001 || ps[0].PreemptedBy != 1002 {
+		t.Fatalf("Expected order 1001 preempted by 1002, got %+v", ps)
+	}
+
+	h.advance(5 * time.Second)
+	if got := h.m.Orders.GetOrder(1002).Status(); got != order.OrderStatusComplete {
+		t.Fatalf("Expected the urgent order complete, got %s", got)
+	}
+	if got := h.m.Orders.GetOrder(1001).Status(); got == order.OrderStatusComplete {
+		t.Error("Expected the displaced order to finish after the urgent one")
+	}
+	// The displaced order kept its first second of cooking.
+	h.advance(4 * time.Second)
+	if got := h.m.Orders.GetOrder(1001).Status(); got != order.OrderStatusComplete {
+		t.Errorf("Expected the displaced order complete, got %s", got)
+	}
+}
+
+func TestPreemptionSkippedWhenBotIdleOrDisabled(t *testing.T) {
+	h := newHarness(t)
+
+	h.do(func(m *SystemManager) { m.AddBot(bot.BotTypeFast) })
+	h.do(func(m *SystemManager) { m.AddOrder(order.OrderTypeNormal) })
+	h.advance(time.Second)
+
+	// Preemption is off by default
+	h.do(func(m *SystemManager) { m.AddOrder(order.OrderTypeUrgent) })
+	if got := h.m.Orders.GetOrder(1001).Status(); got != order.OrderStatusProcessing {
+		t.Errorf("Expected order 1001 to keep cooking, got %s", got)
+	}
+	if ps := h.preemptions(); len(ps) != 0 {
+		t.Errorf("Expected no preemption, got %+v", ps)
+	}
+
+	// An idle bot takes the urgent order without preempting anyone
+	h = newHarness(t, WithPreemption(PreemptionPolicy{Enabled: true, Threshold: order.OrderPriorityUrgent}))
+	h.do(func(m *SystemManager) { m.AddBot(bot.BotTypeFast) })
+	h.do(func(m *SystemManager) { m.AddBot(bot.BotTypeFast) })
+	h.do(func(m *SystemManager) { m.AddOrder(order.OrderTypeNormal) })
+	h.advance(time.Second)
+	h.do(func(m *SystemManager) { m.AddOrder(order.OrderTypeUrgent) })
+	if ps := h.preemptions(); len(ps) != 0 {
+		t.Errorf("Expected the idle bot to take the urgent order, got %+v", ps)
+	}
+	if got := h.m.Orders.GetOrder(1002).Status(); got != order.OrderStatusProcessing {
+		t.Errorf("Expected the urgent order cooking, got %s", got)
+	}
+}
+
+func TestPreemptionIsRateLimited(t *testing.T) {
+	h := newHarness(t, WithPreemption(PreemptionPolicy{Enabled: true, Threshold: order.OrderPriorityVIP, MinInterval: time.Hour}))
+
+	h.do(func(m *SystemManager) { m.AddBot(bot.BotTypeFast) })
+	h.do(func(m *SystemManager) { m.AddBot(bot.BotTypeFast) })
+	h.do(func(m *SystemManager) { m.AddOrder(order.OrderTypeNormal) }) // 1001
+	h.do(func(m *SystemManager) { m.AddOrder(order.OrderTypeNormal) }) // 1002
+	h.advance(time.Second)
+
+	h.do(func(m *SystemManager) { m.AddOrder(order.OrderTypeVIP) }) // 1003 preempts
+	h.do(func(m *SystemManager) { m.AddOrder(order.OrderTypeVIP) }) // 1004 is rate limited
+
+	if got := h.m.Orders.GetOrder(1003).Status(); got != order.OrderStatusProcessing {
+		t.Errorf("Expected order 1003 to preempt a bot, got %s", got)
+	}
+	if got := h.m.Orders.GetOrder(1004).Status(); got != order.OrderStatusPending {
+		t.Errorf("Expected rate-limited order 1004 to wait, got %s", got)
+	}
+	if ps := h.preemptions(); len(ps) != 1 {
+		t.Errorf("Expected a single preemption, got %+v", ps)
+	}
+}
+
+func TestPreemptionRespectsUrgentAffinity(t *testing.T) {
+	h := newHarness(t, WithPreemption(PreemptionPolicy{Enabled: true, Threshold: order.OrderPriorityUrgent}))
+
+	h.do(func(m *SystemManager) { m.AddBot(bot.BotTypeSlow) }) // B1
+	h.do(func(m *SystemManager) { m.AddBot(bot.BotTypeFast) }) // B2
+	h.do(func(m *SystemManager) { m.AddOrder(order.OrderTypeNormal) })
+	h.do(func(m *SystemManager) { m.AddOrder(order.OrderTypeNormal) })
+	h.advance(time.Second)
+
+	// B1 has the most work left, but the urgent order may only go to B2.
+	h.do(func(m *SystemManager) {
+		m.AddPinnedOrder(order.OrderTypeUrgent, order.Affinity{BotID: "B2", Hard: true})
+	})
+	ps := h.preemptions()
+	if len(ps) != 1 || ps[0].BotID != "B2" {
+		t.Fatalf("Expected B2 preempted for the pinned order, got %+v", ps)
+	}
+}
+
+func TestPreemptionIgnoresIdleBotsThatCannotTakeTheOrder(t *testing.T) {
+	h := newHarness(t, WithPreemption(PreemptionPolicy{Enabled: true, Threshold: order.OrderPriorityUrgent}))
+
+	h.do(func(m *SystemManager) { m.AddBot(bot.BotTypeFast) }) // B1
+	h.do(func(m *SystemManager) { m.AddBot(bot.BotTypeFast) }) // B2
+	h.do(func(m *SystemManager) {
+		if err := m.DedicateBot("B2", true); err != nil {
+			t.Fatal(err)
+		}
+	})
+	h.do(func(m *SystemManager) { m.AddOrder(order.OrderTypeNormal) })
+	h.advance(time.Second)
+
+	// B2 is idle but dedicated, so only preempting B1 makes room. B2 is
+	// released afterwards to pick up the displaced order.
+	h.do(func(m *SystemManager) {
+		m.AddOrder(order.OrderTypeUrgent)
+		if err := m.DedicateBot("B2", false); err != nil {
+			t.Fatal(err)
+		}
+	})
+	ps := h.preemptions()
+	if len(ps) != 1 || ps[0].BotID != "B1" || ps[0].Displaced.ID != 1001 {
+		t.Fatalf("Expected order 1001 preempted on B1, got %+v", ps)
+	}
+	if got := h.m.Orders.GetOrder(1002).Status(); got != order.OrderStatusProcessing {
+		t.Errorf("Expected the urgent order cooking, got %s", got)
+	}
+}
+
+func TestPreemptionSkippedOnceUrgentOrderIsTaken(t *testing.T) {
+	h := newHarness(t, WithPreemption(PreemptionPolicy{Enabled: true, Threshold: order.OrderPriorityUrgent}))
+
+	h.do(func(m *SystemManager) { m.AddBot(bot.BotTypeFast) })         // B1
+	h.do(func(m *SystemManager) { m.AddOrder(order.OrderTypeNormal) }) // 1001
+	h.do(func(m *SystemManager) { m.AddBot(bot.BotTypeFast) })         // B2
+	h.do(func(m *SystemManager) { m.AddOrder(order.OrderTypeUrgent) }) // 1002, taken by B2
+
+	// A late check, e.g. after the dispatcher won the race for the order,
+	// must not preempt B1 for an order that is already cooking.
+	var preempted bool
+	h.do(func(m *SystemManager) { preempted = m.maybePreempt(m.Orders.GetOrder(1002)) })
+	if preempted || len(h.preemptions()) != 0 {
+		t.Fatalf("Expected no preemption for a taken order, got %+v", h.preemptions())
+	}
+	if got := h.m.Orders.GetOrder(1001).Status(); got != order.OrderStatusProcessing {
+		t.Errorf("Expected order 1001 to keep cooking, got %s", got)
+	}
+}
@@ -0,0 +1,109 @@
+package manager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/feedme/order-controller/internal/bot"
+	"github.com/feedme/order-controller/internal/order"
+	"github.com/feedme/order-controller/internal/report"
+)
+
+// interruptAndResume cooks one order for 3s of a 5s FAST bot, removes the bot,
+// and returns how long a replacement bot needs to finish the order, along with
+// the progress the order kept.
+func interruptAndResume(t *testing.T, policy order.ProgressPolicy) (time.Duration, float64) {
+	t.Helper()
+	h := newHarness(t, WithProgressPolicy(policy))
+
+	h.do(func(m *SystemManager) { m.AddBot(bot.BotTypeFast) }) // B1
+	h.do(func(m *SystemManager) { m.AddOrder(order.OrderTypeNormal) })
+	h.advance(3 * time.Second)
+	h.do(func(m *SystemManager) { m.RemoveBot("B1") })
+
+	ord := h.m.Orders.GetOrder(order.DefaultFirstOrderID)
+	if ord.Status() != order.OrderStatusPending {
+		t.Fatalf("Expected the interrupted order back in the queue, got %s", ord.Status())
+	}
+	progress := ord.Progress()
+
+	resumed := h.clock.Now()
+	h.do(func(m *SystemManager) { m.AddBot(bot.BotTypeFast) })
+	h.advance(bot.ProcessingTimeMap[bot.BotTypeFast])
+	snap := ord.Snapshot()
+	if snap.Status != order.OrderStatusComplete {
+		t.Fatalf("Expected the resumed order to complete, got %s", snap.Status)
+	}
+	return snap.CompletedAt.Sub(resumed), progress
+}
+
+func TestRequeuedOrderKeepsProgress(t *testing.T) {
+	took, progress := interruptAndResume(t, order.KeepProgress)
+	if progress != 0.6 {
+		t.Errorf("Expected 60%% progress after interruption, got %.0f%%", progress*100)
+	}
+	if took != 2*time.Second {
+		t.Errorf("Expected resumed order to need only the remaining 2s, took %v", took)
+	}
+}
+
+func TestRequeuedOrderLosesProgress(t *testing.T) {
+	took, progress := interruptAndResume(t, order.LoseProgress)
+	if progress != 0 {
+		t.Errorf("Expected progress reset to 0, got %.2f", progress)
+	}
+	if took != 5*time.Second {
+		t.Errorf("Expected order to restart from zero and take 5s, took %v", took)
+	}
+}
+
+func TestTimeRemainingReflectsResumedProgress(t *testing.T) {
+	h := newHarness(t)
+
+	h.do(func(m *SystemManager) { m.AddBot(bot.BotTypeFast) }) // B1
+	h.do(func(m *SystemManager) { m.AddOrder(order.OrderTypeNormal) })
+	h.advance(2500 * time.Millisecond)
+	h.do(func(m *SystemManager) { m.RemoveBot("B1") })
+
+	// Half the work is done, so a SLOW bot needs half of its 10s
+	h.do(func(m *SystemManager) { m.AddBot(bot.BotTypeSlow) }) // B2
+	snap := h.m.BotPool.GetBot("B2").Snapshot()
+	if snap.Status != bot.BotStatusProcessing {
+		t.Fatalf("Expected SLOW bot to be processing, got %s", snap.Status)
+	}
+	if snap.TimeRemaining != 5*time.Second {
+		t.Errorf("Expected 5s remaining on the SLOW bot, got %v", snap.TimeRemaining)
+	}
+}
+
+func TestPausedTimeIsNotBusyTime(t *testing.T) {
+	h := newHarness(t)
+
+	h.do(func(m *SystemManager) { m.AddBot(bot.BotTypeFast) }) // B1
+	h.do(func(m *SystemManager) { m.AddOrder(order.OrderTypeNormal) })
+	h.advance(2 * time.Second)
+	h.do(func(m *SystemManager) {
+		if err := m.PauseBot("B1", true); err != nil {
+			t.Fatalf("PauseBot: %v", err)
+		}
+	})
+	h.advance(30 * time.Second)
+	h.do(func(m *SystemManager) {
+		if err := m.ResumeBot("B1"); err != nil {
+			t.Fatalf("ResumeBot: %v", err)
+		}
+	})
+	h.advance(3 * time.Second)
+
+	ord := h.m.Orders.GetOrder(order.DefaultFirstOrderID)
+	if ord.Status() != order.OrderStatusComplete {
+		t.Fatalf("Expected the resumed order to complete, got %s", ord.Status())
+	}
+	r := report.Build([]*order.Order{ord}, h.m.Orders.WorkerHistory(), 0)
+	if len(r.Bots) != 1 || r.Bots[0].Busy != 5*time.Second {
+		t.Errorf("Expected B1 busy for its 5s of cooking, got %+v", r.Bots)
+	}
+	if r.Cook.P50 != 5*time.Second {
+		t.Errorf("Expected the order to have cooked for 5s, got %v", r.Cook.P50)
+	}
+}
@@ -0,0 +1,197 @@
+package manager
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/feedme/order-controller/internal/bot"
+	"github.com/feedme/order-controller/internal/event"
+	"github.com/feedme/order-controller/internal/order"
+	"github.com/feedme/order-controller/internal/proptest"
+)
+
+// managerOp is one step of a randomized restaurant scenario. Arg selects the
+// bot for pause and resume.
+type managerOp struct {
+	Kind managerOpKind
+	Arg  byte
+}
+
+type managerOpKind byte
+
+const (
+	opAddNormal managerOpKind = iota
+	opAddVIP
+	opAddFastBot
+	opAddSlowBot
+	opRemoveBot
+	opPauseBot
+	opResumeBot
+	opWait
+	numManagerOps
+)
+
+func (op managerOp) String() string {
+	name := [...]string{"AddNormal", "AddVIP", "AddFastBot", "AddSlowBot", "RemoveBot", "PauseBot", "ResumeBot", "Wait"}[op.Kind%numManagerOps]
+	switch op.Kind {
+	case opPauseBot, opResumeBot:
+		return fmt.Sprintf("%s(%d)", name, op.Arg)
+	case opWait:
+		return fmt.Sprintf("%s(%ds)", name, op.Arg%8)
+	}
+	return name
+}
+
+func decodeManagerOps(data []byte) []managerOp {
+	ops := make([]managerOp, 0, len(data)/2)
+	for i := 0; i+1 < len(data); i += 2 {
+		ops = append(ops, managerOp{Kind: managerOpKind(data[i]) % numManagerOps, Arg: data[i+1]})
+	}
+	return ops
+}
+
+func randomManagerOps(rnd *rand.Rand) []managerOp {
+	ops := make([]managerOp, 5+rnd.Intn(20))
+	for i := range ops {
+		ops[i] = managerOp{Kind: managerOpKind(rnd.Intn(int(numManagerOps))), Arg: byte(rnd.Intn(256))}
+	}
+	return ops
+}
+
+// checkManagerOps runs ops against a SystemManager on a virtual clock, then
+// resumes every bot, adds one and lets the kitchen drain. It checks that:
+//   - every pickup takes the head of the queue: VIP before Normal, requeued
+//     orders first within their class, FIFO otherwise;
+//   - no order is picked up while not queued or completed twice;
+//   - every created order ends COMPLETE.
+func checkManagerOps(t *testing.T, ops []managerOp) error {
+	h := newHarness(t)
+	defer h.m.Stop()
+
+	for _, op := range ops {
+		switch op.Kind {
+		case opAddNormal:
+			h.do(func(m *SystemManager) { m.AddOrder(order.OrderTypeNormal) })
+		case opAddVIP:
+			h.do(func(m *SystemManager) { m.AddOrder(order.OrderTypeVIP) })
+		case opAddFastBot:
+			h.do(func(m *SystemManager) { m.AddBot(bot.BotTypeFast) })
+		case opAddSlowBot:
+			h.do(func(m *SystemManager) { m.AddBot(bot.BotTypeSlow) })
+		case opRemoveBot:
+			h.do(func(m *SystemManager) { m.RemoveBot("") })
+		case opPauseBot, opResumeBot:
+			bots := h.m.BotPool.Snapshots()
+			if len(bots) == 0 {
+				continue
+			}
+			id := bots[int(op.Arg)%len(bots)].ID
+			h.do(func(m *SystemManager) {
+				if op.Kind == opPauseBot {
+					_ = m.PauseBot(id, op.Arg%2 == 0)
+				} else {
+					_ = m.ResumeBot(id)
+				}
+			})
+		case opWait:
+			h.advance(time.Duration(op.Arg%8) * time.Second)
+		}
+	}
+
+	for _, b := range h.m.BotPool.Snapshots() {
+		h.do(func(m *SystemManager) { _ = m.ResumeBot(b.ID) })
+	}
+	h.do(func(m *SystemManager) { m.AddBot(bot.BotTypeFast) })
+	// Drain in short steps; every settle costs real time, so stop once done.
+	for i := 0; i < 120 && h.m.Orders.GetCompletedCount() < h.m.Orders.GetTotalCount(); i++ {
+		h.advance(5 * time.Second)
+	}
+
+	if err := checkTimeline(h.recorded); err != nil {
+		return err
+	}
+	for _, o := range h.m.Orders.Query(order.Query{}).Orders {
+		if o.Status != order.OrderStatusComplete {
+			return fmt.Errorf("order %d ended %s", o.ID, o.Status)
+		}
+	}
+	return nil
+}
+
+// checkTimeline replays the recorded events against a model of the queue.
+func checkTimeline(events []event.Event) error {
+	type waiting struct {
+		snap     order.OrderSnapshot
+		requeued bool
+	}
+	queue := make(map[int]waiting)
+	completed := make(map[int]bool)
+	ahead := func(a, b waiting) bool {
+		if a.snap.Priority != b.snap.Priority {
+			return a.snap.Priority > b.snap.Priority
+		}
+		if a.requeued != b.requeued {
+			return a.requeued
+		}
+		return a.snap.ID < b.snap.ID
+	}
+
+	for i, ev := range events {
+		snap, ok := ev.Data.(order.OrderSnapshot)
+		if !ok {
+			continue
+		}
+		switch ev.Type {
+		case event.OrderCreated:
+			queue[snap.ID] = waiting{snap: snap}
+		case event.OrderRequeued:
+			queue[snap.ID] = waiting{snap: snap, requeued: true}
+		case event.OrderAssigned:
+			picked, ok := queue[snap.ID]
+			if !ok {
+				return fmt.Errorf("event %d: order %d picked up while not queued", i, snap.ID)
+			}
+			for _, other := range queue {
+				if other.snap.ID != snap.ID && ahead(other, picked) {
+					return fmt.Errorf("event %d: order %d (%s) picked up ahead of order %d (%s)",
+						i, snap.ID, snap.Type, other.snap.ID, other.snap.Type)
+				}
+			}
+			delete(queue, snap.ID)
+		case event.OrderCompleted:
+			if completed[snap.ID] {
+				return fmt.Errorf("event %d: order %d completed twice", i, snap.ID)
+			}
+			completed[snap.ID] = true
+		}
+	}
+	return nil
+}
+
+func TestManagerProperties(t *testing.T) {
+	runs := 20
+	if testing.Short() {
+		runs = 3
+	}
+	proptest.Check(t, runs, randomManagerOps, func(ops []managerOp) error {
+		return checkManagerOps(t, ops)
+	})
+}
+
+func FuzzManager(f *testing.F) {
+	f.Add([]byte{byte(opAddFastBot), 0, byte(opAddNormal), 0, byte(opAddVIP), 0, byte(opWait), 6})
+	f.Add([]byte{byte(opAddNormal), 0, byte(opAddSlowBot), 0, byte(opWait), 3, byte(opRemoveBot), 0, byte(opAddVIP), 0})
+	f.Add([]byte{byte(opAddSlowBot), 0, byte(opAddNormal), 0, byte(opPauseBot), 0, byte(opAddVIP), 0, byte(opResumeBot), 0})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ops := decodeManagerOps(data)
+		if len(ops) > 40 {
+			t.Skip("sequence too long")
+		}
+		prop := func(ops []managerOp) error { return checkManagerOps(t, ops) }
+		if err := prop(ops); err != nil {
+			proptest.Fail(t, ops, prop, "fuzz input %v", data)
+		}
+	})
+}
@@ -0,0 +1,46 @@
+package manager
+
+import (
+	"github.com/feedme/order-controller/internal/order"
+	"github.com/feedme/order-controller/internal/utils"
+)
+
+// recoverOrders picks up the unfinished orders restored from the order
+// repository, e.g. after a restart with an order journal. Pre-orders go back
+// to the holding area and waiting orders back to the queue, in their original
+// place. Orders that were cooking start over in the queue, since no bot holds
+// them any more, and READY orders are expired, since the pickup shelf is not
+// kept across restarts.
+func (m *SystemManager) recoverOrders() {
+	for _, status := range []order.OrderStatusEnum{
+		order.OrderStatusScheduled,
+		order.OrderStatusPending,
+		order.OrderStatusProcessing,
+		order.OrderStatusReady,
+	} {
+		for _, snap := range m.Orders.Query(order.Query{Status: status}).Orders {
+			ord := m.Orders.GetOrder(snap.ID)
+			switch status {
+			case order.OrderStatusScheduled:
+				releaseAt := ord.NotBefore.Add(-m.leadTime())
+				if !releaseAt.After(m.clock.Now()) {
+					m.releaseScheduled(ord)
+					continue
+				}
+				m.Holding.Hold(ord, releaseAt)
+			case order.OrderStatusPending:
+				m.OrderQueue.Push(ord)
+			case order.OrderStatusProcessing:
+				if err := ord.Transition(order.OrderStatusPending, order.ActorSystem); err != nil {
+					utils.LogError("Cannot recover Order •%d: %v", ord.ID, err)
+					continue
+				}
+				m.OrderQueue.Push(ord)
+			case order.OrderStatusReady:
+				m.expireOrder(ord)
+				continue
+			}
+			utils.Log("Order •%d recovered - Status: %s", ord.ID, ord.Status())
+		}
+	}
+}
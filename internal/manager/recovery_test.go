@@ -0,0 +1,67 @@
+package manager
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/feedme/order-controller/internal/bot"
+	"github.com/feedme/order-controller/internal/clock"
+	"github.com/feedme/order-controller/internal/order"
+)
+
+func TestRestartRecoversUnfinishedOrders(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "orders.jsonl")
+	v := clock.NewVirtual(time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC))
+	times := WithProcessingTimes(map[bot.BotTypeEnum]time.Duration{bot.BotTypeFast: time.Minute})
+
+	repo, err := order.OpenFileRepository(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewSystemManager(WithClock(v), WithOrderRepository(repo), times)
+	cooking := m.Orders.AddOrder(m.OrderQueue, order.OrderTypeNormal)
+	waiting := m.Orders.AddOrder(m.OrderQueue, order.OrderTypeNormal)
+	pre, err := m.AddScheduledOrder(order.OrderTypeNormal, v.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.AddBot(bot.BotTypeFast)
+	waitForOrderStatus(t, m, cooking.ID, order.OrderStatusProcessing)
+	m.Stop()
+	if err := repo.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err = order.OpenFileRepository(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	m = NewSystemManager(WithClock(v), WithOrderRepository(repo), times)
+	defer m.Stop()
+
+	if got := m.Orders.GetOrder(cooking.ID).Status(); got != order.OrderStatusPending {
+		t.Errorf("Expected the interrupted order back to PENDING, got %s", got)
+	}
+	if pos, ok := m.OrderQueue.Position(cooking.ID); !ok || pos != 1 {
+		t.Errorf("Expected the interrupted order first in line, got %d (%v)", pos, ok)
+	}
+	if pos, ok := m.OrderQueue.Position(waiting.ID); !ok || pos != 2 {
+		t.Errorf("Expected the waiting order second in line, got %d (%v)", pos, ok)
+	}
+	if held := m.ScheduledOrders(); len(held) != 1 || held[0].Order.ID != pre.ID {
+		t.Errorf("Expected the pre-order held again, got %+v", held)
+	}
+
+	next := m.Orders.AddOrder(m.OrderQueue, order.OrderTypeVIP)
+	if next.ID != pre.ID+1 {
+		t.Errorf("Expected order %d after the restored ones, got %d", pre.ID+1, next.ID)
+	}
+	if err := m.CancelOrder(waiting.ID); err != nil {
+		t.Errorf("Expected a restored order to be cancellable, got %v", err)
+	}
+	if s := m.Summary(); s.TotalOrders != 4 {
+		t.Errorf("Expected 4 orders in total, got %d", s.TotalOrders)
+	}
+}
@@ -0,0 +1,96 @@
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/feedme/order-controller/internal/bot"
+	"github.com/feedme/order-controller/internal/event"
+	"github.com/feedme/order-controller/internal/order"
+	"github.com/feedme/order-controller/internal/utils"
+)
+
+// DefaultScheduleMargin is how long before its expected cook time a pre-order
+// is released into the queue, on top of the cook time itself.
+const DefaultScheduleMargin = time.Minute
+
+// ErrInvalidSchedule is returned by AddScheduledOrder when the requested time
+// is not in the future.
+var ErrInvalidSchedule = errors.New("scheduled time is not in the future")
+
+// WithScheduleMargin sets how much slack is added to the expected cook time
+// when deciding when to release a pre-order. The default is
+// DefaultScheduleMargin.
+func WithScheduleMargin(d time.Duration) Option {
+	return func(m *SystemManager) {
+		m.scheduleMargin = d
+	}
+}
+
+// AddScheduledOrder creates a pre-order to be ready at notBefore. The order is
+// SCHEDULED and waits in the holding area until notBefore minus its lead time,
+// then becomes PENDING and is queued like any other order. The lead time is
+// the expected cook time plus the schedule margin; if that moment has already
+// passed, the order is queued at once.
+func (m *SystemManager) AddScheduledOrder(orderType order.OrderTypeEnum, notBefore time.Time) (*order.Order, error) {
+	if err := (order.OrderRequest{Type: orderType}).Validate(); err != nil {
+		return nil, err
+	}
+	now := m.clock.Now()
+	if !notBefore.After(now) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSchedule, notBefore.Format(time.RFC3339))
+	}
+
+	ord := m.Orders.AddScheduledOrder(orderType, notBefore)
+	releaseAt := notBefore.Add(-m.leadTime())
+	if !releaseAt.After(now) {
+		m.releaseScheduled(ord)
+		return ord, nil
+	}
+	utils.Log("Order •%d held until %s (ready by %s)", ord.ID,
+		releaseAt.Format("15:04:05"), notBefore.Format("15:04:05"))
+	m.Holding.Hold(ord, releaseAt)
+	return ord, nil
+}
+
+// ScheduledOrders returns the pre-orders waiting in the holding area, soonest
+// release first.
+func (m *SystemManager) ScheduledOrders() []order.HeldOrder {
+	return m.Holding.List()
+}
+
+// leadTime is how long before its pickup time a pre-order is queued: the
+// longest processing time among the working bots, or that of a SLOW bot if
+// none is working, plus the schedule margin.
+func (m *SystemManager) leadTime() time.Duration {
+	var cook time.Duration
+	for _, b := range m.BotPool.Snapshots() {
+		if b.Status != bot.BotStatusIdle && b.Status != bot.BotStatusProcessing {
+			continue
+		}
+		cook = max(cook, b.ProcessingTime)
+	}
+	if cook == 0 {
+		cook = bot.ProcessingTimeMap[bot.BotTypeSlow]
+		if d, ok := m.processingTimes[bot.BotTypeSlow]; ok {
+			cook = d
+		}
+	}
+	return cook + m.scheduleMargin
+}
+
+// releaseScheduled moves a pre-order from the holding area into the queue.
+func (m *SystemManager) releaseScheduled(ord *order.Order) {
+	if err := ord.Transition(order.OrderStatusPending, order.ActorSystem); err != nil {
+		utils.LogError("Cannot release Order •%d: %v", ord.ID, err)
+		return
+	}
+	utils.Log("Order •%d released - Status: PENDING", ord.ID)
+	m.EventBus.Publish(event.Event{
+		Type: event.OrderReleased,
+		Data: ord.Snapshot(),
+	})
+	m.OrderQueue.Push(ord)
+	m.maybePreempt(ord)
+}
@@ -0,0 +1,113 @@
+package manager
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/feedme/order-controller/internal/bot"
+	"github.com/feedme/order-controller/internal/clock"
+	"github.com/feedme/order-controller/internal/event"
+	"github.com/feedme/order-controller/internal/order"
+)
+
+func TestScheduledOrderIsReleasedAtLeadTime(t *testing.T) {
+	start := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	v := clock.NewVirtual(start)
+	m := NewSystemManager(
+		WithClock(v),
+		WithScheduleMargin(time.Minute),
+		WithProcessingTimes(map[bot.BotTypeEnum]time.Duration{
+			bot.BotTypeFast: 2 * time.Minute,
+			bot.BotTypeSlow: 4 * time.Minute,
+		}),
+	)
+	defer m.Stop()
+	released := m.EventBus.Subscribe(event.OrderReleased)
+
+	// Without bots the lead time assumes a SLOW bot: 4m cook + 1m margin.
+	pickup := start.Add(30 * time.Minute)
+	ord, err := m.AddScheduledOrder(order.OrderTypeVIP, pickup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ord.Status() != order.OrderStatusScheduled || m.OrderQueue.Len() != 0 {
+		t.Fatalf("Expected a held SCHEDULED order, got %s with %d queued", ord.Status(), m.OrderQueue.Len())
+	}
+	held := m.ScheduledOrders()
+	if len(held) != 1 || held[0].Order.ID != ord.ID || !held[0].ReleaseAt.Equal(pickup.Add(-5*time.Minute)) {
+		t.Fatalf("Expected the order held until 12:25, got %+v", held)
+	}
+	if page := m.Orders.Query(order.Query{Status: order.OrderStatusScheduled}); len(page.Orders) != 1 {
+		t.Errorf("Expected the order to be found by status, got %d", len(page.Orders))
+	}
+	if eta, err := m.EstimateReady(ord.ID); err != nil || eta != 30*time.Minute {
+		t.Errorf("Expected ETA at pickup time, got %v, %v", eta, err)
+	}
+	if s := m.Summary(); s.ScheduledOrders != 1 || s.PendingOrders != 0 {
+		t.Errorf("Expected 1 scheduled and no pending orders, got %+v", s)
+	}
+	if s := m.GetSummary(); !strings.Contains(s, "- Scheduled Orders: 1") {
+		t.Errorf("Expected the printed summary to show the held order, got %s", s)
+	}
+
+	v.Advance(24 * time.Minute)
+	if ord.Status() != order.OrderStatusScheduled {
+		t.Fatalf("Expected order still held a minute before release, got %s", ord.Status())
+	}
+	v.Advance(time.Minute)
+	select {
+	case <-released:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the order to be released at 12:25")
+	}
+	if ord.Status() != order.OrderStatusPending || len(m.ScheduledOrders()) != 0 {
+		t.Errorf("Expected the order PENDING and no longer held, got %s", ord.Status())
+	}
+}
+
+func TestCancelScheduledOrder(t *testing.T) {
+	start := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	v := clock.NewVirtual(start)
+	m := NewSystemManager(WithClock(v))
+	defer m.Stop()
+
+	ord, err := m.AddScheduledOrder(order.OrderTypeNormal, start.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.CancelOrder(ord.ID); err != nil {
+		t.Fatalf("CancelOrder: %v", err)
+	}
+	if ord.Status() != order.OrderStatusCancelled || len(m.ScheduledOrders()) != 0 {
+		t.Fatalf("Expected the order cancelled and no longer held, got %s", ord.Status())
+	}
+	v.Advance(2 * time.Hour)
+	if m.OrderQueue.Len() != 0 {
+		t.Errorf("Expected a cancelled pre-order never to be queued, got %d queued", m.OrderQueue.Len())
+	}
+}
+
+func TestAddScheduledOrderValidates(t *testing.T) {
+	start := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	v := clock.NewVirtual(start)
+	m := NewSystemManager(WithClock(v))
+	defer m.Stop()
+
+	if _, err := m.AddScheduledOrder(order.OrderTypeNormal, start); !errors.Is(err, ErrInvalidSchedule) {
+		t.Errorf("Expected ErrInvalidSchedule for a time not in the future, got %v", err)
+	}
+	if _, err := m.AddScheduledOrder("Catering", start.Add(time.Hour)); !errors.Is(err, order.ErrUnknownOrderType) {
+		t.Errorf("Expected ErrUnknownOrderType, got %v", err)
+	}
+
+	// A pickup closer than the lead time is queued straight away.
+	ord, err := m.AddScheduledOrder(order.OrderTypeNormal, start.Add(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ord.Status() != order.OrderStatusPending || m.OrderQueue.Len() != 1 {
+		t.Errorf("Expected the order queued at once, got %s with %d queued", ord.Status(), m.OrderQueue.Len())
+	}
+}
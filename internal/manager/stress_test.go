@@ -0,0 +1,131 @@
+package manager
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/feedme/order-controller/internal/bot"
+	"github.com/feedme/order-controller/internal/order"
+)
+
+// TestConcurrentStress hammers the manager with concurrent order creation,
+// cancellation, bot churn and status reads. It is meant to be run with -race.
+func TestConcurrentStress(t *testing.T) {
+	m := NewSystemManager(WithProcessingTimes(map[bot.BotTypeEnum]time.Duration{
+		bot.BotTypeFast: time.Millisecond,
+		bot.BotTypeSlow: 2 * time.Millisecond,
+	}))
+
+	for i := 0; i < 4; i++ {
+		m.AddBot(bot.BotTypeFast)
+	}
+
+	const workers = 8
+	const opsPerWorker = 200
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			rnd := rand.New(rand.NewSource(seed))
+			for i := 0; i < opsPerWorker; i++ {
+				switch rnd.Intn(8) {
+				case 0, 1, 2:
+					m.AddOrder(order.OrderTypeNormal)
+				case 3:
+					m.AddOrder(order.OrderTypeVIP)
+				case 4:
+					m.AddBot(bot.BotTypeSlow)
+				case 5:
+					m.RemoveBot("")
+				case 6:
+					_ = m.CancelOrder(order.DefaultFirstOrderID + rnd.Intn(i+1))
+				case 7:
+					m.LogProcessingStatus()
+					_ = m.Summary()
+					m.BotPool.ForEach("", func(b *bot.Bot) { _ = b.Snapshot() })
+				}
+			}
+		}(int64(w))
+	}
+	wg.Wait()
+	m.Stop()
+
+	assertNoOrderLost(t, m)
+}
+
+// TestConcurrentAddRemoveBots adds and removes bots while orders complete,
+// checking that every requeued order is eventually finished.
+func TestConcurrentAddRemoveBots(t *testing.T) {
+	m := NewSystemManager(WithProcessingTimes(map[bot.BotTypeEnum]time.Duration{
+		bot.BotTypeFast: 2 * time.Millisecond,
+		bot.BotTypeSlow: 3 * time.Millisecond,
+	}))
+
+	const orders = 300
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < orders; i++ {
+			m.AddOrder(order.OrderTypeNormal)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			id, _ := m.AddBot(bot.BotTypeFast)
+			time.Sleep(time.Millisecond)
+			m.RemoveBot(id)
+		}
+	}()
+	wg.Wait()
+
+	// Leave a stable pool to drain the queue
+	m.AddBot(bot.BotTypeFast)
+	m.AddBot(bot.BotTypeFast)
+	deadline := time.Now().Add(5 * time.Second)
+	for m.Orders.GetCompletedCount() < orders && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	m.Stop()
+
+	if got := m.Orders.GetCompletedCount(); got != orders {
+		t.Errorf("Expected %d completed orders, got %d", orders, got)
+	}
+	assertNoOrderLost(t, m)
+}
+
+// assertNoOrderLost checks that, once all bots have stopped, every order is
+// either finished or waiting in the queue exactly once.
+func assertNoOrderLost(t *testing.T, m *SystemManager) {
+	t.Helper()
+
+	queued := make(map[int]int)
+	for o := m.OrderQueue.Pop(); o != nil; o = m.OrderQueue.Pop() {
+		queued[o.ID]++
+	}
+
+	total := m.Orders.GetTotalCount()
+	for id := order.DefaultFirstOrderID; id < order.DefaultFirstOrderID+total; id++ {
+		o := m.Orders.GetOrder(id)
+		if o == nil {
+			t.Errorf("Order %d missing from store", id)
+			continue
+		}
+		switch s := o.Status(); s {
+		case order.OrderStatusPending:
+			if queued[id] != 1 {
+				t.Errorf("Pending order %d queued %d times", id, queued[id])
+			}
+		case order.OrderStatusComplete, order.OrderStatusCancelled:
+			if queued[id] != 0 {
+				t.Errorf("%s order %d still queued", s, id)
+			}
+		default:
+			t.Errorf("Order %d left in status %s after shutdown", id, s)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package manager
+
+import (
+	"time"
+
+	"github.com/feedme/order-controller/internal/order"
+	"github.com/feedme/order-controller/internal/webhook"
+)
+
+// WithWebhooks delivers the restaurant's events to partner endpoints, which are
+// registered at runtime on SystemManager.Webhooks. Payloads name the store and
+// are stamped with the restaurant's clock. Without this option Webhooks is nil.
+func WithWebhooks(opts ...webhook.Option) Option {
+	return func(m *SystemManager) {
+		m.webhooks = true
+		m.webhookOpts = opts
+	}
+}
+
+// modificationData is a Modification as shared with webhook partners.
+type modificationData struct {
+	Order     webhook.OrderData   `json:"order"`
+	FromType  order.OrderTypeEnum `json:"from_type"`
+	FromItems []webhook.ItemData  `json:"from_items"`
+}
+
+// PartnerData returns the modification without the customer's contact details.
+func (mod Modification) PartnerData() interface{} {
+	return modificationData{
+		Order:     webhook.NewOrderData(mod.Order),
+		FromType:  mod.Diff.From.Type,
+		FromItems: webhook.NewItemData(mod.Diff.From.Items),
+	}
+}
+
+// preemptionData is a Preemption as shared with webhook partners.
+type preemptionData struct {
+	BotID       string            `json:"bot_id"`
+	Displaced   webhook.OrderData `json:"displaced"`
+	PreemptedBy int               `json:"preempted_by"`
+	At          time.Time         `json:"at"`
+}
+
+// PartnerData returns the preemption without the customer's contact details.
+func (p Preemption) PartnerData() interface{} {
+	return preemptionData{
+		BotID:       p.BotID,
+		Displaced:   webhook.NewOrderData(p.Displaced),
+		PreemptedBy: p.PreemptedBy,
+		At:          p.At,
+	}
+}
@@ -0,0 +1,47 @@
+package manager
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/feedme/order-controller/internal/event"
+	"github.com/feedme/order-controller/internal/order"
+	"github.com/feedme/order-controller/internal/webhook"
+)
+
+func TestWebhooksReceiveRestaurantEvents(t *testing.T) {
+	bodies := make(chan []byte, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies <- body
+	}))
+	defer srv.Close()
+
+	m := NewSystemManager(WithStoreID("KL01"), WithWebhooks(webhook.WithClient(srv.Client())))
+	defer m.Stop()
+	if _, err := m.Webhooks.Register(srv.URL, "s3cret", event.OrderCreated); err != nil {
+		t.Fatal(err)
+	}
+	m.AddOrder(order.OrderTypeVIP)
+
+	select {
+	case body := <-bodies:
+		var p struct {
+			Type    event.EventType
+			StoreID string `json:"store_id"`
+			Data    webhook.OrderData
+		}
+		if err := json.Unmarshal(body, &p); err != nil {
+			t.Fatal(err)
+		}
+		if p.Type != event.OrderCreated || p.StoreID != "KL01" || p.Data.Type != order.OrderTypeVIP {
+			t.Errorf("Unexpected payload: %+v", p)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for the webhook delivery")
+	}
+}
@@ -0,0 +1,195 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/feedme/order-controller/internal/order"
+)
+
+// Channel delivers notifications to customers by one means, e.g. SMS.
+type Channel interface {
+	// Name identifies the channel in logs, e.g. "sms".
+	Name() string
+	// Reaches reports whether the channel can deliver to the given contact.
+	Reaches(c order.Contact) bool
+	// Send delivers one message. It is called from several goroutines at once.
+	Send(ctx context.Context, msg Message) error
+}
+
+// ConsoleChannel writes every notification to w, e.g. the in-store pickup
+// screen. It reaches every customer.
+type ConsoleChannel struct {
+	w  io.Writer
+	mu sync.Mutex
+}
+
+// NewConsoleChannel returns a ConsoleChannel writing to w.
+func NewConsoleChannel(w io.Writer) *ConsoleChannel {
+	return &ConsoleChannel{w: w}
+}
+
+func (c *ConsoleChannel) Name() string { return "console" }
+
+func (c *ConsoleChannel) Reaches(order.Contact) bool { return true }
+
+func (c *ConsoleChannel) Send(_ context.Context, msg Message) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err := fmt.Fprintf(c.w, "[%s] %s\n", msg.Kind, msg.Text)
+	return err
+}
+
+// SMSGateway is the interface to an SMS provider.
+type SMSGateway interface {
+	SendSMS(ctx context.Context, phone, text string) error
+}
+
+// SMSChannel texts customers who left a phone number.
+type SMSChannel struct {
+	gateway SMSGateway
+}
+
+// NewSMSChannel returns an SMSChannel sending through gateway.
+func NewSMSChannel(gateway SMSGateway) *SMSChannel {
+	return &SMSChannel{gateway: gateway}
+}
+
+func (c *SMSChannel) Name() string { return "sms" }
+
+func (c *SMSChannel) Reaches(contact order.Contact) bool { return contact.Phone != "" }
+
+func (c *SMSChannel) Send(ctx context.Context, msg Message) error {
+	return c.gateway.SendSMS(ctx, msg.Order.Contact.Phone, msg.Text)
+}
+
+// WebhookChannel POSTs a JSON payload to the customer's webhook URL. Any
+// response other than 2xx counts as a failure.
+type WebhookChannel struct {
+	client *http.Client
+}
+
+// webhookPayload is the JSON body of a webhook notification.
+type webhookPayload struct {
+	Kind        Kind      `json:"kind"`
+	OrderID     int       `json:"order_id"`
+	OrderNumber string    `json:"order_number"`
+	Status      string    `json:"status"`
+	Text        string    `json:"text"`
+	At          time.Time `json:"at"`
+}
+
+// NewWebhookChannel returns a WebhookChannel using client, or, if client is
+// nil, a client with a 10 second timeout that only connects to public
+// addresses. Customers choose the URL, so the default client refuses to call
+// into the restaurant's own network even if a public name resolves there.
+func NewWebhookChannel(client *http.Client) *WebhookChannel {
+	if client == nil {
+		dialer := &net.Dialer{Timeout: 5 * time.Second, Control: publicOnly}
+		client = &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: &http.Transport{DialContext: dialer.DialContext},
+		}
+	}
+	return &WebhookChannel{client: client}
+}
+
+// publicOnly is a net.Dialer Control function that refuses connections to
+// addresses that are not public.
+func publicOnly(network, address string, _ syscall.RawConn) error {
+	addr, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if !order.IsPublicAddr(addr.Addr()) {
+		return fmt.Errorf("%w: %s", ErrNonPublicAddress, address)
+	}
+	return nil
+}
+
+func (c *WebhookChannel) Name() string { return "webhook" }
+
+func (c *WebhookChannel) Reaches(contact order.Contact) bool { return contact.WebhookURL != "" }
+
+func (c *WebhookChannel) Send(ctx context.Context, msg Message) error {
+	body, err := json.Marshal(webhookPayload{
+		Kind:        msg.Kind,
+		OrderID:     msg.Order.ID,
+		OrderNumber: msg.Order.Number,
+		Status:      string(msg.Order.Status),
+		Text:        msg.Text,
+		At:          msg.At,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, msg.Order.Contact.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook %s: %s", msg.Order.Contact.WebhookURL, resp.Status)
+	}
+	return nil
+}
+
+// ErrNonPublicAddress is returned when a customer webhook resolves to an
+// address that is not on the public internet.
+var ErrNonPublicAddress = errors.New("webhook address is not public")
+
+// ErrFakeFailure is returned by a FakeChannel told to fail.
+var ErrFakeFailure = errors.New("fake channel failure")
+
+// FakeChannel records the messages sent through it, for tests. It reaches
+// every customer and can be told to fail a number of sends first.
+type FakeChannel struct {
+	messages []Message
+	failures int
+	mu       sync.Mutex
+}
+
+func (c *FakeChannel) Name() string { return "fake" }
+
+func (c *FakeChannel) Reaches(order.Contact) bool { return true }
+
+func (c *FakeChannel) Send(_ context.Context, msg Message) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.failures > 0 {
+		c.failures--
+		return ErrFakeFailure
+	}
+	c.messages = append(c.messages, msg)
+	return nil
+}
+
+// FailNext makes the next n sends fail with ErrFakeFailure.
+func (c *FakeChannel) FailNext(n int) {
+	c.mu.Lock()
+	c.failures = n
+	c.mu.Unlock()
+}
+
+// Messages returns the messages delivered so far, in delivery order.
+func (c *FakeChannel) Messages() []Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Message(nil), c.messages...)
+}
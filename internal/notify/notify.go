@@ -0,0 +1,329 @@
+// Package notify tells customers about their orders. A Notifier watches a
+// restaurant's event bus and sends templated messages through pluggable
+// channels, such as SMS, a webhook or the in-store console, retrying failed
+// sends with exponential backoff.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/feedme/order-controller/internal/clock"
+	"github.com/feedme/order-controller/internal/event"
+	"github.com/feedme/order-controller/internal/order"
+	"github.com/feedme/order-controller/internal/utils"
+)
+
+// Kind is what a notification is about.
+type Kind string
+
+const (
+	// KindReady tells the customer their order can be collected: it has been
+	// cooked or, with WithPickupShelf, put on the pickup shelf.
+	KindReady Kind = "READY"
+	// KindDelayed tells the customer their order is taking longer than expected.
+	KindDelayed Kind = "DELAYED"
+)
+
+// DefaultTemplates are the message texts used unless overridden with
+// WithTemplate. They are executed with the Message being sent.
+var DefaultTemplates = map[Kind]*template.Template{
+	KindReady: template.Must(template.New(string(KindReady)).Parse(
+		"{{with .Order.Contact.Name}}Hi {{.}}, your{{else}}Your{{end}} order {{.Order.Number}} is ready for pickup.")),
+	KindDelayed: template.Must(template.New(string(KindDelayed)).Parse(
+		"{{with .Order.Contact.Name}}Hi {{.}}, your{{else}}Your{{end}} order {{.Order.Number}} is taking longer than expected. Sorry for the wait!")),
+}
+
+// Message is one notification about an order.
+type Message struct {
+	Kind Kind
+	// Order is the order as it was when the notification was triggered.
+	Order order.OrderSnapshot
+	// Text is the rendered template. It is empty while the template runs.
+	Text string
+	// At is when the notification was triggered.
+	At time.Time
+}
+
+// RetryPolicy controls how often a failed send is retried. The wait before
+// each retry doubles, starting at Backoff and capped at MaxBackoff.
+type RetryPolicy struct {
+	// Attempts is the total number of tries; values below one mean one.
+	Attempts   int
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy tries a send three times, waiting 1s and then 2s.
+var DefaultRetryPolicy = RetryPolicy{Attempts: 3, Backoff: time.Second, MaxBackoff: 30 * time.Second}
+
+// Delay returns how long to wait after the given failed attempt, counting from one.
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	d := p.Backoff
+	for i := 1; i < attempt && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	return d
+}
+
+// Stats counts the sends of a Notifier, one per message and channel.
+type Stats struct {
+	Sent int `json:"sent"`
+	// Retried counts failed attempts that were tried again.
+	Retried int `json:"retried"`
+	// Failed counts sends given up after the last attempt.
+	Failed int `json:"failed"`
+}
+
+// Notifier sends customer notifications for the orders of one restaurant.
+type Notifier struct {
+	bus       *event.EventBus
+	channels  []Channel
+	templates map[Kind]*template.Template
+	retry     RetryPolicy
+	// delayAfter is how long after being queued an unfinished order triggers
+	// a KindDelayed notification; zero disables delay notifications.
+	delayAfter time.Duration
+	// shelf is set if cooked orders go on a pickup shelf before they can be collected.
+	shelf bool
+	clock clock.Clock
+
+	events chan event.Event
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	// watches, stats and stopped are guarded by mu.
+	watches map[int]clock.Timer
+	stats   Stats
+	stopped bool
+	mu      sync.Mutex
+}
+
+// Option configures a Notifier at construction time.
+type Option func(*Notifier)
+
+// WithClock sets the clock used to time delays and retries. The default is clock.Real.
+func WithClock(c clock.Clock) Option {
+	return func(n *Notifier) {
+		n.clock = c
+	}
+}
+
+// WithRetryPolicy sets how failed sends are retried. The default is DefaultRetryPolicy.
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(n *Notifier) {
+		n.retry = p
+	}
+}
+
+// WithTemplate replaces the message text of one kind of notification.
+func WithTemplate(kind Kind, t *template.Template) Option {
+	return func(n *Notifier) {
+		n.templates[kind] = t
+	}
+}
+
+// WithDelayAfter sends a KindDelayed notification for every order not cooked
+// within d of being queued. By default no delay notifications are sent.
+func WithDelayAfter(d time.Duration) Option {
+	return func(n *Notifier) {
+		n.delayAfter = d
+	}
+}
+
+// WithPickupShelf sends KindReady notifications when an order is put on the
+// restaurant's pickup shelf rather than when it is cooked. A cooked order may
+// wait in a stalled bot for a slot, or be thrown away, before it is READY.
+func WithPickupShelf() Option {
+	return func(n *Notifier) {
+		n.shelf = true
+	}
+}
+
+// New returns a Notifier that sends through the given channels once started.
+func New(bus *event.EventBus, channels []Channel, opts ...Option) *Notifier {
+	n := &Notifier{
+		bus:       bus,
+		channels:  channels,
+		templates: make(map[Kind]*template.Template, len(DefaultTemplates)),
+		retry:     DefaultRetryPolicy,
+		clock:     clock.Real,
+		watches:   make(map[int]clock.Timer),
+	}
+	for k, t := range DefaultTemplates {
+		n.templates[k] = t
+	}
+	for _, opt := range opts {
+		opt(n)
+	}
+	n.ctx, n.cancel = context.WithCancel(context.Background())
+	return n
+}
+
+// Start subscribes to the event bus and begins sending notifications.
+func (n *Notifier) Start() {
+	n.events = n.bus.SubscribeAll(1024)
+	n.wg.Add(1)
+	go n.loop()
+}
+
+// Stop unsubscribes from the event bus, abandons pending retries and waits
+// for sends in progress to return.
+func (n *Notifier) Stop() {
+	n.mu.Lock()
+	if n.stopped {
+		n.mu.Unlock()
+		return
+	}
+	n.stopped = true
+	for id, t := range n.watches {
+		t.Stop()
+		delete(n.watches, id)
+	}
+	n.mu.Unlock()
+
+	if n.events != nil {
+		n.bus.UnsubscribeAll(n.events)
+	}
+	n.cancel()
+	n.wg.Wait()
+}
+
+// Stats returns the send counts so far.
+func (n *Notifier) Stats() Stats {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.stats
+}
+
+// loop turns order events into notifications until the subscription is closed.
+func (n *Notifier) loop() {
+	defer n.wg.Done()
+	for ev := range n.events {
+		snap, ok := ev.Data.(order.OrderSnapshot)
+		if !ok {
+			continue
+		}
+		switch ev.Type {
+		case event.OrderCreated:
+			// Pre-orders are watched from their release instead.
+			if snap.Status == order.OrderStatusPending {
+				n.watch(snap)
+			}
+		case event.OrderReleased:
+			n.watch(snap)
+		case event.OrderCompleted:
+			n.unwatch(snap.ID)
+			if !n.shelf {
+				n.notify(Message{Kind: KindReady, Order: snap, At: ev.At})
+			}
+		case event.OrderReady:
+			if n.shelf {
+				n.notify(Message{Kind: KindReady, Order: snap, At: ev.At})
+			}
+		case event.OrderCancelled:
+			n.unwatch(snap.ID)
+		}
+	}
+}
+
+// watch arms the delay notification of a newly queued order.
+func (n *Notifier) watch(snap order.OrderSnapshot) {
+	if n.delayAfter <= 0 {
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.stopped {
+		return
+	}
+	if t, ok := n.watches[snap.ID]; ok {
+		t.Stop()
+	}
+	n.watches[snap.ID] = n.clock.AfterFunc(n.delayAfter, func() {
+		n.mu.Lock()
+		delete(n.watches, snap.ID)
+		n.mu.Unlock()
+		n.notify(Message{Kind: KindDelayed, Order: snap, At: n.clock.Now()})
+	})
+}
+
+// unwatch cancels the delay notification of an order that is no longer waiting.
+func (n *Notifier) unwatch(id int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if t, ok := n.watches[id]; ok {
+		t.Stop()
+		delete(n.watches, id)
+	}
+}
+
+// notify renders msg and sends it through every channel that can reach the
+// customer, each in its own goroutine so a slow channel delays no other.
+func (n *Notifier) notify(msg Message) {
+	t, ok := n.templates[msg.Kind]
+	if !ok {
+		return
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, msg); err != nil {
+		utils.LogError("Cannot render %s notification for Order •%d: %v", msg.Kind, msg.Order.ID, err)
+		return
+	}
+	msg.Text = buf.String()
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.stopped {
+		return
+	}
+	for _, ch := range n.channels {
+		if !ch.Reaches(msg.Order.Contact) {
+			continue
+		}
+		n.wg.Add(1)
+		go n.send(ch, msg)
+	}
+}
+
+// send delivers msg through ch, retrying with backoff.
+func (n *Notifier) send(ch Channel, msg Message) {
+	defer n.wg.Done()
+	attempts := max(n.retry.Attempts, 1)
+	for attempt := 1; ; attempt++ {
+		err := ch.Send(n.ctx, msg)
+		if err == nil {
+			n.count(func(s *Stats) { s.Sent++ })
+			return
+		}
+		if attempt >= attempts || n.ctx.Err() != nil {
+			utils.LogError("Cannot send %s notification for Order •%d via %s after %d attempts: %v",
+				msg.Kind, msg.Order.ID, ch.Name(), attempt, err)
+			n.count(func(s *Stats) { s.Failed++ })
+			return
+		}
+		n.count(func(s *Stats) { s.Retried++ })
+
+		timer := n.clock.NewTimer(n.retry.Delay(attempt))
+		select {
+		case <-n.ctx.Done():
+			timer.Stop()
+			n.count(func(s *Stats) { s.Failed++ })
+			return
+		case <-timer.Chan():
+		}
+	}
+}
+
+func (n *Notifier) count(f func(*Stats)) {
+	n.mu.Lock()
+	f(&n.stats)
+	n.mu.Unlock()
+}
@@ -0,0 +1,204 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/feedme/order-controller/internal/clock"
+	"github.com/feedme/order-controller/internal/event"
+	"github.com/feedme/order-controller/internal/order"
+)
+
+var start = time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+
+func snapshot(id int, status order.OrderStatusEnum, contact order.Contact) order.OrderSnapshot {
+	return order.OrderSnapshot{ID: id, Number: "KL01-" + string(rune('0'+id)), Status: status, Contact: contact}
+}
+
+// waitFor polls cond until it holds or a second has passed.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timeout waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+type fakeGateway struct {
+	texts map[string]string
+	mu    sync.Mutex
+}
+
+func (g *fakeGateway) SendSMS(_ context.Context, phone, text string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.texts[phone] = text
+	return nil
+}
+
+func (g *fakeGateway) text(phone string) string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.texts[phone]
+}
+
+func TestNotifierSendsReadyThroughReachingChannels(t *testing.T) {
+	bus := event.NewEventBus()
+	fake := &FakeChannel{}
+	gw := &fakeGateway{texts: make(map[string]string)}
+	n := New(bus, []Channel{fake, NewSMSChannel(gw)})
+	n.Start()
+	defer n.Stop()
+
+	withPhone := snapshot(1, order.OrderStatusComplete, order.Contact{Name: "Aisyah", Phone: "+60123456789"})
+	bus.Publish(event.Event{Type: event.OrderCompleted, Data: withPhone})
+	bus.Publish(event.Event{Type: event.OrderCompleted, Data: snapshot(2, order.OrderStatusComplete, order.Contact{})})
+
+	waitFor(t, "two sends to the fake channel", func() bool { return len(fake.Messages()) == 2 })
+	waitFor(t, "the SMS", func() bool { return gw.text("+60123456789") != "" })
+	if got, want := gw.text("+60123456789"), "Hi Aisyah, your order KL01-1 is ready for pickup."; got != want {
+		t.Errorf("Expected SMS %q, got %q", want, got)
+	}
+	for _, got := range fake.Messages() {
+		if got.Order.ID == 2 && (got.Kind != KindReady || got.Text != "Your order KL01-2 is ready for pickup.") {
+			t.Errorf("Unexpected message for an order without contact: %+v", got)
+		}
+	}
+	waitFor(t, "three sends counted", func() bool { return n.Stats().Sent == 3 })
+}
+
+func TestNotifierWaitsForPickupShelf(t *testing.T) {
+	bus := event.NewEventBus()
+	fake := &FakeChannel{}
+	n := New(bus, []Channel{fake}, WithPickupShelf())
+	n.Start()
+
+	// Order 2 is cooked but thrown away before it reaches the shelf.
+	bus.Publish(event.Event{Type: event.OrderCompleted, Data: snapshot(2, order.OrderStatusComplete, order.Contact{})})
+	bus.Publish(event.Event{Type: event.OrderCompleted, Data: snapshot(1, order.OrderStatusComplete, order.Contact{})})
+	bus.Publish(event.Event{Type: event.OrderReady, Data: snapshot(1, order.OrderStatusReady, order.Contact{})})
+	waitFor(t, "the ready message", func() bool { return len(fake.Messages()) > 0 })
+	n.Stop()
+
+	msgs := fake.Messages()
+	if len(msgs) != 1 || msgs[0].Kind != KindReady || msgs[0].Order.ID != 1 || msgs[0].Order.Status != order.OrderStatusReady {
+		t.Errorf("Expected one ready message for the shelved order, got %+v", msgs)
+	}
+}
+
+func TestNotifierRetriesWithBackoff(t *testing.T) {
+	v := clock.NewVirtual(start)
+	bus := event.NewEventBus()
+	fake := &FakeChannel{}
+	fake.FailNext(2)
+	n := New(bus, []Channel{fake},
+		WithClock(v),
+		WithRetryPolicy(RetryPolicy{Attempts: 3, Backoff: time.Second, MaxBackoff: time.Minute}),
+	)
+	n.Start()
+	defer n.Stop()
+
+	bus.Publish(event.Event{Type: event.OrderCompleted, Data: snapshot(1, order.OrderStatusComplete, order.Contact{})})
+
+	// 1s after the first failure, then 2s after the second.
+	for _, backoff := range []time.Duration{time.Second, 2 * time.Second} {
+		waitFor(t, "a retry to be scheduled", func() bool { return v.PendingTimers() == 1 })
+		v.Advance(backoff - time.Millisecond)
+		if v.PendingTimers() != 1 {
+			t.Fatalf("Expected the retry to wait the full %v", backoff)
+		}
+		v.Advance(time.Millisecond)
+	}
+	waitFor(t, "the third attempt to succeed", func() bool { return len(fake.Messages()) == 1 })
+	if s := n.Stats(); s.Sent != 1 || s.Retried != 2 || s.Failed != 0 {
+		t.Errorf("Expected 1 sent after 2 retries, got %+v", s)
+	}
+
+	// Giving up after the last attempt counts as a failure.
+	fake.FailNext(3)
+	bus.Publish(event.Event{Type: event.OrderCompleted, Data: snapshot(2, order.OrderStatusComplete, order.Contact{})})
+	for i := 0; i < 2; i++ {
+		waitFor(t, "a retry to be scheduled", func() bool { return v.PendingTimers() == 1 })
+		v.Advance(time.Minute)
+	}
+	waitFor(t, "the send to fail", func() bool { return n.Stats().Failed == 1 })
+}
+
+func TestNotifierSendsDelayedForSlowOrders(t *testing.T) {
+	v := clock.NewVirtual(start)
+	bus := event.NewEventBus()
+	fake := &FakeChannel{}
+	delayed := template.Must(template.New("delayed").Parse("{{.Order.Number}} is late"))
+	n := New(bus, []Channel{fake}, WithClock(v), WithDelayAfter(5*time.Minute), WithTemplate(KindDelayed, delayed))
+	n.Start()
+	defer n.Stop()
+
+	bus.Publish(event.Event{Type: event.OrderCreated, Data: snapshot(1, order.OrderStatusPending, order.Contact{})})
+	bus.Publish(event.Event{Type: event.OrderCreated, Data: snapshot(2, order.OrderStatusPending, order.Contact{})})
+	// A pre-order is not late while it is held.
+	bus.Publish(event.Event{Type: event.OrderCreated, Data: snapshot(3, order.OrderStatusScheduled, order.Contact{})})
+	waitFor(t, "two orders to be watched", func() bool { return v.PendingTimers() == 0 && watching(n) == 2 })
+
+	bus.Publish(event.Event{Type: event.OrderCompleted, Data: snapshot(2, order.OrderStatusComplete, order.Contact{})})
+	waitFor(t, "the ready message", func() bool { return len(fake.Messages()) == 1 && watching(n) == 1 })
+
+	v.Advance(5 * time.Minute)
+	waitFor(t, "the delayed message", func() bool { return len(fake.Messages()) == 2 })
+	if got := fake.Messages()[1]; got.Kind != KindDelayed || got.Text != "KL01-1 is late" {
+		t.Errorf("Unexpected delayed message: %+v", got)
+	}
+}
+
+func watching(n *Notifier) int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return len(n.watches)
+}
+
+func TestWebhookChannel(t *testing.T) {
+	var got webhookPayload
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("Cannot decode payload: %v", err)
+		}
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	ch := NewWebhookChannel(srv.Client())
+	contact := order.Contact{WebhookURL: srv.URL}
+	if ch.Reaches(order.Contact{Phone: "+60123456789"}) || !ch.Reaches(contact) {
+		t.Fatal("Expected the webhook channel to reach only contacts with a webhook URL")
+	}
+	msg := Message{Kind: KindReady, Order: snapshot(1, order.OrderStatusComplete, contact), Text: "ready", At: start}
+	if err := ch.Send(context.Background(), msg); err != nil {
+		t.Fatal(err)
+	}
+	if got.OrderID != 1 || got.Kind != KindReady || got.Text != "ready" || got.Status != "COMPLETE" {
+		t.Errorf("Unexpected payload: %+v", got)
+	}
+
+	status = http.StatusServiceUnavailable
+	if err := ch.Send(context.Background(), msg); err == nil {
+		t.Error("Expected a 503 response to fail the send")
+	}
+	if err := (&FakeChannel{}).Send(context.Background(), msg); err != nil || errors.Is(err, ErrFakeFailure) {
+		t.Errorf("Expected a fake send to succeed, got %v", err)
+	}
+
+	// The default client only calls public addresses; the test server is on loopback.
+	if err := NewWebhookChannel(nil).Send(context.Background(), msg); !errors.Is(err, ErrNonPublicAddress) {
+		t.Errorf("Expected ErrNonPublicAddress for a loopback webhook, got %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package order
+
+import "time"
+
+// Affinity ties an order to a particular bot or bot type.
+//
+// A hard affinity pins the order: only matching bots may pick it up until
+// FallbackAfter has passed since the order was created (zero means never).
+// A soft affinity is only a hint: any bot may take the order, but a matching
+// bot prefers it over other orders of the same priority.
+type Affinity struct {
+	// BotID restricts the order to the bot with this ID. Empty means any bot.
+	BotID string
+	// BotType restricts the order to bots of this type (e.g. "FAST"). Empty means any type.
+	BotType string
+	// Hard makes the affinity a pin rather than a hint.
+	Hard bool
+	// FallbackAfter releases a hard pin to every bot once the order has waited this long.
+	FallbackAfter time.Duration
+}
+
+// IsZero reports whether the affinity expresses no preference at all.
+func (a Affinity) IsZero() bool {
+	return a.BotID == "" && a.BotType == ""
+}
+
+// Matches reports whether the worker satisfies the affinity.
+func (a Affinity) Matches(w Worker) bool {
+	if a.IsZero() {
+		return false
+	}
+	if a.BotID != "" && a.BotID != w.ID {
+		return false
+	}
+	if a.BotType != "" && a.BotType != w.Type {
+		return false
+	}
+	return true
+}
+
+// Worker identifies the bot asking the queue for an order.
+type Worker struct {
+	ID   string
+	Type string
+	// Dedicated workers only take orders whose affinity matches them.
+	Dedicated bool
+	// ProcessingTime is how long the worker takes per order; the FastestFirst
+	// idle policy wakes the quickest worker first.
+	ProcessingTime time.Duration
+}
+
+// Eligible reports whether w may take o at time now: dedicated workers only
+// take orders whose affinity matches them, and hard-pinned orders only go to
+// matching workers until their fallback time has passed.
+func Eligible(o *Order, w Worker, now time.Time) bool {
+	a := o.Affinity
+	if a.IsZero() {
+		return !w.Dedicated
+	}
+	if a.Matches(w) {
+		return true
+	}
+	if w.Dedicated {
+		return false
+	}
+	if !a.Hard {
+		return true
+	}
+	return a.FallbackAfter > 0 && now.Sub(o.CreatedAt) >= a.FallbackAfter
+}
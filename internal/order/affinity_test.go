@@ -0,0 +1,88 @@
+package order
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHardPinOnlyServesMatchingBot(t *testing.T) {
+	q := NewQueue()
+	now := time.Now()
+	q.Push(&Order{ID: 1, Priority: OrderPriorityNormal, CreatedAt: now, Affinity: Affinity{BotID: "777", Hard: true}})
+	q.Push(&Order{ID: 2, Priority: OrderPriorityNormal, CreatedAt: now.Add(time.Millisecond)})
+
+	other := Worker{ID: "111", Type: "SLOW"}
+	if got := q.PopFor(other); got == nil || got.ID != 2 {
+		t.Fatalf("Expected non-matching bot to skip pinned order 1 and get 2, got %v", got)
+	}
+	if got := q.PopFor(other); got != nil {
+		t.Fatalf("Expected nothing for non-matching bot, got %d", got.ID)
+	}
+	if got := q.PopFor(Worker{ID: "777", Type: "FAST"}); got == nil || got.ID != 1 {
+		t.Fatalf("Expected pinned bot to get order 1, got %v", got)
+	}
+}
+
+func TestHardPinFallsBackAfterTimeout(t *testing.T) {
+	ready := make(chan struct{}, 1)
+	q := NewQueue(WithReadyFunc(func() {
+		select {
+		case ready <- struct{}{}:
+		default:
+		}
+	}))
+	q.Push(&Order{
+		ID:        1,
+		Priority:  OrderPriorityNormal,
+		CreatedAt: time.Now(),
+		Affinity:  Affinity{BotType: "FAST", Hard: true, FallbackAfter: 30 * time.Millisecond},
+	})
+	// Drain the push signal
+	<-ready
+
+	slow := Worker{ID: "111", Type: "SLOW"}
+	if q.PopFor(slow) != nil {
+		t.Fatal("Expected SLOW bot not to get a FAST-pinned order before the fallback")
+	}
+
+	// The queue signals its owner again when the fallback time is reached
+	select {
+	case <-ready:
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for fallback signal")
+	}
+	if got := q.PopFor(slow); got == nil || got.ID != 1 {
+		t.Fatalf("Expected SLOW bot to get order 1 after fallback, got %v", got)
+	}
+}
+
+func TestSoftAffinityPreferredWithinPriority(t *testing.T) {
+	q := NewQueue()
+	now := time.Now()
+	q.Push(&Order{ID: 1, Priority: OrderPriorityNormal, CreatedAt: now})
+	q.Push(&Order{ID: 2, Priority: OrderPriorityNormal, CreatedAt: now.Add(time.Millisecond), Affinity: Affinity{BotType: "FAST"}})
+	q.Push(&Order{ID: 3, Priority: OrderPriorityVIP, CreatedAt: now.Add(2 * time.Millisecond)})
+
+	fast := Worker{ID: "222", Type: "FAST"}
+	// VIP still beats the hint; then the hinted Normal beats the older Normal
+	for _, expectedID := range []int{3, 2, 1} {
+		if got := q.PopFor(fast); got == nil || got.ID != expectedID {
+			t.Fatalf("Expected ID %d, got %v", expectedID, got)
+		}
+	}
+}
+
+func TestDedicatedWorkerOnlyTakesMatchingOrders(t *testing.T) {
+	q := NewQueue()
+	now := time.Now()
+	q.Push(&Order{ID: 1, Priority: OrderPriorityVIP, CreatedAt: now})
+	q.Push(&Order{ID: 2, Priority: OrderPriorityNormal, CreatedAt: now, Affinity: Affinity{BotID: "999"}})
+
+	driveThru := Worker{ID: "999", Type: "FAST", Dedicated: true}
+	if got := q.PopFor(driveThru); got == nil || got.ID != 2 {
+		t.Fatalf("Expected dedicated bot to get its order 2, got %v", got)
+	}
+	if got := q.PopFor(driveThru); got != nil {
+		t.Fatalf("Expected dedicated bot to ignore unpinned order, got %d", got.ID)
+	}
+}
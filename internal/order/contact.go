@@ -0,0 +1,71 @@
+package order
+
+import (
+	"fmt"
+	"net/netip"
+	"net/url"
+	"strings"
+)
+
+// sharedAddrSpace is the carrier-grade NAT range (RFC 6598), which
+// netip.Addr.IsPrivate does not cover.
+var sharedAddrSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// Contact is how a customer wants to hear about their order. Every field is
+// optional; an order without contact details is only announced in store.
+type Contact struct {
+	Name string
+	// Phone is an international number for SMS, e.g. "+60123456789".
+	Phone string
+	// WebhookURL receives a POST for every notification, e.g. from a
+	// delivery partner's system. It must name a public host.
+	WebhookURL string
+}
+
+// IsZero reports whether the contact has no way to reach the customer.
+func (c Contact) IsZero() bool {
+	return c.Phone == "" && c.WebhookURL == ""
+}
+
+// Validate reports whether the phone number and webhook URL are well formed.
+// A webhook URL naming localhost or a loopback, private or link-local address
+// is rejected, so an order cannot make the restaurant call into its own
+// network.
+func (c Contact) Validate() error {
+	if c.Phone != "" {
+		digits := strings.TrimPrefix(c.Phone, "+")
+		if len(digits) < 6 || strings.Trim(digits, "0123456789") != "" {
+			return fmt.Errorf("%w: phone %q", ErrInvalidContact, c.Phone)
+		}
+	}
+	if c.WebhookURL != "" {
+		u, err := url.Parse(c.WebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+			return fmt.Errorf("%w: webhook URL %q", ErrInvalidContact, c.WebhookURL)
+		}
+		if !isPublicHost(u.Hostname()) {
+			return fmt.Errorf("%w: webhook URL %q is not public", ErrInvalidContact, c.WebhookURL)
+		}
+	}
+	return nil
+}
+
+// IsPublicAddr reports whether ip is an address on the public internet, i.e.
+// not loopback, private, link-local, shared, multicast or unspecified.
+func IsPublicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsGlobalUnicast() && !ip.IsPrivate() && !sharedAddrSpace.Contains(ip)
+}
+
+// isPublicHost reports whether a URL host may be public: any name other than
+// localhost, or a public IP address. Names are checked again when dialled.
+func isPublicHost(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return false
+	}
+	if ip, err := netip.ParseAddr(host); err == nil {
+		return IsPublicAddr(ip)
+	}
+	return true
+}
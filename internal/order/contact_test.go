@@ -0,0 +1,41 @@
+package order
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestContactValidate(t *testing.T) {
+	valid := []Contact{
+		{},
+		{Name: "Aisyah", Phone: "+60123456789"},
+		{WebhookURL: "https://partner.example.com/hooks/orders"},
+		{WebhookURL: "https://203.0.113.7/hooks/orders"},
+	}
+	for _, c := range valid {
+		if err := c.Validate(); err != nil {
+			t.Errorf("Expected %+v to be valid, got %v", c, err)
+		}
+	}
+
+	invalid := []Contact{
+		{Phone: "call me"},
+		{Phone: "+12"},
+		{WebhookURL: "ftp://partner.example.com"},
+		{WebhookURL: "https://"},
+		{WebhookURL: "http://localhost:8080/hook"},
+		{WebhookURL: "http://127.0.0.1/hook"},
+		{WebhookURL: "http://10.0.0.5/hook"},
+		{WebhookURL: "http://169.254.169.254/latest/meta-data"},
+		{WebhookURL: "http://[::1]/hook"},
+		{WebhookURL: "http://[::ffff:192.168.1.1]/hook"},
+	}
+	for _, c := range invalid {
+		if err := c.Validate(); !errors.Is(err, ErrInvalidContact) {
+			t.Errorf("Expected ErrInvalidContact for %+v, got %v", c, err)
+		}
+	}
+	if err := (OrderRequest{Type: OrderTypeNormal, Contact: Contact{Phone: "x"}}).Validate(); !errors.Is(err, ErrInvalidContact) {
+		t.Errorf("Expected OrderRequest.Validate to check the contact, got %v", err)
+	}
+}
@@ -0,0 +1,300 @@
+package order
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// record operations written to a FileRepository's log.
+const (
+	opAdd        = "add"
+	opTransition = "transition"
+	opModify     = "modify"
+	opEvict      = "evict"
+	opWorker     = "worker"
+)
+
+// logRecord is one line of a FileRepository's append-only JSONL log.
+type logRecord struct {
+	Op         string            `json:"op"`
+	Order      *orderRecord      `json:"order,omitempty"`
+	Transition *StatusTransition `json:"transition,omitempty"`
+	Diff       *OrderDiff        `json:"diff,omitempty"`
+	Before     *time.Time        `json:"before,omitempty"`
+	Worker     *WorkerTransition `json:"worker,omitempty"`
+}
+
+// orderRecord holds the fields of an order as written to the log when it is
+// created; later changes to its type and items are logged as modify records.
+type orderRecord struct {
+	ID        int           `json:"id"`
+	Number    string        `json:"number"`
+	Type      OrderTypeEnum `json:"type"`
+	Priority  int           `json:"priority"`
+	CreatedAt time.Time     `json:"created_at"`
+	Affinity  Affinity      `json:"affinity"`
+	Items     []Item        `json:"items,omitempty"`
+	NotBefore *time.Time    `json:"not_before,omitempty"`
+	Contact   *Contact      `json:"contact,omitempty"`
+}
+
+// FileRepository is a Repository that keeps its indexes in memory and persists
+// every change to an append-only JSONL file. Opening an existing file replays
+// it, so order history survives a restart. A Store opened on the repository
+// counts the restored orders and numbers new ones after them, and a
+// SystemManager picks up the unfinished ones: SCHEDULED orders are held
+// again, PENDING and PROCESSING orders are queued in their original place,
+// and READY orders are expired, since the pickup shelf is not kept.
+type FileRepository struct {
+	mem  *MemoryRepository
+	file *os.File
+	enc  *json.Encoder
+	// err is the first write error, reported by Err and Close.
+	err error
+	mu  sync.Mutex
+}
+
+// OpenFileRepository opens (or creates) the log at path and replays it.
+func OpenFileRepository(path string) (*FileRepository, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open order repository: %w", err)
+	}
+
+	r := &FileRepository{mem: NewMemoryRepository(), file: f, enc: json.NewEncoder(f)}
+	if err := replay(f, r.mem); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("replay order repository %s: %w", path, err)
+	}
+	return r, nil
+}
+
+// ReplayFile reads the log at path, e.g. for a report, and returns the orders
+// it holds in a MemoryRepository. The file is opened read-only, so it is never
+// created or changed.
+func ReplayFile(path string) (*MemoryRepository, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open order repository: %w", err)
+	}
+	defer f.Close()
+
+	mem := NewMemoryRepository()
+	if err := replay(f, mem); err != nil {
+		return nil, fmt.Errorf("replay order repository %s: %w", path, err)
+	}
+	return mem, nil
+}
+
+// replay rebuilds the in-memory state in mem from the log read from rd.
+func replay(rd io.Reader, mem *MemoryRepository) error {
+	scanner := bufio.NewScanner(rd)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var rec logRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		switch rec.Op {
+		case opAdd:
+			if rec.Order == nil {
+				return fmt.Errorf("line %d: add without order", line)
+			}
+			if err := mem.Add(rec.Order.restore()); err != nil {
+				return fmt.Errorf("line %d: %w", line, err)
+			}
+		case opTransition:
+			if rec.Transition == nil {
+				return fmt.Errorf("line %d: transition without payload", line)
+			}
+			if o := mem.Get(rec.Transition.OrderID); o != nil {
+				o.restoreTransition(*rec.Transition)
+				mem.Record(*rec.Transition)
+			}
+		case opModify:
+			if rec.Diff == nil {
+				return fmt.Errorf("line %d: modify without diff", line)
+			}
+			if o := mem.Get(rec.Diff.OrderID); o != nil {
+				o.restoreContents(rec.Diff.To)
+				mem.Modify(*rec.Diff)
+			}
+		case opEvict:
+			if rec.Before != nil {
+				mem.Evict(*rec.Before)
+			}
+		case opWorker:
+			if rec.Worker == nil {
+				return fmt.Errorf("line %d: worker without transition", line)
+			}
+			mem.RecordWorker(*rec.Worker)
+		default:
+			return fmt.Errorf("line %d: unknown op %q", line, rec.Op)
+		}
+	}
+	return scanner.Err()
+}
+
+// Add stores a newly created order and appends it to the log.
+func (r *FileRepository) Add(o *Order) error {
+	if err := r.mem.Add(o); err != nil {
+		return err
+	}
+	rec := &orderRecord{
+		ID:        o.ID,
+		Number:    o.Number,
+		Type:      o.Type,
+		Priority:  o.Priority,
+		CreatedAt: o.CreatedAt,
+		Affinity:  o.Affinity,
+		Items:     o.Items(),
+	}
+	if !o.NotBefore.IsZero() {
+		notBefore := o.NotBefore
+		rec.NotBefore = &notBefore
+	}
+	if o.Contact != (Contact{}) {
+		contact := o.Contact
+		rec.Contact = &contact
+	}
+	return r.write(logRecord{Op: opAdd, Order: rec})
+}
+
+// Get returns the order with the given ID, or nil.
+func (r *FileRepository) Get(id int) *Order {
+	return r.mem.Get(id)
+}
+
+// Record updates the indexes and appends the transition to the log. Write
+// errors are kept and reported by Err.
+func (r *FileRepository) Record(t StatusTransition) {
+	r.mem.Record(t)
+	_ = r.write(logRecord{Op: opTransition, Transition: &t})
+}
+
+// Modify updates the indexes and appends the change to the log. Write errors
+// are kept and reported by Err.
+func (r *FileRepository) Modify(d OrderDiff) {
+	r.mem.Modify(d)
+	_ = r.write(logRecord{Op: opModify, Diff: &d})
+}
+
+// RecordWorker stores a worker status change and appends it to the log. Write
+// errors are kept and reported by Err.
+func (r *FileRepository) RecordWorker(t WorkerTransition) {
+	r.mem.RecordWorker(t)
+	_ = r.write(logRecord{Op: opWorker, Worker: &t})
+}
+
+// WorkerHistory returns every stored worker status change, oldest first.
+func (r *FileRepository) WorkerHistory() []WorkerTransition {
+	return r.mem.WorkerHistory()
+}
+
+// Query returns the orders matching q, oldest first.
+func (r *FileRepository) Query(q Query) Page {
+	return r.mem.Query(q)
+}
+
+// Count returns how many orders match q.
+func (r *FileRepository) Count(q Query) int {
+	return r.mem.Count(q)
+}
+
+// Evict removes finished orders that finished before the given time, along
+// with worker status changes made before it, and logs the eviction so it is
+// repeated on replay.
+func (r *FileRepository) Evict(before time.Time) int {
+	n, workers := r.mem.evict(before)
+	if n > 0 || workers > 0 {
+		_ = r.write(logRecord{Op: opEvict, Before: &before})
+	}
+	return n
+}
+
+// Err returns the first error encountered while writing the log.
+func (r *FileRepository) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Close closes the log file. It returns the first write error, if any.
+func (r *FileRepository) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.file.Close(); err != nil && r.err == nil {
+		r.err = err
+	}
+	return r.err
+}
+
+// write appends rec to the log, remembering the first failure.
+func (r *FileRepository) write(rec logRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.enc.Encode(rec); err != nil {
+		if r.err == nil {
+			r.err = err
+		}
+		return err
+	}
+	return nil
+}
+
+// restore rebuilds an order from its log record.
+func (rec *orderRecord) restore() *Order {
+	o := &Order{
+		ID:        rec.ID,
+		Number:    rec.Number,
+		Type:      rec.Type,
+		Priority:  rec.Priority,
+		CreatedAt: rec.CreatedAt,
+		Affinity:  rec.Affinity,
+		items:     rec.Items,
+		status:    OrderStatusPending,
+		history: []StatusTransition{{
+			OrderID: rec.ID,
+			To:      OrderStatusPending,
+			Actor:   ActorUser,
+			At:      rec.CreatedAt,
+		}},
+	}
+	if rec.NotBefore != nil {
+		o.scheduleFor(*rec.NotBefore)
+	}
+	if rec.Contact != nil {
+		o.Contact = *rec.Contact
+	}
+	return o
+}
+
+// restoreTransition applies a transition read from the log without validating
+// it, since it was validated when it was first recorded.
+func (o *Order) restoreTransition(t StatusTransition) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	switch t.To {
+	case OrderStatusProcessing:
+		o.processedAt = t.At
+	case OrderStatusComplete:
+		o.completedAt = t.At
+		o.progress = 1
+	}
+	o.status = t.To
+	o.history = append(o.history, t)
+}
+
+// restoreContents applies a modification read from the log.
+func (o *Order) restoreContents(c OrderContents) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.Type = c.Type
+	o.Priority = c.Priority
+	o.items = c.Items
+}
@@ -0,0 +1,121 @@
+package order
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/feedme/order-controller/internal/clock"
+)
+
+// Holding keeps pre-orders out of the queue until their release time. Each
+// held order has its own timer; when it fires, the order leaves the holding
+// area and is passed to the release function.
+type Holding struct {
+	held    map[int]*heldOrder
+	release func(*Order)
+	clock   clock.Clock
+	mu      sync.Mutex
+}
+
+type heldOrder struct {
+	order     *Order
+	releaseAt time.Time
+	timer     clock.Timer
+}
+
+// HeldOrder describes an order waiting in the holding area.
+type HeldOrder struct {
+	Order     OrderSnapshot
+	ReleaseAt time.Time
+}
+
+// NewHolding returns an empty holding area that calls release, on a timer
+// goroutine, for every order once its release time has come.
+func NewHolding(c clock.Clock, release func(*Order)) *Holding {
+	return &Holding{
+		held:    make(map[int]*heldOrder),
+		release: release,
+		clock:   c,
+	}
+}
+
+// Hold keeps o until releaseAt. A release time that has already passed
+// releases the order on the next timer tick.
+func (h *Holding) Hold(o *Order, releaseAt time.Time) {
+	ho := &heldOrder{order: o, releaseAt: releaseAt}
+	h.mu.Lock()
+	h.held[o.ID] = ho
+	h.mu.Unlock()
+
+	// The timer is armed outside the lock in case it fires at once.
+	t := h.clock.AfterFunc(releaseAt.Sub(h.clock.Now()), func() { h.fire(o.ID) })
+	h.mu.Lock()
+	ho.timer = t
+	h.mu.Unlock()
+}
+
+// fire releases the order with the given ID if it is still held.
+func (h *Holding) fire(id int) {
+	h.mu.Lock()
+	ho, ok := h.held[id]
+	delete(h.held, id)
+	h.mu.Unlock()
+
+	if ok {
+		h.release(ho.order)
+	}
+}
+
+// Remove takes the order with the given ID out of the holding area without
+// releasing it, e.g. to cancel it. Returns nil if the order is not held.
+func (h *Holding) Remove(id int) *Order {
+	h.mu.Lock()
+	ho, ok := h.held[id]
+	delete(h.held, id)
+	h.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+	if ho.timer != nil {
+		ho.timer.Stop()
+	}
+	return ho.order
+}
+
+// List returns every held order, soonest release first.
+func (h *Holding) List() []HeldOrder {
+	h.mu.Lock()
+	list := make([]HeldOrder, 0, len(h.held))
+	for _, ho := range h.held {
+		list = append(list, HeldOrder{Order: ho.order.Snapshot(), ReleaseAt: ho.releaseAt})
+	}
+	h.mu.Unlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].ReleaseAt.Equal(list[j].ReleaseAt) {
+			return list[i].ReleaseAt.Before(list[j].ReleaseAt)
+		}
+		return list[i].Order.ID < list[j].Order.ID
+	})
+	return list
+}
+
+// Len returns the number of held orders.
+func (h *Holding) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.held)
+}
+
+// Stop cancels every release timer. Held orders stay in the holding area.
+func (h *Holding) Stop() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, ho := range h.held {
+		if ho.timer != nil {
+			ho.timer.Stop()
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package order
+
+import (
+	"testing"
+	"time"
+
+	"github.com/feedme/order-controller/internal/clock"
+)
+
+func TestHoldingReleasesWhenDue(t *testing.T) {
+	start := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	v := clock.NewVirtual(start)
+	released := make(chan *Order, 2)
+	h := NewHolding(v, func(o *Order) { released <- o })
+
+	late := newOrderFor(2, OrderPriorityNormal, Affinity{})
+	early := newOrderFor(1, OrderPriorityNormal, Affinity{})
+	h.Hold(late, start.Add(20*time.Minute))
+	h.Hold(early, start.Add(10*time.Minute))
+
+	list := h.List()
+	if len(list) != 2 || list[0].Order.ID != 1 || list[1].Order.ID != 2 {
+		t.Fatalf("Expected orders listed by release time, got %+v", list)
+	}
+
+	v.Advance(10 * time.Minute)
+	select {
+	case o := <-released:
+		if o != early {
+			t.Fatalf("Expected order 1 released first, got %d", o.ID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected order 1 to be released at its release time")
+	}
+	if h.Len() != 1 {
+		t.Errorf("Expected 1 order still held, got %d", h.Len())
+	}
+}
+
+func TestHoldingRemoveCancelsRelease(t *testing.T) {
+	start := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	v := clock.NewVirtual(start)
+	released := make(chan *Order, 1)
+	h := NewHolding(v, func(o *Order) { released <- o })
+
+	o := newOrderFor(1, OrderPriorityNormal, Affinity{})
+	h.Hold(o, start.Add(time.Minute))
+	if got := h.Remove(1); got != o {
+		t.Fatalf("Expected Remove to return the held order, got %v", got)
+	}
+	if got := h.Remove(1); got != nil {
+		t.Errorf("Expected nil for an order no longer held, got %v", got)
+	}
+
+	v.Advance(time.Hour)
+	select {
+	case o := <-released:
+		t.Fatalf("Expected removed order not to be released, got %d", o.ID)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,215 @@
+package order
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ErrUnknownIdlePolicy is returned by ParseIdlePolicy for an unrecognised name.
+var ErrUnknownIdlePolicy = errors.New("unknown idle policy")
+
+// IdlePolicy decides which idle bot gets an order when several are equally
+// suited to it.
+type IdlePolicy int
+
+const (
+	// FastestFirst picks the idle bot with the shortest processing time. Ties
+	// go to the bot that has gone longest without an order.
+	FastestFirst IdlePolicy = iota
+	// LeastRecentlyUsed picks the bot that has gone longest without an order.
+	LeastRecentlyUsed
+	// RoundRobin picks idle bots in turn, cycling through their IDs.
+	RoundRobin
+)
+
+var idlePolicyNames = map[IdlePolicy]string{
+	FastestFirst:      "fastest",
+	LeastRecentlyUsed: "lru",
+	RoundRobin:        "round-robin",
+}
+
+func (p IdlePolicy) String() string {
+	if name, ok := idlePolicyNames[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("IdlePolicy(%d)", int(p))
+}
+
+// ParseIdlePolicy returns the policy with the given name: fastest, lru or
+// round-robin.
+func ParseIdlePolicy(name string) (IdlePolicy, error) {
+	for p, n := range idlePolicyNames {
+		if n == name {
+			return p, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %q", ErrUnknownIdlePolicy, name)
+}
+
+// WithIdlePolicy sets how Match picks between idle workers of equal cost. The
+// default is FastestFirst.
+func WithIdlePolicy(p IdlePolicy) QueueOption {
+	return func(q *Queue) {
+		q.policy = p
+	}
+}
+
+// WithReadyFunc sets a function the queue calls whenever Match may have work
+// to do: an order was queued, a worker became idle, the queue was unpaused or
+// a hard pin's fallback expired. It is called without the queue lock held and
+// must not block.
+func WithReadyFunc(fn func()) QueueOption {
+	return func(q *Queue) {
+		q.ready = fn
+	}
+}
+
+// CostFunc scores how well worker w suits order o; Match gives each order to
+// the eligible idle worker with the lowest cost. It is called with the queue
+// locked, so it must not call back into the queue.
+type CostFunc func(o *Order, w Worker) float64
+
+// Assignment is an order that Match removed from the queue for a worker.
+type Assignment struct {
+	Order  *Order
+	Worker Worker
+}
+
+// Idle adds w to the queue's idle set, replacing any earlier entry for the
+// same worker ID, so that Match may hand it an order.
+func (q *Queue) Idle(w Worker) {
+	q.mu.Lock()
+	q.idle[w.ID] = w
+	ready := !q.paused && q.pq.Len() > 0
+	q.mu.Unlock()
+
+	if ready {
+		q.signal()
+	}
+}
+
+// Leave removes the worker with the given ID from the idle set, e.g. because
+// its bot was paused. The worker keeps its place in the LeastRecentlyUsed
+// order for when it comes back.
+func (q *Queue) Leave(id string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.idle, id)
+}
+
+// Forget removes the worker with the given ID from the idle set and drops its
+// usage history, because its bot was removed for good.
+func (q *Queue) Forget(id string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.idle, id)
+	delete(q.lastUsed, id)
+}
+
+// Match pairs queued orders with idle workers and removes both from the queue.
+// Orders are considered in dispatch order; each goes to the eligible idle
+// worker with the lowest cost, ties being broken by the queue's IdlePolicy.
+// The chosen worker then takes the order it prefers among those of the same
+// priority, as with PopFor, so soft affinities are honoured. Nothing is
+// matched while the queue is paused.
+func (q *Queue) Match(cost CostFunc) []Assignment {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.paused || len(q.idle) == 0 || q.pq.Len() == 0 {
+		return nil
+	}
+
+	now := q.clock.Now()
+	var matched []Assignment
+	assign := func(o *Order, w Worker) {
+		delete(q.idle, w.ID)
+		q.uses++
+		q.lastUsed[w.ID] = q.uses
+		q.lastWoken = w.ID
+		matched = append(matched, Assignment{Order: o, Worker: w})
+	}
+
+	if q.affine == 0 {
+		// Every queued order suits the same workers, so the heap top stands in for all.
+		for q.pq.Len() > 0 {
+			w, ok := q.cheapestLocked(q.pq[0], cost, now)
+			if !ok {
+				break
+			}
+			assign(q.removeLocked(0), w)
+		}
+		return matched
+	}
+
+	sorted := make(PriorityQueue, len(q.pq))
+	copy(sorted, q.pq)
+	sort.Sort(sorted)
+	taken := make(map[*Order]bool)
+	for i := 0; i < len(sorted) && len(q.idle) > 0; {
+		o := sorted[i]
+		if taken[o] {
+			i++
+			continue
+		}
+		w, ok := q.cheapestLocked(o, cost, now)
+		if !ok {
+			i++
+			continue
+		}
+		// w may prefer an order of the same priority hinted to it; o is then
+		// considered again for the remaining workers.
+		got := q.popForLocked(w)
+		taken[got] = true
+		assign(got, w)
+	}
+	return matched
+}
+
+// cheapestLocked returns the idle worker with the lowest cost for o among
+// those that may take it. The caller must hold q.mu.
+func (q *Queue) cheapestLocked(o *Order, cost CostFunc, now time.Time) (Worker, bool) {
+	var best Worker
+	var bestCost float64
+	found := false
+	for _, w := range q.idle {
+		if !Eligible(o, w, now) {
+			continue
+		}
+		c := cost(o, w)
+		if !found || c < bestCost || (c == bestCost && q.preferWorker(w, best)) {
+			best, bestCost, found = w, c, true
+		}
+	}
+	return best, found
+}
+
+// preferWorker reports whether the policy picks a before b. Every policy ends
+// in a comparison of IDs, so the choice does not depend on map order.
+func (q *Queue) preferWorker(a, b Worker) bool {
+	switch q.policy {
+	case FastestFirst:
+		if a.ProcessingTime != b.ProcessingTime {
+			return a.ProcessingTime < b.ProcessingTime
+		}
+		fallthrough
+	case LeastRecentlyUsed:
+		if au, bu := q.lastUsed[a.ID], q.lastUsed[b.ID]; au != bu {
+			return au < bu
+		}
+	case RoundRobin:
+		// IDs after the last picked worker come first, then the wrap-around.
+		if aAfter, bAfter := a.ID > q.lastWoken, b.ID > q.lastWoken; aAfter != bAfter {
+			return aAfter
+		}
+	}
+	return a.ID < b.ID
+}
+
+// signal tells the queue's owner that Match may have work to do.
+func (q *Queue) signal() {
+	if q.ready != nil {
+		q.ready()
+	}
+}
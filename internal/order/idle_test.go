@@ -0,0 +1,156 @@
+package order
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// speedCost prefers faster workers, like the manager's default cost.
+func speedCost(o *Order, w Worker) float64 {
+	return w.ProcessingTime.Seconds()
+}
+
+func newOrderFor(id, priority int, affinity Affinity) *Order {
+	return &Order{ID: id, Priority: priority, CreatedAt: time.Unix(int64(id), 0), Affinity: affinity}
+}
+
+// matched returns the order ID given to each worker ID.
+func matched(as []Assignment) map[string]int {
+	m := make(map[string]int)
+	for _, a := range as {
+		m[a.Worker.ID] = a.Order.ID
+	}
+	return m
+}
+
+func TestMatchGivesUrgentOrdersToFastestWorkers(t *testing.T) {
+	q := NewQueue()
+	q.Idle(Worker{ID: "001", Type: "SLOW", ProcessingTime: 10 * time.Second})
+	q.Idle(Worker{ID: "002", Type: "FAST", ProcessingTime: 5 * time.Second})
+	q.Push(newOrderFor(1, OrderPriorityNormal, Affinity{}))
+	q.Push(newOrderFor(2, OrderPriorityVIP, Affinity{}))
+	q.Push(newOrderFor(3, OrderPriorityNormal, Affinity{}))
+
+	got := matched(q.Match(speedCost))
+	if len(got) != 2 || got["002"] != 2 || got["001"] != 1 {
+		t.Fatalf("Expected VIP 2 on FAST 002 and Normal 1 on SLOW 001, got %v", got)
+	}
+	if q.Len() != 1 {
+		t.Errorf("Expected 1 order left, got %d", q.Len())
+	}
+	if as := q.Match(speedCost); len(as) != 0 {
+		t.Errorf("Expected nothing to match without idle workers, got %v", matched(as))
+	}
+}
+
+func TestMatchHonoursAffinity(t *testing.T) {
+	q := NewQueue()
+	q.Idle(Worker{ID: "001", Type: "FAST", ProcessingTime: 5 * time.Second})
+	q.Idle(Worker{ID: "002", Type: "SLOW", ProcessingTime: 10 * time.Second})
+	q.Idle(Worker{ID: "003", Type: "FAST", Dedicated: true, ProcessingTime: 5 * time.Second})
+	q.Push(newOrderFor(1, OrderPriorityNormal, Affinity{}))
+	q.Push(newOrderFor(2, OrderPriorityNormal, Affinity{BotID: "002", Hard: true}))
+	q.Push(newOrderFor(3, OrderPriorityNormal, Affinity{BotID: "003"}))
+
+	got := matched(q.Match(speedCost))
+	if got["002"] != 2 || got["003"] != 3 || got["001"] != 1 {
+		t.Fatalf("Expected pinned and hinted orders on their bots, got %v", got)
+	}
+}
+
+func TestMatchSignalsAndPauses(t *testing.T) {
+	signals := 0
+	q := NewQueue(WithReadyFunc(func() { signals++ }))
+	q.Idle(Worker{ID: "001"})
+	if signals != 0 {
+		t.Fatalf("Expected no signal for an idle worker without orders, got %d", signals)
+	}
+	q.SetPaused(true)
+	q.Push(newOrderFor(1, OrderPriorityNormal, Affinity{}))
+	if as := q.Match(speedCost); len(as) != 0 {
+		t.Fatal("Expected nothing matched while paused")
+	}
+	q.SetPaused(false)
+	if signals != 2 {
+		t.Errorf("Expected push and unpause to signal, got %d signals", signals)
+	}
+	q.Leave("001")
+	if as := q.Match(speedCost); len(as) != 0 {
+		t.Fatal("Expected nothing matched after the worker left")
+	}
+}
+
+func TestIdlePolicies(t *testing.T) {
+	fast := Worker{ID: "003", ProcessingTime: 5 * time.Second}
+	slow1 := Worker{ID: "001", ProcessingTime: 10 * time.Second}
+	slow2 := Worker{ID: "002", ProcessingTime: 10 * time.Second}
+	flat := func(*Order, Worker) float64 { return 0 }
+
+	tests := []struct {
+		policy IdlePolicy
+		want   []string
+	}{
+		{FastestFirst, []string{"003", "003", "003"}},
+		// 001 and 002 have never cooked; 003 becomes the most recently used
+		{LeastRecentlyUsed, []string{"001", "002", "003"}},
+		{RoundRobin, []string{"001", "002", "003"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			q := NewQueue(WithIdlePolicy(tt.policy))
+			workers := map[string]Worker{"001": slow1, "002": slow2, "003": fast}
+			for _, w := range workers {
+				q.Idle(w)
+			}
+			for i, want := range tt.want {
+				q.Push(newOrderFor(i+1, OrderPriorityNormal, Affinity{}))
+				as := q.Match(flat)
+				if len(as) != 1 || as[0].Worker.ID != want {
+					t.Fatalf("Order %d: expected %s, got %v", i+1, want, matched(as))
+				}
+				// The worker cooks the order and comes back idle
+				q.Idle(workers[want])
+			}
+		})
+	}
+}
+
+func TestLeaveKeepsLeastRecentlyUsedStanding(t *testing.T) {
+	q := NewQueue(WithIdlePolicy(LeastRecentlyUsed))
+	flat := func(*Order, Worker) float64 { return 0 }
+	a, b := Worker{ID: "001"}, Worker{ID: "002"}
+
+	// 001 cooks, pauses and comes back; 002 has still never cooked
+	q.Idle(a)
+	q.Push(newOrderFor(1, OrderPriorityNormal, Affinity{}))
+	q.Match(flat)
+	q.Idle(a)
+	q.Leave(a.ID)
+	q.Idle(a)
+	q.Idle(b)
+	q.Push(newOrderFor(2, OrderPriorityNormal, Affinity{}))
+	if as := q.Match(flat); len(as) != 1 || as[0].Worker.ID != "002" {
+		t.Fatalf("Expected the unused 002 picked after 001 paused, got %v", matched(as))
+	}
+
+	// A removed worker's history goes with it
+	q.Forget(a.ID)
+	q.Idle(a)
+	q.Idle(b)
+	q.Push(newOrderFor(3, OrderPriorityNormal, Affinity{}))
+	if as := q.Match(flat); len(as) != 1 || as[0].Worker.ID != "001" {
+		t.Fatalf("Expected the forgotten 001 to count as unused, got %v", matched(as))
+	}
+}
+
+func TestParseIdlePolicy(t *testing.T) {
+	for _, p := range []IdlePolicy{FastestFirst, LeastRecentlyUsed, RoundRobin} {
+		if got, err := ParseIdlePolicy(p.String()); err != nil || got != p {
+			t.Errorf("ParseIdlePolicy(%q) = %v, %v", p, got, err)
+		}
+	}
+	if _, err := ParseIdlePolicy("random"); !errors.Is(err, ErrUnknownIdlePolicy) {
+		t.Errorf("Expected ErrUnknownIdlePolicy, got %v", err)
+	}
+}
@@ -1,13 +1,25 @@
 package order
 
-import "time"
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/feedme/order-controller/internal/clock"
+)
 
 type OrderStatusEnum string
 
 const (
+	OrderStatusScheduled  OrderStatusEnum = "SCHEDULED"
 	OrderStatusPending    OrderStatusEnum = "PENDING"
 	OrderStatusProcessing OrderStatusEnum = "PROCESSING"
 	OrderStatusComplete   OrderStatusEnum = "COMPLETE"
+	OrderStatusReady      OrderStatusEnum = "READY"
+	OrderStatusCollected  OrderStatusEnum = "COLLECTED"
+	OrderStatusExpired    OrderStatusEnum = "EXPIRED"
+	OrderStatusCancelled  OrderStatusEnum = "CANCELLED"
+	OrderStatusFailed     OrderStatusEnum = "FAILED"
 )
 
 type OrderTypeEnum string
@@ -31,11 +43,112 @@ var PriorityMap = map[OrderTypeEnum]int{
 }
 
 type Order struct {
+	// ID is the internal identifier, unique across every store in the process.
+	ID int
+	// Number is the customer-facing order number, e.g. "KL01-1001".
+	Number string
+	// Type and Priority change only through Queue.Modify while the order is
+	// queued, with both the queue's and the order's lock held.
+	Type      OrderTypeEnum
+	Priority  int
+	CreatedAt time.Time
+	// Affinity optionally ties the order to a bot or bot type. It is set
+	// before the order is queued and not changed afterwards.
+	Affinity Affinity
+	// NotBefore is the pickup time of a pre-order, which waits in SCHEDULED
+	// until it is released into the queue. It is zero for immediate orders.
+	NotBefore time.Time
+	// Contact tells how to notify the customer. It is set before the order is
+	// shared and not changed afterwards.
+	Contact Contact
+
+	// status, items, timestamps and history are guarded by mu; use the
+	// accessor methods or Snapshot to read them from other goroutines.
+	status      OrderStatusEnum
+	items       []Item
+	processedAt time.Time
+	completedAt time.Time
+	// progress is the fraction of cooking work done so far, carried across bots.
+	progress float64
+	history  []StatusTransition
+	mu       sync.Mutex
+	// observer is called with every transition while mu is held. It is set by
+	// the Store before the order is shared and never changed afterwards.
+	observer func(StatusTransition)
+	// clock stamps transitions. It is set with observer; nil means the wall clock.
+	clock clock.Clock
+
+	// requeued marks an order returned by PushFront so it is served ahead of
+	// its priority peers, including after Return. It is guarded by the lock of
+	// the Queue holding the order.
+	requeued bool
+}
+
+// OrderSnapshot is an immutable copy of an order's state at a point in time.
+// Zero timestamps mean the order has not reached that stage yet.
+type OrderSnapshot struct {
 	ID          int
+	Number      string
 	Type        OrderTypeEnum
-	Status      OrderStatusEnum
 	Priority    int
+	Status      OrderStatusEnum
+	Items       []Item
 	CreatedAt   time.Time
-	ProcessedAt *time.Time
-	CompletedAt *time.Time
+	ProcessedAt time.Time
+	CompletedAt time.Time
+	// NotBefore is the pickup time of a pre-order; zero for immediate orders.
+	NotBefore time.Time
+	Contact   Contact
+	// Progress is the fraction of cooking work done, between 0 and 1.
+	Progress float64
+}
+
+// NewOrder returns a PENDING order of the given type, created now by the given actor.
+func NewOrder(id int, orderType OrderTypeEnum, actor string) *Order {
+	return newOrderAt(id, orderType, actor, time.Now())
+}
+
+// newOrderAt is NewOrder with an explicit creation time.
+func newOrderAt(id int, orderType OrderTypeEnum, actor string, now time.Time) *Order {
+	return &Order{
+		ID:        id,
+		Type:      orderType,
+		Priority:  PriorityMap[orderType],
+		CreatedAt: now,
+		status:    OrderStatusPending,
+		history: []StatusTransition{{
+			OrderID: id,
+			To:      OrderStatusPending,
+			Actor:   actor,
+			At:      now,
+		}},
+	}
+}
+
+// scheduleFor turns a new order that has not been shared yet into a pre-order
+// for pickup at notBefore.
+func (o *Order) scheduleFor(notBefore time.Time) {
+	o.NotBefore = notBefore
+	o.status = OrderStatusScheduled
+	o.history[0].To = OrderStatusScheduled
+}
+
+// Snapshot returns a consistent copy of the order's current state.
+func (o *Order) Snapshot() OrderSnapshot {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return OrderSnapshot{
+		ID:          o.ID,
+		Number:      o.Number,
+		Type:        o.Type,
+		Priority:    o.Priority,
+		Status:      o.status,
+		Items:       slices.Clone(o.items),
+		CreatedAt:   o.CreatedAt,
+		ProcessedAt: o.processedAt,
+		CompletedAt: o.completedAt,
+		NotBefore:   o.NotBefore,
+		Contact:     o.Contact,
+		Progress:    o.progress,
+	}
 }
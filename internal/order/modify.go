@@ -0,0 +1,140 @@
+package order
+
+import (
+	"container/heap"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Item is one line of an order, e.g. two burgers.
+type Item struct {
+	Name     string
+	Quantity int
+}
+
+func (it Item) String() string {
+	return fmt.Sprintf("%dx %s", it.Quantity, it.Name)
+}
+
+// validateItems reports whether every item has a name and a positive quantity.
+func validateItems(items []Item) error {
+	for i, it := range items {
+		if it.Name == "" || it.Quantity <= 0 {
+			return fmt.Errorf("%w: item %d (%q, quantity %d)", ErrInvalidItem, i, it.Name, it.Quantity)
+		}
+	}
+	return nil
+}
+
+// OrderChanges describes a modification of a pending order. Zero-valued
+// fields are left unchanged; to remove every item, set Items to an empty,
+// non-nil slice.
+type OrderChanges struct {
+	Type  OrderTypeEnum
+	Items []Item
+}
+
+// Validate reports whether the changes can be applied to an order.
+func (c OrderChanges) Validate() error {
+	if c.Type != "" {
+		if _, ok := PriorityMap[c.Type]; !ok {
+			return fmt.Errorf("%w: %q", ErrUnknownOrderType, c.Type)
+		}
+	}
+	return validateItems(c.Items)
+}
+
+// OrderContents is the part of an order that may change while it is pending.
+type OrderContents struct {
+	Type     OrderTypeEnum
+	Priority int
+	Items    []Item
+}
+
+// OrderDiff records a modification of an order: its contents before and after.
+type OrderDiff struct {
+	OrderID int
+	From    OrderContents
+	To      OrderContents
+}
+
+// TypeChanged reports whether the order's type, and so its priority, changed.
+func (d OrderDiff) TypeChanged() bool {
+	return d.From.Type != d.To.Type
+}
+
+// ItemsChanged reports whether the order's items changed.
+func (d OrderDiff) ItemsChanged() bool {
+	return !slices.Equal(d.From.Items, d.To.Items)
+}
+
+// Changed reports whether the modification changed anything.
+func (d OrderDiff) Changed() bool {
+	return d.TypeChanged() || d.ItemsChanged()
+}
+
+// String lists the changed fields, e.g. "type Normal->VIP; items [] -> [2x Fries]".
+func (d OrderDiff) String() string {
+	var parts []string
+	if d.TypeChanged() {
+		parts = append(parts, fmt.Sprintf("type %s->%s", d.From.Type, d.To.Type))
+	}
+	if d.ItemsChanged() {
+		parts = append(parts, fmt.Sprintf("items %v -> %v", d.From.Items, d.To.Items))
+	}
+	if len(parts) == 0 {
+		return "no changes"
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Items returns a copy of the order's items.
+func (o *Order) Items() []Item {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return slices.Clone(o.items)
+}
+
+// modify applies c to the order and returns what changed. CreatedAt is kept,
+// so the order keeps its place among orders of its new priority. The caller
+// must hold the lock of the queue holding the order.
+func (o *Order) modify(c OrderChanges) OrderDiff {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	d := OrderDiff{
+		OrderID: o.ID,
+		From:    OrderContents{Type: o.Type, Priority: o.Priority, Items: slices.Clone(o.items)},
+	}
+	if c.Type != "" {
+		o.Type = c.Type
+		o.Priority = PriorityMap[c.Type]
+	}
+	if c.Items != nil {
+		o.items = slices.Clone(c.Items)
+	}
+	d.To = OrderContents{Type: o.Type, Priority: o.Priority, Items: slices.Clone(o.items)}
+	return d
+}
+
+// Modify changes the type or items of a queued order and restores the heap
+// order, so an upgraded order moves ahead of lower-priority ones while keeping
+// its creation time within its new tier. The changes must be valid; see
+// OrderChanges.Validate. The second result is false if the order is not
+// queued, e.g. because a bot has already taken it.
+func (q *Queue) Modify(id int, c OrderChanges) (OrderDiff, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, o := range q.pq {
+		if o.ID != id {
+			continue
+		}
+		d := o.modify(c)
+		if d.TypeChanged() {
+			heap.Fix(&q.pq, i)
+		}
+		return d, true
+	}
+	return OrderDiff{}, false
+}
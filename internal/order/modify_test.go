@@ -0,0 +1,86 @@
+package order
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestModifyUpgradeKeepsCreationOrder(t *testing.T) {
+	q := NewQueue()
+	s := NewStore(nil, nil)
+	vip := s.AddOrder(q, OrderTypeVIP)
+	n1 := s.AddOrder(q, OrderTypeNormal)
+	n2 := s.AddOrder(q, OrderTypeNormal)
+	laterVIP := s.AddOrder(q, OrderTypeVIP)
+
+	d, ok := s.ModifyOrder(q, n2.ID, OrderChanges{Type: OrderTypeVIP, Items: []Item{{Name: "Fries", Quantity: 2}}})
+	if !ok || !d.TypeChanged() || !d.ItemsChanged() {
+		t.Fatalf("Expected type and items changed, got %+v, %v", d, ok)
+	}
+	if d.From.Priority != OrderPriorityNormal || d.To.Priority != OrderPriorityVIP {
+		t.Errorf("Expected priority %d->%d, got %+v", OrderPriorityNormal, OrderPriorityVIP, d)
+	}
+
+	// The upgraded order joins the VIP tier by its original creation time.
+	want := []int{vip.ID, n2.ID, laterVIP.ID, n1.ID}
+	for i, id := range want {
+		if o := q.Pop(); o == nil || o.ID != id {
+			t.Fatalf("Pop %d: expected order %d, got %v", i, id, o)
+		}
+	}
+	if got := n2.Items(); len(got) != 1 || got[0].Name != "Fries" {
+		t.Errorf("Expected the new items, got %v", got)
+	}
+	if s.GetCountByType(OrderTypeVIP) != 3 || s.GetCountByType(OrderTypeNormal) != 1 {
+		t.Errorf("Expected counts to follow the upgrade, got %d VIP and %d Normal",
+			s.GetCountByType(OrderTypeVIP), s.GetCountByType(OrderTypeNormal))
+	}
+	if page := s.Query(Query{Type: OrderTypeVIP}); page.Total != 3 {
+		t.Errorf("Expected the type index to follow the upgrade, got %d VIP orders", page.Total)
+	}
+
+	if _, ok := s.ModifyOrder(q, n2.ID, OrderChanges{Type: OrderTypeNormal}); ok {
+		t.Error("Expected an order no longer queued not to be modified")
+	}
+}
+
+func TestOrderChangesValidate(t *testing.T) {
+	if err := (OrderChanges{Type: "Catering"}).Validate(); !errors.Is(err, ErrUnknownOrderType) {
+		t.Errorf("Expected ErrUnknownOrderType, got %v", err)
+	}
+	if err := (OrderChanges{Items: []Item{{Name: "Burger"}}}).Validate(); !errors.Is(err, ErrInvalidItem) {
+		t.Errorf("Expected ErrInvalidItem for a zero quantity, got %v", err)
+	}
+	if err := (OrderChanges{Items: []Item{}}).Validate(); err != nil {
+		t.Errorf("Expected clearing the items to be valid, got %v", err)
+	}
+}
+
+func TestFileRepositoryReplaysModifications(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "orders.jsonl")
+	repo, err := OpenFileRepository(path)
+	if err != nil {
+		t.Fatalf("OpenFileRepository: %v", err)
+	}
+	q := NewQueue()
+	s := NewStore(nil, nil, WithRepository(repo))
+	o := s.AddOrder(q, OrderTypeNormal)
+	s.ModifyOrder(q, o.ID, OrderChanges{Type: OrderTypeVIP, Items: []Item{{Name: "Burger", Quantity: 1}}})
+	if err := repo.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	reopened, err := OpenFileRepository(path)
+	if err != nil {
+		t.Fatalf("Reopen: %v", err)
+	}
+	defer reopened.Close()
+	snap := reopened.Get(o.ID).Snapshot()
+	if snap.Type != OrderTypeVIP || snap.Priority != OrderPriorityVIP || len(snap.Items) != 1 {
+		t.Errorf("Expected the modification to be replayed, got %+v", snap)
+	}
+	if page := reopened.Query(Query{Type: OrderTypeVIP}); page.Total != 1 {
+		t.Errorf("Expected the type index to be rebuilt, got %d VIP orders", page.Total)
+	}
+}
@@ -47,30 +47,51 @@ func TestSamePriorityFIFO(t *testing.T) {
 }
 func TestOrderStats(t *testing.T) {
 	q := NewQueue()
+	s := NewStore(nil, nil)
 
 	// Add a few orders
-	AddOrder(q, OrderTypeNormal)
-	AddOrder(q, OrderTypeNormal)
-	AddOrder(q, OrderTypeVIP)
+	s.AddOrder(q, OrderTypeNormal)
+	s.AddOrder(q, OrderTypeNormal)
+	s.AddOrder(q, OrderTypeVIP)
 
-	if GetTotalCount() < 3 {
-		t.Errorf("Expected total count at least 3, got %d", GetTotalCount())
+	if s.GetTotalCount() < 3 {
+		t.Errorf("Expected total count at least 3, got %d", s.GetTotalCount())
 	}
 
-	if GetCountByType(OrderTypeNormal) < 2 {
-		t.Errorf("Expected normal count at least 2, got %d", GetCountByType(OrderTypeNormal))
+	if s.GetCountByType(OrderTypeNormal) < 2 {
+		t.Errorf("Expected normal count at least 2, got %d", s.GetCountByType(OrderTypeNormal))
 	}
 
-	if GetCountByType(OrderTypeVIP) < 1 {
-		t.Errorf("Expected VIP count at least 1, got %d", GetCountByType(OrderTypeVIP))
+	if s.GetCountByType(OrderTypeVIP) < 1 {
+		t.Errorf("Expected VIP count at least 1, got %d", s.GetCountByType(OrderTypeVIP))
 	}
 
 	// Pop one and mark complete (status update)
 	ord := q.Pop()
-	ord.Status = OrderStatusComplete
+	if err := ord.Transition(OrderStatusProcessing, BotActor("001")); err != nil {
+		t.Fatal(err)
+	}
+	if err := ord.Transition(OrderStatusComplete, BotActor("001")); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.GetCompletedCount() < 1 {
+		t.Errorf("Expected completed count at least 1, got %d", s.GetCompletedCount())
+	}
+}
+
+func TestStoresAreIndependent(t *testing.T) {
+	s1 := NewStore(nil, nil)
+	s2 := NewStore(nil, nil)
+
+	o1 := s1.AddOrder(NewQueue(), OrderTypeNormal)
+	o2 := s2.AddOrder(NewQueue(), OrderTypeNormal)
 
-	if GetCompletedCount() < 1 {
-		t.Errorf("Expected completed count at least 1, got %d", GetCompletedCount())
+	if o1.ID != 1001 || o2.ID != 1001 {
+		t.Errorf("Expected both stores to start at ID 1001, got %d and %d", o1.ID, o2.ID)
+	}
+	if s1.GetTotalCount() != 1 || s2.GetTotalCount() != 1 {
+		t.Errorf("Expected 1 order per store, got %d and %d", s1.GetTotalCount(), s2.GetTotalCount())
 	}
 }
 
@@ -112,3 +133,48 @@ func TestQueuePause(t *testing.T) {
 		t.Error("Pop should return order when queue is unpaused")
 	}
 }
+
+func TestPushFrontAheadOfPeers(t *testing.T) {
+	q := NewQueue()
+	now := time.Now()
+
+	q.Push(&Order{ID: 2, Type: OrderTypeNormal, Priority: OrderPriorityNormal, CreatedAt: now})
+	q.Push(&Order{ID: 3, Type: OrderTypeVIP, Priority: OrderPriorityVIP, CreatedAt: now})
+	// Requeued Normal order created later than its peer
+	q.PushFront(&Order{ID: 4, Type: OrderTypeNormal, Priority: OrderPriorityNormal, CreatedAt: now.Add(time.Second)})
+
+	// VIP still first, then the requeued Normal ahead of the older Normal
+	for _, expectedID := range []int{3, 4, 2} {
+		if got := q.Pop(); got.ID != expectedID {
+			t.Errorf("Expected ID %d, got %d", expectedID, got.ID)
+		}
+	}
+}
+
+func TestQueueListAndPosition(t *testing.T) {
+	q := NewQueue()
+	now := time.Now()
+	q.Push(&Order{ID: 1, Type: OrderTypeNormal, Priority: OrderPriorityNormal, CreatedAt: now})
+	q.Push(&Order{ID: 2, Type: OrderTypeNormal, Priority: OrderPriorityNormal, CreatedAt: now.Add(time.Millisecond)})
+	q.Push(&Order{ID: 3, Type: OrderTypeVIP, Priority: OrderPriorityVIP, CreatedAt: now.Add(2 * time.Millisecond)})
+
+	list := q.List()
+	expected := []int{3, 1, 2}
+	if len(list) != len(expected) {
+		t.Fatalf("Expected %d listed orders, got %d", len(expected), len(list))
+	}
+	for i, id := range expected {
+		if list[i].ID != id {
+			t.Errorf("List[%d]: expected ID %d, got %d", i, id, list[i].ID)
+		}
+		if pos, ok := q.Position(id); !ok || pos != i+1 {
+			t.Errorf("Position(%d): expected %d, got %d (%v)", id, i+1, pos, ok)
+		}
+	}
+	if _, ok := q.Position(99); ok {
+		t.Error("Expected unknown order to have no position")
+	}
+	if q.Len() != 3 {
+		t.Errorf("Listing must not consume orders, queue length %d", q.Len())
+	}
+}
@@ -0,0 +1,55 @@
+package order
+
+// ProgressPolicy decides how much cooking progress an order keeps when it is
+// interrupted (e.g. its bot is removed) and returned to the queue.
+type ProgressPolicy struct {
+	// Retain is the fraction of accumulated progress kept, between 0 and 1.
+	Retain float64
+}
+
+var (
+	// KeepProgress resumes interrupted orders exactly where they stopped.
+	KeepProgress = ProgressPolicy{Retain: 1}
+	// LoseProgress restarts interrupted orders from zero.
+	LoseProgress = ProgressPolicy{Retain: 0}
+)
+
+// PartialProgress keeps the given fraction of an interrupted order's progress,
+// e.g. 0.5 when half of the work is assumed spoiled. The fraction is clamped to [0, 1].
+func PartialProgress(retain float64) ProgressPolicy {
+	return ProgressPolicy{Retain: clampFraction(retain)}
+}
+
+// Progress returns the fraction of cooking work already done, between 0 and 1.
+// Progress is measured as a fraction rather than a duration so it carries over
+// between bots of different speeds.
+func (o *Order) Progress() float64 {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.progress
+}
+
+// AddProgress adds the given fraction of work to the order's progress, capped at 1.
+func (o *Order) AddProgress(fraction float64) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.progress = clampFraction(o.progress + fraction)
+}
+
+// ApplyProgressPolicy scales the order's progress according to p. It is called
+// when an interrupted order is returned to the queue.
+func (o *Order) ApplyProgressPolicy(p ProgressPolicy) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.progress = clampFraction(o.progress * clampFraction(p.Retain))
+}
+
+func clampFraction(f float64) float64 {
+	if f < 0 {
+		return 0
+	}
+	if f > 1 {
+		return 1
+	}
+	return f
+}
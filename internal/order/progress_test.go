@@ -0,0 +1,35 @@
+package order
+
+import "testing"
+
+func TestProgressPolicies(t *testing.T) {
+	cases := []struct {
+		name   string
+		policy ProgressPolicy
+		want   float64
+	}{
+		{"keep", KeepProgress, 0.6},
+		{"lose", LoseProgress, 0},
+		{"partial", PartialProgress(0.5), 0.3},
+		{"partial clamped", PartialProgress(2), 0.6},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			o := NewOrder(1, OrderTypeNormal, ActorUser)
+			o.AddProgress(0.6)
+			o.ApplyProgressPolicy(c.policy)
+			if got := o.Progress(); got < c.want-1e-9 || got > c.want+1e-9 {
+				t.Errorf("Expected progress %.2f, got %.2f", c.want, got)
+			}
+		})
+	}
+}
+
+func TestProgressIsCapped(t *testing.T) {
+	o := NewOrder(1, OrderTypeNormal, ActorUser)
+	o.AddProgress(0.7)
+	o.AddProgress(0.7)
+	if o.Progress() != 1 {
+		t.Errorf("Expected progress capped at 1, got %.2f", o.Progress())
+	}
+}
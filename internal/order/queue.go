@@ -2,7 +2,10 @@ package order
 
 import (
 	"container/heap"
+	"sort"
 	"sync"
+
+	"github.com/feedme/order-controller/internal/clock"
 )
 
 // PriorityQueue implements heap.Interface and holds Orders.
@@ -15,6 +18,10 @@ func (pq PriorityQueue) Less(i, j int) bool {
 	if pq[i].Priority != pq[j].Priority {
 		return pq[i].Priority > pq[j].Priority
 	}
+	// Interrupted orders go back ahead of their priority peers
+	if pq[i].requeued != pq[j].requeued {
+		return pq[i].requeued
+	}
 	// For same priority, earlier CreatedAt comes first
 	if !pq[i].CreatedAt.Equal(pq[j].CreatedAt) {
 		return pq[i].CreatedAt.Before(pq[j].CreatedAt)
@@ -46,73 +53,204 @@ func (pq *PriorityQueue) Pop() interface{} {
 // such as Asynq or Machinery, backed by a persistent broker like Redis or RabbitMQ
 // to ensure task persistence, scalability, and better reliability.
 type Queue struct {
-	pq PriorityQueue
-	mu sync.Mutex
-	// Notify is used as an internal signaling mechanism to wake up bot workers
-	// immediately when an order is available, minimizing idle polling.
-	Notify chan struct{}
+	pq     PriorityQueue
+	mu     sync.Mutex
 	paused bool //  allows a manager to "freeze" bots from picking up orders
+	// affine counts queued orders with an affinity; while it is zero, PopFor
+	// can take the heap top directly.
+	affine int
+	// clock decides when hard-pin fallbacks expire.
+	clock clock.Clock
+	// idle holds the workers waiting for an order, keyed by worker ID. Match
+	// hands them orders; policy breaks ties between workers of equal cost.
+	idle   map[string]Worker
+	policy IdlePolicy
+	// lastUsed records, per worker, the value of uses when it last got an
+	// order; lastWoken is the worker picked most recently.
+	uses      uint64
+	lastUsed  map[string]uint64
+	lastWoken string
+	// ready is called when Match may have work to do. It may be nil.
+	ready func()
+}
+
+// QueueOption configures a Queue at construction time.
+type QueueOption func(*Queue)
+
+// WithQueueClock sets the clock used for affinity fallbacks. The default is clock.Real.
+func WithQueueClock(c clock.Clock) QueueOption {
+	return func(q *Queue) {
+		q.clock = c
+	}
 }
 
 // NewQueue initializes and returns a new empty order priority Queue.
-func NewQueue() *Queue {
+func NewQueue(opts ...QueueOption) *Queue {
 	q := &Queue{
-		pq:     make(PriorityQueue, 0),
-		Notify: make(chan struct{}, 100), // Buffered to prevent blocking producers
+		pq:       make(PriorityQueue, 0),
+		clock:    clock.Real,
+		idle:     make(map[string]Worker),
+		lastUsed: make(map[string]uint64),
+	}
+	for _, opt := range opts {
+		opt(q)
 	}
 	heap.Init(&q.pq)
 	return q
 }
 
+// SetPaused stops (true) or restarts (false) handing out orders. Unpausing
+// signals the queue's owner to match waiting orders.
 func (q *Queue) SetPaused(paused bool) {
 	q.mu.Lock()
-	defer q.mu.Unlock()
 	q.paused = paused
-	// If we just unpaused, signal bots to check the queue again
+	q.mu.Unlock()
+
 	if !paused {
-		select {
-		case q.Notify <- struct{}{}:
-		default:
-		}
+		q.signal()
 	}
 }
 
-// Push adds a new order to the priority queue.
+// Push adds a new order to the priority queue and signals the queue's owner.
+// The order is placed by priority and creation time, even if it was returned
+// by PushFront before. Orders hard-pinned with a fallback signal again once
+// the fallback time has passed.
 func (q *Queue) Push(order *Order) {
 	q.mu.Lock()
-	heap.Push(&q.pq, order)
+	order.requeued = false
+	q.pushLocked(order)
+	q.mu.Unlock()
+
+	if a := order.Affinity; a.Hard && a.FallbackAfter > 0 {
+		wait := a.FallbackAfter - q.clock.Since(order.CreatedAt)
+		q.clock.AfterFunc(wait, q.signal)
+	}
+	q.signal()
+}
+
+// PushBatch adds several orders to the queue under one lock, so the dispatcher
+// never sees part of the batch, and signals the queue's owner once.
+func (q *Queue) PushBatch(orders []*Order) {
+	q.mu.Lock()
+	for _, o := range orders {
+		q.pushLocked(o)
+	}
 	q.mu.Unlock()
 
-	// Signal that a new order is available
-	select {
-	case q.Notify <- struct{}{}:
-	default:
-		// Channel full, signal already pending
+	for _, o := range orders {
+		if a := o.Affinity; a.Hard && a.FallbackAfter > 0 {
+			wait := a.FallbackAfter - q.clock.Since(o.CreatedAt)
+			q.clock.AfterFunc(wait, q.signal)
+		}
 	}
+	q.signal()
 }
 
-// Pop removes and returns the highest-priority order from the queue.
+// Pop removes and returns the highest-priority order that any bot may take,
+// skipping orders hard-pinned to specific bots.
 // Returns nil if the queue is empty.
 func (q *Queue) Pop() *Order {
+	return q.PopFor(Worker{})
+}
+
+// PopFor removes and returns the highest-priority order the given worker may
+// take. Among orders of equal priority, orders whose affinity matches the worker
+// are preferred. Returns nil if the queue is paused or holds nothing for w.
+func (q *Queue) PopFor(w Worker) *Order {
 	q.mu.Lock()
 	defer q.mu.Unlock()
+	return q.popForLocked(w)
+}
+
+// popForLocked implements PopFor. The caller must hold q.mu.
+func (q *Queue) popForLocked(w Worker) *Order {
 	if q.paused || q.pq.Len() == 0 {
 		return nil
 	}
-	return heap.Pop(&q.pq).(*Order)
+
+	if q.affine == 0 && !w.Dedicated {
+		return q.removeLocked(0)
+	}
+
+	now := q.clock.Now()
+	best := -1
+	for i, o := range q.pq {
+		if !Eligible(o, w, now) {
+			continue
+		}
+		if best == -1 || q.preferred(o, q.pq[best], w) {
+			best = i
+		}
+	}
+	if best == -1 {
+		return nil
+	}
+	return q.removeLocked(best)
+}
+
+// preferred reports whether worker w should take a before b.
+func (q *Queue) preferred(a, b *Order, w Worker) bool {
+	if a.Priority != b.Priority {
+		return a.Priority > b.Priority
+	}
+	if am, bm := a.Affinity.Matches(w), b.Affinity.Matches(w); am != bm {
+		return am
+	}
+	return PriorityQueue{a, b}.Less(0, 1)
+}
+
+// pushLocked adds an order to the heap. The caller must hold q.mu.
+func (q *Queue) pushLocked(o *Order) {
+	if !o.Affinity.IsZero() {
+		q.affine++
+	}
+	heap.Push(&q.pq, o)
+}
+
+// removeLocked removes the order at heap index i. The caller must hold q.mu.
+func (q *Queue) removeLocked(i int) *Order {
+	o := heap.Remove(&q.pq, i).(*Order)
+	if !o.Affinity.IsZero() {
+		q.affine--
+	}
+	return o
 }
 
 // PushFront adds an order back to the queue (e.g., after a bot cancellation).
+// It is placed ahead of every queued order of the same priority, but still
+// behind higher-priority orders. Like Push, it signals the queue's owner.
 func (q *Queue) PushFront(order *Order) {
 	q.mu.Lock()
-	heap.Push(&q.pq, order)
+	order.requeued = true
+	q.pushLocked(order)
 	q.mu.Unlock()
 
-	// Signal that a new order is available
-	select {
-	case q.Notify <- struct{}{}:
-	default:
+	q.signal()
+}
+
+// Return puts back an order that was taken from the queue but never started,
+// e.g. because it could not be delivered to its bot. It gets the place it had:
+// an order returned by PushFront is still served ahead of its priority peers.
+// Like Push, it signals the queue's owner.
+func (q *Queue) Return(order *Order) {
+	q.mu.Lock()
+	q.pushLocked(order)
+	q.mu.Unlock()
+
+	q.signal()
+}
+
+// Remove takes the order with the given ID out of the queue and returns it.
+// Returns nil if the order is not queued.
+func (q *Queue) Remove(id int) *Order {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, o := range q.pq {
+		if o.ID == id {
+			return q.removeLocked(i)
+		}
 	}
+	return nil
 }
 
 // Peek returns the highest-priority order without removing it from the queue.
@@ -125,6 +263,49 @@ func (q *Queue) Peek() *Order {
 	return q.pq[0]
 }
 
+// List returns a snapshot of every queued order in dispatch order, i.e. the
+// order in which a bot without affinities would pop them.
+func (q *Queue) List() []OrderSnapshot {
+	q.mu.Lock()
+	sorted := make(PriorityQueue, len(q.pq))
+	copy(sorted, q.pq)
+	sort.Sort(sorted)
+	q.mu.Unlock()
+
+	snapshots := make([]OrderSnapshot, len(sorted))
+	for i, o := range sorted {
+		snapshots[i] = o.Snapshot()
+	}
+	return snapshots
+}
+
+// Position returns the 1-based place of the order in dispatch order. The second
+// result is false if the order is not queued.
+func (q *Queue) Position(orderID int) (int, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var target *Order
+	for _, o := range q.pq {
+		if o.ID == orderID {
+			target = o
+			break
+		}
+	}
+	if target == nil {
+		return 0, false
+	}
+
+	// Count the orders that would be dispatched before the target
+	ahead := 0
+	for _, o := range q.pq {
+		if o != target && (PriorityQueue{o, target}).Less(0, 1) {
+			ahead++
+		}
+	}
+	return ahead + 1, true
+}
+
 // Len returns the number of orders currently in the queue.
 func (q *Queue) Len() int {
 	q.mu.Lock()
@@ -0,0 +1,62 @@
+package order
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func benchOrders(n int) []*Order {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	orders := make([]*Order, n)
+	for i := range orders {
+		typ := OrderTypeNormal
+		if i%5 == 0 {
+			typ = OrderTypeVIP
+		}
+		orders[i] = &Order{ID: i + 1, Type: typ, Priority: PriorityMap[typ], CreatedAt: base.Add(time.Duration(i))}
+	}
+	return orders
+}
+
+// BenchmarkQueuePushPop measures one push and one pop on a queue already
+// holding size orders.
+func BenchmarkQueuePushPop(b *testing.B) {
+	for _, size := range []int{0, 1000, 100000} {
+		b.Run(fmt.Sprintf("size=%d", size), func(b *testing.B) {
+			q := NewQueue()
+			for _, o := range benchOrders(size) {
+				q.Push(o)
+			}
+			extra := benchOrders(size + 1)[size]
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				q.Push(extra)
+				extra = q.Pop()
+			}
+		})
+	}
+}
+
+// BenchmarkQueuePopForPinned measures PopFor when pinned orders force the
+// linear scan instead of taking the heap top.
+func BenchmarkQueuePopForPinned(b *testing.B) {
+	q := NewQueue()
+	orders := benchOrders(1000)
+	for i, o := range orders {
+		if i%10 == 0 {
+			o.Affinity = Affinity{BotType: "FAST"}
+		}
+		q.Push(o)
+	}
+	w := Worker{ID: "001", Type: "FAST"}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		o := q.PopFor(w)
+		q.PushFront(o)
+	}
+}
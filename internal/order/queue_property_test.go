@@ -0,0 +1,171 @@
+package order
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/feedme/order-controller/internal/proptest"
+)
+
+// queueOp is one step of a randomized queue scenario.
+type queueOp byte
+
+const (
+	opPushNormal queueOp = iota
+	opPushVIP
+	opPop
+	opPushFront // returns the most recently popped order
+	opPause
+	opUnpause
+	numQueueOps
+)
+
+func (op queueOp) String() string {
+	return [...]string{"PushNormal", "PushVIP", "Pop", "PushFront", "Pause", "Unpause"}[op%numQueueOps]
+}
+
+func decodeQueueOps(data []byte) []queueOp {
+	ops := make([]queueOp, len(data))
+	for i, b := range data {
+		ops[i] = queueOp(b) % numQueueOps
+	}
+	return ops
+}
+
+func randomQueueOps(rnd *rand.Rand) []queueOp {
+	ops := make([]queueOp, 10+rnd.Intn(60))
+	for i := range ops {
+		ops[i] = queueOp(rnd.Intn(int(numQueueOps)))
+	}
+	return ops
+}
+
+// queued is the reference model of an order waiting in the queue.
+type queued struct {
+	order    *Order
+	requeued bool
+}
+
+// checkQueueOps runs ops against a Queue and a sorted-list model of it. Every
+// pop must return the model's head, which means VIP orders always leave before
+// Normal ones, requeued orders lead their class and the rest leave in FIFO
+// order. At the end, every pushed order must be either still queued or held by
+// a consumer, exactly once.
+func checkQueueOps(ops []queueOp) error {
+	q := NewQueue()
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var (
+		model  []queued
+		held   []*Order
+		pushed = make(map[int]bool)
+		paused bool
+		nextID = 1
+	)
+
+	for step, op := range ops {
+		switch op {
+		case opPushNormal, opPushVIP:
+			typ := OrderTypeNormal
+			if op == opPushVIP {
+				typ = OrderTypeVIP
+			}
+			o := &Order{ID: nextID, Type: typ, Priority: PriorityMap[typ], CreatedAt: base.Add(time.Duration(nextID) * time.Millisecond)}
+			nextID++
+			q.Push(o)
+			model = append(model, queued{order: o})
+			pushed[o.ID] = true
+		case opPop:
+			got := q.Pop()
+			if paused || len(model) == 0 {
+				if got != nil {
+					return fmt.Errorf("step %d: popped order %d from a paused or empty queue", step, got.ID)
+				}
+				continue
+			}
+			sortModel(model)
+			want := model[0].order
+			if got == nil || got.ID != want.ID {
+				return fmt.Errorf("step %d: popped %s, want order %d (%s)", step, describe(got), want.ID, want.Type)
+			}
+			model = model[1:]
+			held = append(held, got)
+		case opPushFront:
+			if len(held) == 0 {
+				continue
+			}
+			o := held[len(held)-1]
+			held = held[:len(held)-1]
+			q.PushFront(o)
+			model = append(model, queued{order: o, requeued: true})
+		case opPause, opUnpause:
+			paused = op == opPause
+			q.SetPaused(paused)
+		}
+		if q.Len() != len(model) {
+			return fmt.Errorf("step %d: queue holds %d orders, want %d", step, q.Len(), len(model))
+		}
+	}
+
+	// Drain and check that nothing was lost or duplicated
+	q.SetPaused(false)
+	seen := make(map[int]bool)
+	for _, o := range held {
+		seen[o.ID] = true
+	}
+	for o := q.Pop(); o != nil; o = q.Pop() {
+		if seen[o.ID] {
+			return fmt.Errorf("order %d returned twice", o.ID)
+		}
+		seen[o.ID] = true
+	}
+	for id := range pushed {
+		if !seen[id] {
+			return fmt.Errorf("order %d was lost", id)
+		}
+	}
+	return nil
+}
+
+// sortModel orders the model by the queue's documented dispatch order.
+func sortModel(model []queued) {
+	sort.SliceStable(model, func(i, j int) bool {
+		a, b := model[i], model[j]
+		if a.order.Priority != b.order.Priority {
+			return a.order.Priority > b.order.Priority
+		}
+		if a.requeued != b.requeued {
+			return a.requeued
+		}
+		return a.order.ID < b.order.ID
+	})
+}
+
+func describe(o *Order) string {
+	if o == nil {
+		return "nothing"
+	}
+	return fmt.Sprintf("order %d (%s)", o.ID, o.Type)
+}
+
+func TestQueueProperties(t *testing.T) {
+	runs := 500
+	if testing.Short() {
+		runs = 50
+	}
+	proptest.Check(t, runs, randomQueueOps, checkQueueOps)
+}
+
+func FuzzQueue(f *testing.F) {
+	f.Add([]byte{0, 0, 1, 2, 2, 2})
+	f.Add([]byte{0, 1, 2, 3, 2, 4, 2, 5, 2})
+	f.Add([]byte{1, 0, 2, 0, 3, 1, 2, 2, 3, 3, 2})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ops := decodeQueueOps(data)
+		if err := checkQueueOps(ops); err != nil {
+			proptest.Fail(t, ops, checkQueueOps, "fuzz input %v", data)
+		}
+	})
+}
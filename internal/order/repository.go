@@ -0,0 +1,364 @@
+package order
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrDuplicateOrder is returned when adding an order whose ID is already stored.
+var ErrDuplicateOrder = errors.New("order already stored")
+
+// Repository stores the orders of one restaurant and answers historical
+// queries about them. Implementations keep their indexes up to date through
+// Record, which the Store wires to every status transition of an added order,
+// and Modify, which the Store calls when a pending order is changed.
+type Repository interface {
+	// Add stores a newly created order.
+	Add(o *Order) error
+	// Get returns the order with the given ID, or nil if it is unknown or evicted.
+	Get(id int) *Order
+	// Record updates the indexes after an order changed status. It is called
+	// while the order's own lock is held and must not call back into the order.
+	Record(t StatusTransition)
+	// Modify updates the indexes after a pending order's type or items changed.
+	Modify(d OrderDiff)
+	// Query returns the orders matching q, oldest first.
+	Query(q Query) Page
+	// Count returns how many orders match q, ignoring pagination.
+	Count(q Query) int
+	// Evict removes finished (COMPLETE, COLLECTED, EXPIRED, CANCELLED or
+	// FAILED) orders that finished before the given time and returns how many
+	// were removed.
+	Evict(before time.Time) int
+}
+
+// WorkerTransition is a status change of a worker cooking orders, e.g. a bot
+// being PAUSED, kept so reports can tell when a worker was actually cooking.
+type WorkerTransition struct {
+	WorkerID string
+	From     string
+	To       string
+	At       time.Time
+}
+
+// WorkerJournal is implemented by repositories that also keep the status
+// changes of the restaurant's workers. Evict drops worker transitions made
+// before the given time along with the finished orders.
+type WorkerJournal interface {
+	// RecordWorker stores a worker status change.
+	RecordWorker(t WorkerTransition)
+	// WorkerHistory returns every stored worker status change, oldest first.
+	WorkerHistory() []WorkerTransition
+}
+
+// Query filters orders in a Repository. Zero-valued fields do not filter.
+type Query struct {
+	Status OrderStatusEnum
+	Type   OrderTypeEnum
+	// BotID matches orders that were picked up by the bot at least once.
+	BotID string
+	// CreatedFrom and CreatedTo bound the creation time (inclusive, exclusive).
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	// Offset and Limit paginate the result. A zero Limit returns everything.
+	Offset int
+	Limit  int
+}
+
+// Page is one page of query results.
+type Page struct {
+	Orders []OrderSnapshot
+	// Total is the number of matching orders before pagination.
+	Total int
+}
+
+// isTerminal reports whether an order in this status is finished with. A
+// COMPLETE order counts as finished, since it only moves on when the
+// restaurant has a pickup shelf, and then at once.
+func isTerminal(s OrderStatusEnum) bool {
+	switch s {
+	case OrderStatusComplete, OrderStatusCollected, OrderStatusExpired, OrderStatusCancelled, OrderStatusFailed:
+		return true
+	}
+	return false
+}
+
+// entry is the repository's own view of an order, kept separately so queries
+// never need the order's lock.
+type entry struct {
+	order      *Order
+	createdAt  time.Time
+	typ        OrderTypeEnum
+	status     OrderStatusEnum
+	finishedAt time.Time
+	bots       map[string]struct{}
+}
+
+// MemoryRepository is an in-memory Repository indexed by ID, status, type, bot
+// and creation time.
+type MemoryRepository struct {
+	byID     map[int]*entry
+	byStatus map[OrderStatusEnum]map[int]struct{}
+	byType   map[OrderTypeEnum]map[int]struct{}
+	byBot    map[string]map[int]struct{}
+	// created lists entries in creation order; evicted entries are compacted lazily.
+	created []*entry
+	evicted int
+	// workers lists worker status changes in the order they were recorded.
+	workers []WorkerTransition
+	mu      sync.RWMutex
+}
+
+// NewMemoryRepository returns an empty in-memory repository.
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		byID:     make(map[int]*entry),
+		byStatus: make(map[OrderStatusEnum]map[int]struct{}),
+		byType:   make(map[OrderTypeEnum]map[int]struct{}),
+		byBot:    make(map[string]map[int]struct{}),
+	}
+}
+
+// Add stores a newly created order.
+func (r *MemoryRepository) Add(o *Order) error {
+	snap := o.Snapshot()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.byID[o.ID]; ok {
+		return ErrDuplicateOrder
+	}
+	e := &entry{order: o, createdAt: o.CreatedAt, typ: snap.Type, status: snap.Status, bots: make(map[string]struct{})}
+	r.byID[o.ID] = e
+	addIndex(r.byStatus, snap.Status, o.ID)
+	addIndex(r.byType, snap.Type, o.ID)
+	r.created = append(r.created, e)
+	return nil
+}
+
+// Get returns the order with the given ID, or nil.
+func (r *MemoryRepository) Get(id int) *Order {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if e, ok := r.byID[id]; ok {
+		return e.order
+	}
+	return nil
+}
+
+// Record moves the order between status indexes and notes the bot that picked it up.
+func (r *MemoryRepository) Record(t StatusTransition) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e, ok := r.byID[t.OrderID]
+	if !ok {
+		return
+	}
+	removeIndex(r.byStatus, e.status, t.OrderID)
+	addIndex(r.byStatus, t.To, t.OrderID)
+	e.status = t.To
+	if isTerminal(t.To) {
+		e.finishedAt = t.At
+	}
+	if botID, ok := BotIDFromActor(t.Actor); ok {
+		e.bots[botID] = struct{}{}
+		addIndex(r.byBot, botID, t.OrderID)
+	}
+}
+
+// RecordWorker stores a worker status change.
+func (r *MemoryRepository) RecordWorker(t WorkerTransition) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.workers = append(r.workers, t)
+}
+
+// WorkerHistory returns every stored worker status change, oldest first.
+func (r *MemoryRepository) WorkerHistory() []WorkerTransition {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	history := make([]WorkerTransition, len(r.workers))
+	copy(history, r.workers)
+	return history
+}
+
+// Modify moves the order between type indexes.
+func (r *MemoryRepository) Modify(d OrderDiff) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e, ok := r.byID[d.OrderID]
+	if !ok || e.typ == d.To.Type {
+		return
+	}
+	removeIndex(r.byType, e.typ, d.OrderID)
+	addIndex(r.byType, d.To.Type, d.OrderID)
+	e.typ = d.To.Type
+}
+
+// Query returns the orders matching q, oldest first.
+func (r *MemoryRepository) Query(q Query) Page {
+	r.mu.RLock()
+	matches := r.match(q)
+	r.mu.RUnlock()
+
+	page := Page{Total: len(matches)}
+	start := q.Offset
+	if start > len(matches) {
+		start = len(matches)
+	}
+	end := len(matches)
+	if q.Limit > 0 && start+q.Limit < end {
+		end = start + q.Limit
+	}
+
+	// Snapshots are taken after releasing the repository lock, since Record
+	// runs with order locks held.
+	page.Orders = make([]OrderSnapshot, 0, end-start)
+	for _, o := range matches[start:end] {
+		page.Orders = append(page.Orders, o.Snapshot())
+	}
+	return page
+}
+
+// Count returns how many orders match q. Single-index queries are answered
+// from the index size without scanning.
+func (r *MemoryRepository) Count(q Query) int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	plain := q.BotID == "" && q.CreatedFrom.IsZero() && q.CreatedTo.IsZero()
+	switch {
+	case plain && q.Status == "" && q.Type == "":
+		return len(r.byID)
+	case plain && q.Type == "":
+		return len(r.byStatus[q.Status])
+	case plain && q.Status == "":
+		return len(r.byType[q.Type])
+	}
+	return len(r.match(q))
+}
+
+// Evict removes finished orders that finished before the given time, along
+// with worker status changes made before it.
+func (r *MemoryRepository) Evict(before time.Time) int {
+	removed, _ := r.evict(before)
+	return removed
+}
+
+// evict implements Evict and also returns how many worker status changes
+// were removed.
+func (r *MemoryRepository) evict(before time.Time) (int, int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	removed := 0
+	for id, e := range r.byID {
+		if !isTerminal(e.status) || !e.finishedAt.Before(before) {
+			continue
+		}
+		delete(r.byID, id)
+		removeIndex(r.byStatus, e.status, id)
+		removeIndex(r.byType, e.typ, id)
+		for botID := range e.bots {
+			removeIndex(r.byBot, botID, id)
+		}
+		e.order = nil
+		removed++
+	}
+
+	r.evicted += removed
+	if r.evicted > len(r.created)/2 {
+		r.compact()
+	}
+
+	workers := r.workers[:0]
+	for _, t := range r.workers {
+		if !t.At.Before(before) {
+			workers = append(workers, t)
+		}
+	}
+	dropped := len(r.workers) - len(workers)
+	clear(r.workers[len(workers):])
+	r.workers = workers
+	return removed, dropped
+}
+
+// match returns the orders matching q in creation order, ignoring pagination.
+// The caller must hold r.mu.
+func (r *MemoryRepository) match(q Query) []*Order {
+	// Narrow the search to the creation-time window first
+	from := 0
+	if !q.CreatedFrom.IsZero() {
+		from = sort.Search(len(r.created), func(i int) bool {
+			return !r.created[i].createdAt.Before(q.CreatedFrom)
+		})
+	}
+	to := len(r.created)
+	if !q.CreatedTo.IsZero() {
+		to = sort.Search(len(r.created), func(i int) bool {
+			return !r.created[i].createdAt.Before(q.CreatedTo)
+		})
+	}
+
+	var matches []*Order
+	for _, e := range r.created[from:max(from, to)] {
+		if e.order == nil {
+			continue
+		}
+		id := e.order.ID
+		if q.Status != "" && !hasIndex(r.byStatus, q.Status, id) {
+			continue
+		}
+		if q.Type != "" && e.typ != q.Type {
+			continue
+		}
+		if q.BotID != "" && !hasIndex(r.byBot, q.BotID, id) {
+			continue
+		}
+		matches = append(matches, e.order)
+	}
+	return matches
+}
+
+// compact drops evicted entries from the creation list. The caller must hold r.mu.
+func (r *MemoryRepository) compact() {
+	live := r.created[:0]
+	for _, e := range r.created {
+		if e.order != nil {
+			live = append(live, e)
+		}
+	}
+	for i := len(live); i < len(r.created); i++ {
+		r.created[i] = nil
+	}
+	r.created = live
+	r.evicted = 0
+}
+
+func addIndex[K comparable](index map[K]map[int]struct{}, key K, id int) {
+	set, ok := index[key]
+	if !ok {
+		set = make(map[int]struct{})
+		index[key] = set
+	}
+	set[id] = struct{}{}
+}
+
+func removeIndex[K comparable](index map[K]map[int]struct{}, key K, id int) {
+	if set, ok := index[key]; ok {
+		delete(set, id)
+		if len(set) == 0 {
+			delete(index, key)
+		}
+	}
+}
+
+func hasIndex[K comparable](index map[K]map[int]struct{}, key K, id int) bool {
+	_, ok := index[key][id]
+	return ok
+}
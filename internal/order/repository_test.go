@@ -0,0 +1,214 @@
+package order
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fillStore adds four orders: VIP and Normal cooked by bot A, one Normal
+// cancelled, and one VIP left pending.
+func fillStore(t *testing.T, s *Store) []*Order {
+	t.Helper()
+	q := NewQueue()
+	orders := []*Order{
+		s.AddOrder(q, OrderTypeVIP),
+		s.AddOrder(q, OrderTypeNormal),
+		s.AddOrder(q, OrderTypeNormal),
+		s.AddOrder(q, OrderTypeVIP),
+	}
+	for _, o := range orders[:2] {
+		_ = o.Transition(OrderStatusProcessing, BotActor("A"))
+		_ = o.Transition(OrderStatusComplete, BotActor("A"))
+	}
+	_ = orders[2].Transition(OrderStatusCancelled, ActorUser)
+	return orders
+}
+
+func TestRepositoryQueries(t *testing.T) {
+	s := NewStore(nil, nil)
+	orders := fillStore(t, s)
+
+	cases := []struct {
+		name string
+		q    Query
+		want []int
+	}{
+		{"all", Query{}, []int{orders[0].ID, orders[1].ID, orders[2].ID, orders[3].ID}},
+		{"status", Query{Status: OrderStatusComplete}, []int{orders[0].ID, orders[1].ID}},
+		{"type", Query{Type: OrderTypeVIP}, []int{orders[0].ID, orders[3].ID}},
+		{"status and type", Query{Status: OrderStatusPending, Type: OrderTypeVIP}, []int{orders[3].ID}},
+		{"bot", Query{BotID: "A"}, []int{orders[0].ID, orders[1].ID}},
+		{"unknown bot", Query{BotID: "B"}, nil},
+		{"created from", Query{CreatedFrom: orders[2].CreatedAt}, []int{orders[2].ID, orders[3].ID}},
+		{"created to", Query{CreatedTo: orders[1].CreatedAt}, []int{orders[0].ID}},
+		{"page", Query{Offset: 1, Limit: 2}, []int{orders[1].ID, orders[2].ID}},
+		{"past the end", Query{Offset: 10}, nil},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			page := s.Query(c.q)
+			var got []int
+			for _, o := range page.Orders {
+				got = append(got, o.ID)
+			}
+			if len(got) != len(c.want) {
+				t.Fatalf("Expected %v, got %v", c.want, got)
+			}
+			for i := range got {
+				if got[i] != c.want[i] {
+					t.Fatalf("Expected %v, got %v", c.want, got)
+				}
+			}
+		})
+	}
+
+	if page := s.Query(Query{Limit: 1}); page.Total != 4 {
+		t.Errorf("Expected total 4 before pagination, got %d", page.Total)
+	}
+	repo := s.repo.(*MemoryRepository)
+	if n := repo.Count(Query{Status: OrderStatusComplete}); n != 2 {
+		t.Errorf("Expected 2 completed orders, got %d", n)
+	}
+}
+
+func TestRetentionEvictsFinishedOrders(t *testing.T) {
+	s := NewStore(nil, nil, WithRetention(time.Minute))
+	orders := fillStore(t, s)
+
+	if n := s.EvictExpired(time.Now()); n != 0 {
+		t.Fatalf("Expected nothing evicted within the retention period, got %d", n)
+	}
+	if n := s.EvictExpired(time.Now().Add(2 * time.Minute)); n != 3 {
+		t.Fatalf("Expected 3 finished orders evicted, got %d", n)
+	}
+
+	if s.GetOrder(orders[0].ID) != nil {
+		t.Error("Expected evicted order to be gone")
+	}
+	if s.GetOrder(orders[3].ID) == nil {
+		t.Error("Expected pending order to be kept")
+	}
+	if page := s.Query(Query{BotID: "A"}); page.Total != 0 {
+		t.Errorf("Expected bot index to be cleared, got %d orders", page.Total)
+	}
+	// Counters cover evicted orders too
+	if s.GetTotalCount() != 4 || s.GetCompletedCount() != 2 || s.GetCountByType(OrderTypeVIP) != 2 {
+		t.Errorf("Unexpected counts after eviction: total %d, completed %d, VIP %d",
+			s.GetTotalCount(), s.GetCompletedCount(), s.GetCountByType(OrderTypeVIP))
+	}
+}
+
+func TestFileRepositoryReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "orders.jsonl")
+
+	repo, err := OpenFileRepository(path)
+	if err != nil {
+		t.Fatalf("OpenFileRepository: %v", err)
+	}
+	orders := fillStore(t, NewStore(nil, nil, WithRepository(repo)))
+	repo.Evict(orders[0].Snapshot().CompletedAt.Add(time.Nanosecond))
+	if err := repo.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	reopened, err := OpenFileRepository(path)
+	if err != nil {
+		t.Fatalf("Reopen: %v", err)
+	}
+	defer reopened.Close()
+
+	if reopened.Get(orders[0].ID) != nil {
+		t.Error("Expected the eviction to be replayed")
+	}
+	got := reopened.Get(orders[1].ID)
+	if got == nil {
+		t.Fatal("Expected completed order to be restored")
+	}
+	snap := got.Snapshot()
+	if snap.Status != OrderStatusComplete || snap.Number != orders[1].Number || !snap.CompletedAt.Equal(orders[1].Snapshot().CompletedAt) {
+		t.Errorf("Unexpected restored order: %+v", snap)
+	}
+	if len(got.History()) != 3 {
+		t.Errorf("Expected 3 restored transitions, got %d", len(got.History()))
+	}
+	if page := reopened.Query(Query{BotID: "A"}); page.Total != 1 {
+		t.Errorf("Expected bot index to be rebuilt, got %d orders", page.Total)
+	}
+}
+
+func TestReplayFileOnlyReads(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "orders.jsonl")
+	repo, err := OpenFileRepository(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	orders := fillStore(t, NewStore(nil, nil, WithRepository(repo)))
+	if err := repo.Close(); err != nil {
+		t.Fatal(err)
+	}
+	before, _ := os.ReadFile(path)
+
+	mem, err := ReplayFile(path)
+	if err != nil {
+		t.Fatalf("ReplayFile: %v", err)
+	}
+	if got := mem.Get(orders[0].ID); got == nil || got.Status() != OrderStatusComplete {
+		t.Errorf("Expected the completed order replayed, got %v", got)
+	}
+	if after, _ := os.ReadFile(path); string(after) != string(before) {
+		t.Error("Expected the journal unchanged")
+	}
+
+	missing := filepath.Join(dir, "missing.jsonl")
+	if _, err := ReplayFile(missing); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected a missing journal to be reported, got %v", err)
+	}
+	if _, err := os.Stat(missing); !os.IsNotExist(err) {
+		t.Errorf("Expected no journal created, got %v", err)
+	}
+}
+
+func TestStoreResumesAfterReplayedJournal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "orders.jsonl")
+	repo, err := OpenFileRepository(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := NewQueue()
+	s := NewStore(nil, nil, WithRepository(repo))
+	first := s.AddOrder(q, OrderTypeNormal)
+	vip := s.AddOrder(q, OrderTypeVIP)
+	if err := repo.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := OpenFileRepository(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	s = NewStore(nil, nil, WithRepository(reopened))
+	if s.GetTotalCount() != 2 || s.GetCountByType(OrderTypeVIP) != 1 {
+		t.Errorf("Expected the restored orders counted, got %d total, %d VIP", s.GetTotalCount(), s.GetCountByType(OrderTypeVIP))
+	}
+
+	next := s.AddOrder(NewQueue(), OrderTypeNormal)
+	if next.ID != vip.ID+1 || next.Number != "1003" {
+		t.Errorf("Expected order 1003 after the restored ones, got %d (%s)", next.ID, next.Number)
+	}
+	if got := s.GetOrder(first.ID); got == nil || !got.CreatedAt.Equal(first.CreatedAt) {
+		t.Fatalf("Expected the restored order kept, got %v", got)
+	}
+
+	// Transitions of restored orders are journalled like any other.
+	if err := s.GetOrder(first.ID).Transition(OrderStatusCancelled, ActorUser); err != nil {
+		t.Fatal(err)
+	}
+	if page := s.Query(Query{Status: OrderStatusCancelled}); page.Total != 1 {
+		t.Errorf("Expected the cancellation indexed, got %d orders", page.Total)
+	}
+}
@@ -0,0 +1,45 @@
+package order
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrUnknownOrderType is returned for an order type missing from PriorityMap.
+	ErrUnknownOrderType = errors.New("unknown order type")
+	// ErrInvalidAffinity is returned for an affinity that cannot be honoured.
+	ErrInvalidAffinity = errors.New("invalid affinity")
+	// ErrInvalidItem is returned for an item without a name or quantity.
+	ErrInvalidItem = errors.New("invalid item")
+	// ErrInvalidContact is returned for a malformed phone number or webhook URL.
+	ErrInvalidContact = errors.New("invalid contact")
+)
+
+// OrderRequest describes one order to create, e.g. an entry of a catering batch.
+type OrderRequest struct {
+	Type OrderTypeEnum
+	// Affinity optionally ties the order to a bot or bot type.
+	Affinity Affinity
+	Items    []Item
+	// Contact optionally tells how to notify the customer.
+	Contact Contact
+}
+
+// Validate reports whether the order can be created as requested.
+func (r OrderRequest) Validate() error {
+	if _, ok := PriorityMap[r.Type]; !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownOrderType, r.Type)
+	}
+	a := r.Affinity
+	if a.Hard && a.IsZero() {
+		return fmt.Errorf("%w: hard affinity without a bot or bot type", ErrInvalidAffinity)
+	}
+	if a.FallbackAfter < 0 || (a.FallbackAfter > 0 && !a.Hard) {
+		return fmt.Errorf("%w: fallback %v requires a hard affinity", ErrInvalidAffinity, a.FallbackAfter)
+	}
+	if err := validateItems(r.Items); err != nil {
+		return err
+	}
+	return r.Contact.Validate()
+}
@@ -0,0 +1,206 @@
+package order
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/feedme/order-controller/internal/clock"
+)
+
+// Shelf is the pickup shelf where cooked orders wait for their customers. It
+// holds a bounded number of orders; orders arriving while it is full wait in
+// line for a slot. An order left on the shelf for too long is taken off and
+// passed to the expire function.
+type Shelf struct {
+	orders   map[int]*shelvedOrder
+	capacity int
+	// waiting lists the orders waiting for a slot, first come first served.
+	waiting []*shelfWaiter
+	// expireAfter is how long an order may wait; zero keeps orders until collected.
+	expireAfter time.Duration
+	expire      func(*Order)
+	clock       clock.Clock
+	mu          sync.Mutex
+}
+
+type shelvedOrder struct {
+	order    *Order
+	placedAt time.Time
+	timer    clock.Timer
+}
+
+type shelfWaiter struct {
+	order   *Order
+	onShelf func(*Order)
+	placed  chan struct{}
+}
+
+// ShelvedOrder describes an order waiting on the shelf.
+type ShelvedOrder struct {
+	Order    OrderSnapshot
+	PlacedAt time.Time
+	// ExpiresAt is zero if orders on the shelf do not expire.
+	ExpiresAt time.Time
+}
+
+// NewShelf returns an empty shelf with room for capacity orders. If
+// expireAfter is positive, expire is called, on a timer goroutine, for every
+// order still on the shelf that long after it was placed.
+func NewShelf(c clock.Clock, capacity int, expireAfter time.Duration, expire func(*Order)) *Shelf {
+	return &Shelf{
+		orders:      make(map[int]*shelvedOrder),
+		capacity:    capacity,
+		expireAfter: expireAfter,
+		expire:      expire,
+		clock:       c,
+	}
+}
+
+// Place puts o on the shelf and returns true. If the shelf is full, o joins
+// the line for a slot instead, and Place returns false and a channel that is
+// closed once o has been put on the shelf.
+// If onShelf is not nil, it is called with o as o is put on the shelf, before
+// o can be taken off again, e.g. to mark it READY. It is called with the shelf
+// locked and must not use the shelf.
+func (s *Shelf) Place(o *Order, onShelf func(*Order)) (bool, <-chan struct{}) {
+	s.mu.Lock()
+	if len(s.orders) >= s.capacity {
+		w := &shelfWaiter{order: o, onShelf: onShelf, placed: make(chan struct{})}
+		s.waiting = append(s.waiting, w)
+		s.mu.Unlock()
+		return false, w.placed
+	}
+	so := s.putLocked(o, onShelf)
+	s.mu.Unlock()
+
+	s.arm(so)
+	return true, nil
+}
+
+// Withdraw takes o out of the line for a slot, e.g. because the bot holding it
+// was removed. It returns false if o is not waiting, e.g. because it has just
+// been put on the shelf.
+func (s *Shelf) Withdraw(o *Order) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, w := range s.waiting {
+		if w.order == o {
+			s.waiting = append(s.waiting[:i], s.waiting[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// putLocked adds o to the shelf and calls onShelf, if set. The caller must
+// hold s.mu and pass the result to arm after releasing it.
+func (s *Shelf) putLocked(o *Order, onShelf func(*Order)) *shelvedOrder {
+	so := &shelvedOrder{order: o, placedAt: s.clock.Now()}
+	s.orders[o.ID] = so
+	if onShelf != nil {
+		onShelf(o)
+	}
+	return so
+}
+
+// arm starts the expiry timer of a newly placed order. It is called without
+// the lock held in case the timer fires at once.
+func (s *Shelf) arm(so *shelvedOrder) {
+	if s.expireAfter <= 0 {
+		return
+	}
+	id := so.order.ID
+	t := s.clock.AfterFunc(s.expireAfter, func() { s.fire(id) })
+	s.mu.Lock()
+	so.timer = t
+	s.mu.Unlock()
+}
+
+// fire expires the order with the given ID if it is still on the shelf.
+func (s *Shelf) fire(id int) {
+	s.take(id, s.expire)
+}
+
+// Take removes the order with the given ID from the shelf, e.g. because its
+// customer collected it, and calls fn with it before the slot goes to the
+// next order in line. It returns false if the order is not on the shelf.
+func (s *Shelf) Take(id int, fn func(*Order)) bool {
+	return s.take(id, fn)
+}
+
+// take removes an order, passes it to fn and then gives the slot to the order
+// that has waited longest, so fn's effects are seen before the slot is reused.
+func (s *Shelf) take(id int, fn func(*Order)) bool {
+	s.mu.Lock()
+	so, ok := s.orders[id]
+	delete(s.orders, id)
+	s.mu.Unlock()
+	if !ok {
+		return false
+	}
+
+	if so.timer != nil {
+		so.timer.Stop()
+	}
+	fn(so.order)
+
+	s.mu.Lock()
+	if len(s.waiting) == 0 || len(s.orders) >= s.capacity {
+		s.mu.Unlock()
+		return true
+	}
+	w := s.waiting[0]
+	s.waiting = s.waiting[1:]
+	next := s.putLocked(w.order, w.onShelf)
+	s.mu.Unlock()
+
+	s.arm(next)
+	close(w.placed)
+	return true
+}
+
+// List returns every order on the shelf, longest waiting first.
+func (s *Shelf) List() []ShelvedOrder {
+	s.mu.Lock()
+	list := make([]ShelvedOrder, 0, len(s.orders))
+	for _, so := range s.orders {
+		item := ShelvedOrder{Order: so.order.Snapshot(), PlacedAt: so.placedAt}
+		if s.expireAfter > 0 {
+			item.ExpiresAt = so.placedAt.Add(s.expireAfter)
+		}
+		list = append(list, item)
+	}
+	s.mu.Unlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].PlacedAt.Equal(list[j].PlacedAt) {
+			return list[i].PlacedAt.Before(list[j].PlacedAt)
+		}
+		return list[i].Order.ID < list[j].Order.ID
+	})
+	return list
+}
+
+// Len returns the number of orders on the shelf.
+func (s *Shelf) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.orders)
+}
+
+// Cap returns the number of orders the shelf can hold.
+func (s *Shelf) Cap() int {
+	return s.capacity
+}
+
+// Stop cancels every expiry timer. Orders stay on the shelf.
+func (s *Shelf) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, so := range s.orders {
+		if so.timer != nil {
+			so.timer.Stop()
+		}
+	}
+}
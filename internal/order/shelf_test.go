@@ -0,0 +1,128 @@
+package order
+
+import (
+	"testing"
+	"time"
+
+	"github.com/feedme/order-controller/internal/clock"
+)
+
+func TestShelfGivesFreedSlotToLongestWaiting(t *testing.T) {
+	v := clock.NewVirtual(time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC))
+	s := NewShelf(v, 1, 0, nil)
+
+	first := newOrderFor(1, OrderPriorityNormal, Affinity{})
+	if placed, _ := s.Place(first, nil); !placed {
+		t.Fatal("Expected an empty shelf to take the order")
+	}
+	placed, slot2 := s.Place(newOrderFor(2, OrderPriorityNormal, Affinity{}), nil)
+	if placed {
+		t.Fatal("Expected a full shelf to refuse the order")
+	}
+	_, slot3 := s.Place(newOrderFor(3, OrderPriorityNormal, Affinity{}), nil)
+
+	var taken *Order
+	if !s.Take(1, func(o *Order) { taken = o }) || taken != first {
+		t.Fatalf("Expected Take to pass on order 1, got %v", taken)
+	}
+	select {
+	case <-slot2:
+	default:
+		t.Fatal("Expected order 2, the first in line, to get the slot")
+	}
+	select {
+	case <-slot3:
+		t.Fatal("Expected order 3 to keep waiting")
+	default:
+	}
+	if list := s.List(); len(list) != 1 || list[0].Order.ID != 2 {
+		t.Fatalf("Expected order 2 on the shelf, got %+v", list)
+	}
+	if s.Take(1, func(*Order) {}) {
+		t.Error("Expected Take to fail for an order no longer on the shelf")
+	}
+}
+
+func TestShelfCollectsRightAfterPlace(t *testing.T) {
+	v := clock.NewVirtual(time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC))
+	s := NewShelf(v, 1, 0, nil)
+	cooked := func(id int) *Order {
+		o := NewOrder(id, OrderTypeNormal, ActorUser)
+		_ = o.Transition(OrderStatusProcessing, BotActor("A"))
+		_ = o.Transition(OrderStatusComplete, BotActor("A"))
+		return o
+	}
+	ready := func(o *Order) {
+		if err := o.Transition(OrderStatusReady, BotActor("A")); err != nil {
+			t.Errorf("Cannot mark order %d READY: %v", o.ID, err)
+		}
+	}
+	collect := func(o *Order) {
+		if err := o.Transition(OrderStatusCollected, ActorUser); err != nil {
+			t.Errorf("Cannot collect order %d: %v", o.ID, err)
+		}
+	}
+
+	first, second := cooked(1), cooked(2)
+	s.Place(first, ready)
+	_, slot := s.Place(second, ready)
+	if !s.Take(1, collect) {
+		t.Fatal("Expected order 1 on the shelf")
+	}
+	// Order 2 got the freed slot; it is READY before anyone can take it.
+	<-slot
+	if !s.Take(2, collect) {
+		t.Fatal("Expected order 2 on the shelf")
+	}
+	if first.Status() != OrderStatusCollected || second.Status() != OrderStatusCollected {
+		t.Errorf("Expected both orders COLLECTED, got %s and %s", first.Status(), second.Status())
+	}
+}
+
+func TestShelfWithdraw(t *testing.T) {
+	v := clock.NewVirtual(time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC))
+	s := NewShelf(v, 1, 0, nil)
+
+	s.Place(newOrderFor(1, OrderPriorityNormal, Affinity{}), nil)
+	waiting := newOrderFor(2, OrderPriorityNormal, Affinity{})
+	_, slot := s.Place(waiting, nil)
+	if !s.Withdraw(waiting) {
+		t.Fatal("Expected the waiting order to be withdrawn")
+	}
+	s.Take(1, func(*Order) {})
+	select {
+	case <-slot:
+		t.Fatal("Expected a withdrawn order not to get the slot")
+	default:
+	}
+	if s.Len() != 0 || s.Withdraw(waiting) {
+		t.Errorf("Expected an empty shelf and nothing waiting, got %d on the shelf", s.Len())
+	}
+}
+
+func TestShelfExpiresUncollectedOrders(t *testing.T) {
+	start := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	v := clock.NewVirtual(start)
+	expired := make(chan *Order, 1)
+	s := NewShelf(v, 2, 5*time.Minute, func(o *Order) { expired <- o })
+	defer s.Stop()
+
+	o := newOrderFor(1, OrderPriorityNormal, Affinity{})
+	s.Place(o, nil)
+	if list := s.List(); len(list) != 1 || !list[0].ExpiresAt.Equal(start.Add(5*time.Minute)) {
+		t.Fatalf("Expected the order to expire at 12:05, got %+v", list)
+	}
+
+	v.Advance(5 * time.Minute)
+	select {
+	case got := <-expired:
+		if got != o {
+			t.Fatalf("Expected order 1 to expire, got %d", got.ID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the order to expire after 5 minutes")
+	}
+	if s.Len() != 0 {
+		t.Errorf("Expected the expired order off the shelf, got %d", s.Len())
+	}
+}
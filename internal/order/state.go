@@ -0,0 +1,139 @@
+package order
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Actors recorded in the audit trail for transitions not made by a bot.
+const (
+	ActorUser   = "user"
+	ActorSystem = "system"
+)
+
+// BotActor returns the audit-trail actor name for the bot with the given ID.
+func BotActor(botID string) string {
+	return "bot:" + botID
+}
+
+// BotIDFromActor returns the bot ID of an actor created by BotActor. The second
+// result is false for any other actor.
+func BotIDFromActor(actor string) (string, bool) {
+	return strings.CutPrefix(actor, BotActor(""))
+}
+
+// ErrIllegalTransition is matched (via errors.Is) by every TransitionError.
+var ErrIllegalTransition = errors.New("illegal order status transition")
+
+// allowedTransitions lists, for every status, the statuses an order may move to.
+// A COMPLETE order moves on only if the restaurant has a pickup shelf: it is
+// READY on the shelf, then COLLECTED or, if left too long, EXPIRED. COLLECTED,
+// EXPIRED, CANCELLED and FAILED are terminal.
+var allowedTransitions = map[OrderStatusEnum][]OrderStatusEnum{
+	OrderStatusScheduled:  {OrderStatusPending, OrderStatusCancelled},
+	OrderStatusPending:    {OrderStatusProcessing, OrderStatusCancelled, OrderStatusFailed},
+	OrderStatusProcessing: {OrderStatusComplete, OrderStatusPending, OrderStatusCancelled, OrderStatusFailed},
+	OrderStatusComplete:   {OrderStatusReady, OrderStatusExpired},
+	OrderStatusReady:      {OrderStatusCollected, OrderStatusExpired},
+	OrderStatusCollected:  {},
+	OrderStatusExpired:    {},
+	OrderStatusCancelled:  {},
+	OrderStatusFailed:     {},
+}
+
+// StatusTransition is a single entry of an order's audit trail.
+type StatusTransition struct {
+	OrderID int
+	From    OrderStatusEnum
+	To      OrderStatusEnum
+	// Actor is who made the change: a bot (see BotActor), ActorUser or ActorSystem.
+	Actor string
+	At    time.Time
+}
+
+// TransitionError is returned when an order is asked to make a transition that
+// is not allowed from its current status.
+type TransitionError struct {
+	OrderID int
+	From    OrderStatusEnum
+	To      OrderStatusEnum
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("order %d: cannot transition from %s to %s", e.OrderID, e.From, e.To)
+}
+
+// Is makes errors.Is(err, ErrIllegalTransition) true for any TransitionError.
+func (e *TransitionError) Is(target error) bool {
+	return target == ErrIllegalTransition
+}
+
+// CanTransition reports whether an order may move from one status to another.
+func CanTransition(from, to OrderStatusEnum) bool {
+	for _, s := range allowedTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Status returns the order's current status.
+func (o *Order) Status() OrderStatusEnum {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.status
+}
+
+// Transition moves the order to a new status on behalf of actor and appends the
+// change to its audit trail. Entering PROCESSING stamps the processing start time
+// and entering COMPLETE stamps the completion time. Transitioning to the current status is a no-op;
+// illegal transitions return a *TransitionError and leave the order unchanged.
+func (o *Order) Transition(to OrderStatusEnum, actor string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	from := o.status
+	if from == to {
+		return nil
+	}
+	if !CanTransition(from, to) {
+		return &TransitionError{OrderID: o.ID, From: from, To: to}
+	}
+
+	now := time.Now()
+	if o.clock != nil {
+		now = o.clock.Now()
+	}
+	switch to {
+	case OrderStatusProcessing:
+		o.processedAt = now
+	case OrderStatusComplete:
+		o.completedAt = now
+		o.progress = 1
+	}
+	o.status = to
+	t := StatusTransition{
+		OrderID: o.ID,
+		From:    from,
+		To:      to,
+		Actor:   actor,
+		At:      now,
+	}
+	o.history = append(o.history, t)
+	if o.observer != nil {
+		o.observer(t)
+	}
+	return nil
+}
+
+// History returns a copy of the order's audit trail, oldest first.
+func (o *Order) History() []StatusTransition {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	history := make([]StatusTransition, len(o.history))
+	copy(history, o.history)
+	return history
+}
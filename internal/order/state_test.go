@@ -0,0 +1,95 @@
+package order
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestOrderLifecycle(t *testing.T) {
+	o := NewOrder(1, OrderTypeNormal, ActorUser)
+	if o.Status() != OrderStatusPending {
+		t.Fatalf("Expected new order to be PENDING, got %s", o.Status())
+	}
+
+	steps := []struct {
+		to    OrderStatusEnum
+		actor string
+	}{
+		{OrderStatusProcessing, BotActor("111")},
+		{OrderStatusPending, ActorSystem}, // preempted
+		{OrderStatusProcessing, BotActor("222")},
+		{OrderStatusComplete, BotActor("222")},
+	}
+	for _, s := range steps {
+		if err := o.Transition(s.to, s.actor); err != nil {
+			t.Fatalf("Transition to %s: %v", s.to, err)
+		}
+	}
+	if snap := o.Snapshot(); snap.ProcessedAt.IsZero() || snap.CompletedAt.IsZero() {
+		t.Error("Expected ProcessedAt and CompletedAt to be stamped")
+	}
+
+	history := o.History()
+	if len(history) != len(steps)+1 {
+		t.Fatalf("Expected %d audit entries, got %d", len(steps)+1, len(history))
+	}
+	if history[0].To != OrderStatusPending || history[0].Actor != ActorUser {
+		t.Errorf("Unexpected creation entry: %+v", history[0])
+	}
+	for i, s := range steps {
+		got := history[i+1]
+		if got.To != s.to || got.Actor != s.actor {
+			t.Errorf("Audit[%d]: expected %s by %s, got %s by %s", i+1, s.to, s.actor, got.To, got.Actor)
+		}
+	}
+}
+
+func TestOrderIllegalTransitions(t *testing.T) {
+	cases := []struct {
+		name string
+		path []OrderStatusEnum
+		to   OrderStatusEnum
+	}{
+		{"pending to complete", nil, OrderStatusComplete},
+		{"complete is terminal", []OrderStatusEnum{OrderStatusProcessing, OrderStatusComplete}, OrderStatusPending},
+		{"cancelled is terminal", []OrderStatusEnum{OrderStatusCancelled}, OrderStatusProcessing},
+		{"failed is terminal", []OrderStatusEnum{OrderStatusFailed}, OrderStatusPending},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			o := NewOrder(7, OrderTypeVIP, ActorUser)
+			for _, s := range c.path {
+				if err := o.Transition(s, ActorSystem); err != nil {
+					t.Fatal(err)
+				}
+			}
+			before := o.Status()
+
+			err := o.Transition(c.to, ActorSystem)
+			if !errors.Is(err, ErrIllegalTransition) {
+				t.Fatalf("Expected ErrIllegalTransition, got %v", err)
+			}
+			var te *TransitionError
+			if !errors.As(err, &te) || te.OrderID != 7 || te.From != before || te.To != c.to {
+				t.Errorf("Unexpected error details: %v", err)
+			}
+			if o.Status() != before {
+				t.Errorf("Expected status to stay %s, got %s", before, o.Status())
+			}
+		})
+	}
+}
+
+func TestStoreAuditTrail(t *testing.T) {
+	s := NewStore(nil, nil)
+	o := s.AddOrder(NewQueue(), OrderTypeNormal)
+	_ = o.Transition(OrderStatusCancelled, ActorUser)
+
+	trail, ok := s.AuditTrail(o.ID)
+	if !ok || len(trail) != 2 || trail[1].To != OrderStatusCancelled {
+		t.Errorf("Unexpected audit trail: %+v (found=%v)", trail, ok)
+	}
+	if _, ok := s.AuditTrail(9999); ok {
+		t.Error("Expected no audit trail for unknown order")
+	}
+}
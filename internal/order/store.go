@@ -0,0 +1,337 @@
+package order
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/feedme/order-controller/internal/clock"
+	"github.com/feedme/order-controller/internal/event"
+	"github.com/feedme/order-controller/internal/idgen"
+	"github.com/feedme/order-controller/internal/utils"
+)
+
+// DefaultFirstOrderID is the first order ID handed out by a Store using the
+// default ID generator.
+const DefaultFirstOrderID = 1001
+
+// Store holds every order created by a single restaurant together with the ID
+// generator used to number them. Each SystemManager owns its own Store, so several
+// restaurants can run side by side in one process without sharing state.
+type Store struct {
+	repo Repository
+	ids  idgen.OrderIDGenerator
+	// retention is how long finished orders are kept; zero keeps them forever.
+	retention time.Duration
+	// Counters cover every order ever created, including evicted ones.
+	// total and byType are guarded by mu.
+	total     int
+	byType    map[OrderTypeEnum]int
+	completed atomic.Int64
+	wasted    atomic.Int64
+	clock     clock.Clock
+	mu        sync.Mutex
+	// bus is the event bus used to publish order lifecycle events. It may be nil.
+	bus *event.EventBus
+}
+
+// StoreOption configures a Store at construction time.
+type StoreOption func(*Store)
+
+// WithRepository sets the repository orders are kept in. The default is a
+// MemoryRepository.
+func WithRepository(repo Repository) StoreOption {
+	return func(s *Store) {
+		s.repo = repo
+	}
+}
+
+// WithRetention evicts finished orders once they have been finished for
+// longer than maxAge. Eviction happens when EvictExpired is called.
+func WithRetention(maxAge time.Duration) StoreOption {
+	return func(s *Store) {
+		s.retention = maxAge
+	}
+}
+
+// WithStoreClock sets the clock that stamps order creation and transitions.
+// The default is clock.Real.
+func WithStoreClock(c clock.Clock) StoreOption {
+	return func(s *Store) {
+		s.clock = c
+	}
+}
+
+// NewStore initializes and returns an empty Store publishing to the given bus.
+// If ids is nil, orders are numbered from DefaultFirstOrderID without a prefix,
+// restarting every day on the store's clock.
+func NewStore(bus *event.EventBus, ids idgen.OrderIDGenerator, opts ...StoreOption) *Store {
+	s := &Store{
+		ids:    ids,
+		byType: make(map[OrderTypeEnum]int),
+		clock:  clock.Real,
+		bus:    bus,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.ids == nil {
+		// Numbers restart daily on the store's clock.
+		s.ids = idgen.NewDailyOrderIDs("", DefaultFirstOrderID, nil, s.clock.Now)
+	}
+	if s.repo == nil {
+		s.repo = NewMemoryRepository()
+	}
+	s.restore()
+	return s
+}
+
+// restore takes over the orders already in the repository, e.g. replayed from
+// an order journal: it counts them, wires them to the store like new orders,
+// and resumes the ID generator after them so no ID is handed out twice.
+// Orders evicted before the restart are not counted.
+func (s *Store) restore() {
+	page := s.repo.Query(Query{})
+	if page.Total == 0 {
+		return
+	}
+	day := s.clock.Now().Format("2006-01-02")
+	lastID, numberedToday := 0, 0
+	for _, snap := range page.Orders {
+		o := s.repo.Get(snap.ID)
+		o.observer = s.record
+		o.clock = s.clock
+
+		s.total++
+		s.byType[snap.Type]++
+		if !snap.CompletedAt.IsZero() {
+			s.completed.Add(1)
+		}
+		if snap.Status == OrderStatusExpired {
+			s.wasted.Add(1)
+		}
+		lastID = max(lastID, snap.ID)
+		if snap.CreatedAt.Format("2006-01-02") == day {
+			numberedToday++
+		}
+	}
+	if ids, ok := s.ids.(idgen.ResumableOrderIDGenerator); ok {
+		ids.Resume(lastID, numberedToday)
+	}
+}
+
+// AddOrder creates a new order with a unique ID and correct priority, publishes an
+// OrderCreated event to the store's bus, and adds the order to the queue.
+func (s *Store) AddOrder(q *Queue, orderType OrderTypeEnum) *Order {
+	return s.AddOrderWithAffinity(q, orderType, Affinity{})
+}
+
+// AddOrderWithAffinity is like AddOrder but ties the order to a bot or bot type.
+func (s *Store) AddOrderWithAffinity(q *Queue, orderType OrderTypeEnum, affinity Affinity) *Order {
+	s.mu.Lock()
+	orderID, number := s.ids.NextOrderID()
+	newOrder := s.createLocked(orderID, number, OrderRequest{Type: orderType, Affinity: affinity}, time.Time{})
+	s.mu.Unlock()
+
+	// Publish before queueing so OrderCreated always precedes OrderAssigned.
+	s.announce(newOrder)
+	q.Push(newOrder)
+
+	return newOrder
+}
+
+// AddScheduledOrder creates a pre-order for pickup at notBefore. The order is
+// SCHEDULED and is not queued; the caller holds it until it is due and then
+// moves it to PENDING.
+func (s *Store) AddScheduledOrder(orderType OrderTypeEnum, notBefore time.Time) *Order {
+	s.mu.Lock()
+	orderID, number := s.ids.NextOrderID()
+	newOrder := s.createLocked(orderID, number, OrderRequest{Type: orderType}, notBefore)
+	s.mu.Unlock()
+
+	s.announce(newOrder)
+	return newOrder
+}
+
+// AddOrders creates one order per request and adds them all to the queue at
+// once. The orders get consecutive IDs if the store's ID generator implements
+// idgen.BatchOrderIDGenerator. Requests are not validated; see
+// OrderRequest.Validate.
+func (s *Store) AddOrders(q *Queue, reqs []OrderRequest) []*Order {
+	orders := make([]*Order, len(reqs))
+	s.mu.Lock()
+	ids := make([]int, len(reqs))
+	numbers := make([]string, len(reqs))
+	if batch, ok := s.ids.(idgen.BatchOrderIDGenerator); ok {
+		var first int
+		first, numbers = batch.NextOrderIDs(len(reqs))
+		for i := range ids {
+			ids[i] = first + i
+		}
+	} else {
+		for i := range ids {
+			ids[i], numbers[i] = s.ids.NextOrderID()
+		}
+	}
+	for i, req := range reqs {
+		orders[i] = s.createLocked(ids[i], numbers[i], req, time.Time{})
+	}
+	s.mu.Unlock()
+
+	for _, o := range orders {
+		s.announce(o)
+	}
+	q.PushBatch(orders)
+	return orders
+}
+
+// ModifyOrder changes the type or items of an order waiting in q and updates
+// the counters and repository to match. The changes must be valid; see
+// OrderChanges.Validate. The second result is false if the order is not
+// queued.
+func (s *Store) ModifyOrder(q *Queue, id int, c OrderChanges) (OrderDiff, bool) {
+	d, ok := q.Modify(id, c)
+	if !ok || !d.Changed() {
+		return d, ok
+	}
+	if d.TypeChanged() {
+		s.mu.Lock()
+		s.byType[d.From.Type]--
+		s.byType[d.To.Type]++
+		s.mu.Unlock()
+	}
+	s.repo.Modify(d)
+	return d, true
+}
+
+// createLocked builds an order, stores it and counts it. A non-zero notBefore
+// makes it a SCHEDULED pre-order. The caller must hold s.mu.
+//
+// It panics if the repository already holds an order with the same ID, since
+// the ID generator is then reissuing IDs and the new order would shadow the
+// stored one. Generators implementing idgen.ResumableOrderIDGenerator are
+// resumed past restored orders, so this only happens with custom generators.
+func (s *Store) createLocked(id int, number string, req OrderRequest, notBefore time.Time) *Order {
+	o := newOrderAt(id, req.Type, ActorUser, s.clock.Now())
+	o.Number = number
+	o.Affinity = req.Affinity
+	o.Contact = req.Contact
+	o.items = slices.Clone(req.Items)
+	if !notBefore.IsZero() {
+		o.scheduleFor(notBefore)
+	}
+	o.observer = s.record
+	o.clock = s.clock
+
+	if err := s.repo.Add(o); errors.Is(err, ErrDuplicateOrder) {
+		panic(fmt.Sprintf("order: ID generator reissued order ID %d", o.ID))
+	} else if err != nil {
+		utils.LogError("Cannot store Order •%d: %v", o.ID, err)
+	}
+	s.total++
+	s.byType[req.Type]++
+	return o
+}
+
+// announce logs a new order and publishes an OrderCreated event for it.
+func (s *Store) announce(o *Order) {
+	utils.Log("Order •%d (Priority: %d - %s) Created - Status: %s", o.ID, o.Priority, o.Type, o.Status())
+	if s.bus != nil {
+		s.bus.Publish(event.Event{
+			Type: event.OrderCreated,
+			Data: o.Snapshot(),
+		})
+	}
+}
+
+// record keeps the counters and repository indexes in step with an order's
+// status. It runs with the order's lock held.
+func (s *Store) record(t StatusTransition) {
+	switch t.To {
+	case OrderStatusComplete:
+		s.completed.Add(1)
+	case OrderStatusExpired:
+		s.wasted.Add(1)
+	}
+	s.repo.Record(t)
+}
+
+// RecordWorker keeps a worker status change in the repository, if it is a
+// WorkerJournal.
+func (s *Store) RecordWorker(t WorkerTransition) {
+	if j, ok := s.repo.(WorkerJournal); ok {
+		j.RecordWorker(t)
+	}
+}
+
+// WorkerHistory returns the worker status changes kept in the repository,
+// oldest first, or nil if it is not a WorkerJournal.
+func (s *Store) WorkerHistory() []WorkerTransition {
+	if j, ok := s.repo.(WorkerJournal); ok {
+		return j.WorkerHistory()
+	}
+	return nil
+}
+
+// GetTotalCount returns the total number of orders created.
+func (s *Store) GetTotalCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.total
+}
+
+// GetCountByType returns the number of orders of a specific type.
+func (s *Store) GetCountByType(orderType OrderTypeEnum) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.byType[orderType]
+}
+
+// GetCompletedCount returns the number of orders with StatusComplete.
+func (s *Store) GetCompletedCount() int {
+	return int(s.completed.Load())
+}
+
+// GetWastedCount returns the number of cooked orders that expired before
+// they were collected.
+func (s *Store) GetWastedCount() int {
+	return int(s.wasted.Load())
+}
+
+// GetOrder retrieves an order by its ID. It returns nil for unknown and
+// evicted orders.
+func (s *Store) GetOrder(id int) *Order {
+	return s.repo.Get(id)
+}
+
+// Query returns one page of the orders matching q, oldest first.
+func (s *Store) Query(q Query) Page {
+	return s.repo.Query(q)
+}
+
+// Count returns how many stored orders match q, ignoring pagination.
+func (s *Store) Count(q Query) int {
+	return s.repo.Count(q)
+}
+
+// EvictExpired removes finished orders older than the retention period and
+// returns how many were removed. It does nothing without WithRetention.
+func (s *Store) EvictExpired(now time.Time) int {
+	if s.retention <= 0 {
+		return 0
+	}
+	return s.repo.Evict(now.Add(-s.retention))
+}
+
+// AuditTrail returns the status transitions of the order with the given ID,
+// oldest first. The second result is false if no such order exists.
+func (s *Store) AuditTrail(id int) ([]StatusTransition, bool) {
+	o := s.GetOrder(id)
+	if o == nil {
+		return nil, false
+	}
+	return o.History(), true
+}
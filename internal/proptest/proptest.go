@@ -0,0 +1,50 @@
+// Package proptest runs randomized property checks over operation sequences
+// and shrinks failing sequences to a minimal reproduction. It is used by the
+// tests of the order and manager packages.
+package proptest
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// Shrink returns a subsequence of ops for which fails still reports true,
+// found by repeatedly removing chunks of halving size down to single
+// operations. ops itself must fail.
+func Shrink[T any](ops []T, fails func([]T) bool) []T {
+	for n := len(ops) / 2; n >= 1; n /= 2 {
+		for i := 0; i+n <= len(ops); {
+			candidate := make([]T, 0, len(ops)-n)
+			candidate = append(candidate, ops[:i]...)
+			candidate = append(candidate, ops[i+n:]...)
+			if fails(candidate) {
+				ops = candidate
+			} else {
+				i += n
+			}
+		}
+	}
+	return ops
+}
+
+// Check generates runs random sequences with gen, seeded 0..runs-1, and
+// checks prop on each. The first failing sequence is shrunk and reported
+// together with its seed.
+func Check[T any](t testing.TB, runs int, gen func(*rand.Rand) []T, prop func([]T) error) {
+	t.Helper()
+	for seed := int64(0); seed < int64(runs); seed++ {
+		ops := gen(rand.New(rand.NewSource(seed)))
+		if err := prop(ops); err != nil {
+			Fail(t, ops, prop, "seed %d", seed)
+		}
+	}
+}
+
+// Fail shrinks a failing sequence and fails the test with the minimal
+// sequence and its error. The format arguments describe where ops came from.
+func Fail[T any](t testing.TB, ops []T, prop func([]T) error, format string, args ...any) {
+	t.Helper()
+	minimal := Shrink(ops, func(c []T) bool { return prop(c) != nil })
+	t.Fatalf("property failed for "+format+"\nminimal sequence (%d of %d ops): %v\nerror: %v",
+		append(args, len(minimal), len(ops), minimal, prop(minimal))...)
+}
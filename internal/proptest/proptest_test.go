@@ -0,0 +1,18 @@
+package proptest
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestShrink(t *testing.T) {
+	// Fails whenever both 3 and 7 are present, in that order
+	fails := func(ops []int) bool {
+		i := slices.Index(ops, 3)
+		return i >= 0 && slices.Contains(ops[i:], 7)
+	}
+	got := Shrink([]int{1, 3, 4, 5, 9, 7, 2, 3}, fails)
+	if !slices.Equal(got, []int{3, 7}) {
+		t.Errorf("Expected [3 7], got %v", got)
+	}
+}
@@ -0,0 +1,150 @@
+// Package region hosts many independent restaurants in a single process.
+// Each store is backed by its own SystemManager (queue, bot pool, order store
+// and event bus) and is addressed by a store ID.
+package region
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/feedme/order-controller/internal/idgen"
+	"github.com/feedme/order-controller/internal/manager"
+	"github.com/feedme/order-controller/internal/order"
+)
+
+var (
+	// ErrStoreExists is returned when adding a store whose ID is already registered.
+	ErrStoreExists = errors.New("store already exists")
+	// ErrStoreNotFound is returned when a store ID is not registered.
+	ErrStoreNotFound = errors.New("store not found")
+	// ErrEmptyStoreID is returned when adding a store without an ID.
+	ErrEmptyStoreID = errors.New("store ID must not be empty")
+)
+
+// Region is a thread-safe registry of restaurants keyed by store ID.
+type Region struct {
+	stores map[string]*manager.SystemManager
+	// orderIDs is shared by every store so internal order IDs stay unique
+	// across the region.
+	orderIDs *idgen.Sequence
+	mu       sync.RWMutex
+}
+
+// Summary aggregates the statistics of every store in the region.
+type Summary struct {
+	// Stores holds the per-store summaries sorted by store ID.
+	Stores []manager.Summary
+	// Totals is the sum of all store summaries. Its StoreID is empty.
+	Totals manager.Summary
+}
+
+// New initializes and returns an empty Region.
+func New() *Region {
+	return &Region{
+		stores:   make(map[string]*manager.SystemManager),
+		orderIDs: idgen.NewSequence(order.DefaultFirstOrderID),
+	}
+}
+
+// AddStore creates a new SystemManager for the given store ID and registers it.
+// Orders are numbered "<store ID>-<n>" with n restarting daily, while internal
+// order IDs are drawn from a sequence shared by the whole region. Additional
+// options are applied afterwards and may override these defaults.
+func (r *Region) AddStore(id string, opts ...manager.Option) (*manager.SystemManager, error) {
+	if id == "" {
+		return nil, ErrEmptyStoreID
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.stores[id]; ok {
+		return nil, ErrStoreExists
+	}
+
+	opts = append([]manager.Option{
+		manager.WithStoreID(id),
+		manager.WithDailyOrderNumbers(id, r.orderIDs),
+	}, opts...)
+	m := manager.NewSystemManager(opts...)
+	r.stores[id] = m
+	return m, nil
+}
+
+// Store returns the SystemManager registered under the given ID.
+func (r *Region) Store(id string) (*manager.SystemManager, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	m, ok := r.stores[id]
+	return m, ok
+}
+
+// RemoveStore unregisters a store and stops all of its bots.
+func (r *Region) RemoveStore(id string) error {
+	r.mu.Lock()
+	m, ok := r.stores[id]
+	delete(r.stores, id)
+	r.mu.Unlock()
+
+	if !ok {
+		return ErrStoreNotFound
+	}
+	m.Stop()
+	return nil
+}
+
+// ListStores returns the IDs of all registered stores in ascending order.
+func (r *Region) ListStores() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids := make([]string, 0, len(r.stores))
+	for id := range r.stores {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Summary collects the summary of every store and their regional totals.
+func (r *Region) Summary() Summary {
+	var s Summary
+	for _, id := range r.ListStores() {
+		m, ok := r.Store(id)
+		if !ok {
+			// Removed between listing and lookup
+			continue
+		}
+		ss := m.Summary()
+		s.Stores = append(s.Stores, ss)
+
+		s.Totals.TotalOrders += ss.TotalOrders
+		s.Totals.VIPOrders += ss.VIPOrders
+		s.Totals.NormalOrders += ss.NormalOrders
+		s.Totals.CompletedOrders += ss.CompletedOrders
+		s.Totals.ActiveBots += ss.ActiveBots
+		s.Totals.PausedBots += ss.PausedBots
+		s.Totals.MaintenanceBots += ss.MaintenanceBots
+		s.Totals.StalledBots += ss.StalledBots
+		s.Totals.PendingOrders += ss.PendingOrders
+		s.Totals.ScheduledOrders += ss.ScheduledOrders
+		s.Totals.ReadyOrders += ss.ReadyOrders
+		s.Totals.WastedOrders += ss.WastedOrders
+	}
+	return s
+}
+
+// Stop shuts down every registered store.
+func (r *Region) Stop() {
+	r.mu.RLock()
+	stores := make([]*manager.SystemManager, 0, len(r.stores))
+	for _, m := range r.stores {
+		stores = append(stores, m)
+	}
+	r.mu.RUnlock()
+
+	for _, m := range stores {
+		m.Stop()
+	}
+}
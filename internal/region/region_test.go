@@ -0,0 +1,106 @@
+package region
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/feedme/order-controller/internal/clock"
+	"github.com/feedme/order-controller/internal/manager"
+	"github.com/feedme/order-controller/internal/order"
+)
+
+func TestRegionStores(t *testing.T) {
+	r := New()
+	defer r.Stop()
+
+	if _, err := r.AddStore("KL01"); err != nil {
+		t.Fatalf("AddStore KL01: %v", err)
+	}
+	if _, err := r.AddStore("PJ02"); err != nil {
+		t.Fatalf("AddStore PJ02: %v", err)
+	}
+	if _, err := r.AddStore("KL01"); !errors.Is(err, ErrStoreExists) {
+		t.Errorf("Expected ErrStoreExists, got %v", err)
+	}
+	if _, err := r.AddStore(""); !errors.Is(err, ErrEmptyStoreID) {
+		t.Errorf("Expected ErrEmptyStoreID, got %v", err)
+	}
+
+	ids := r.ListStores()
+	if len(ids) != 2 || ids[0] != "KL01" || ids[1] != "PJ02" {
+		t.Errorf("Expected [KL01 PJ02], got %v", ids)
+	}
+
+	if err := r.RemoveStore("PJ02"); err != nil {
+		t.Errorf("RemoveStore: %v", err)
+	}
+	if err := r.RemoveStore("PJ02"); !errors.Is(err, ErrStoreNotFound) {
+		t.Errorf("Expected ErrStoreNotFound, got %v", err)
+	}
+}
+
+func TestRegionStoresAreIsolated(t *testing.T) {
+	r := New()
+	defer r.Stop()
+
+	kl, _ := r.AddStore("KL01")
+	pj, _ := r.AddStore("PJ02")
+
+	kl.AddOrder(order.OrderTypeNormal)
+	kl.AddOrder(order.OrderTypeVIP)
+	pj.AddOrder(order.OrderTypeNormal)
+
+	if kl.EventBus == pj.EventBus {
+		t.Error("Expected each store to own its event bus")
+	}
+
+	// Internal IDs are unique across the region...
+	if kl.Orders.GetOrder(1001) == nil || kl.Orders.GetOrder(1002) == nil {
+		t.Error("Expected KL01 to hold orders 1001 and 1002")
+	}
+	pjOrder := pj.Orders.GetOrder(1003)
+	if pjOrder == nil {
+		t.Fatal("Expected PJ02 to hold order 1003")
+	}
+	if pj.Orders.GetOrder(1001) != nil {
+		t.Error("Expected PJ02 not to see KL01's order 1001")
+	}
+
+	// ...while customer-facing numbers are per store
+	if got := kl.Orders.GetOrder(1001).Number; got != "KL01-1001" {
+		t.Errorf("Expected KL01 number KL01-1001, got %s", got)
+	}
+	if pjOrder.Number != "PJ02-1001" {
+		t.Errorf("Expected PJ02 number PJ02-1001, got %s", pjOrder.Number)
+	}
+
+	s := r.Summary()
+	if len(s.Stores) != 2 {
+		t.Fatalf("Expected 2 store summaries, got %d", len(s.Stores))
+	}
+	if s.Stores[0].StoreID != "KL01" || s.Stores[0].TotalOrders != 2 {
+		t.Errorf("Unexpected KL01 summary: %+v", s.Stores[0])
+	}
+	if s.Stores[1].StoreID != "PJ02" || s.Stores[1].TotalOrders != 1 {
+		t.Errorf("Unexpected PJ02 summary: %+v", s.Stores[1])
+	}
+	if s.Totals.TotalOrders != 3 || s.Totals.VIPOrders != 1 || s.Totals.NormalOrders != 2 {
+		t.Errorf("Unexpected regional totals: %+v", s.Totals)
+	}
+}
+
+func TestRegionNumbersRestartOnTheStoreClock(t *testing.T) {
+	r := New()
+	defer r.Stop()
+	v := clock.NewVirtual(time.Date(2026, 1, 1, 23, 59, 0, 0, time.UTC))
+	kl, _ := r.AddStore("KL01", manager.WithClock(v))
+
+	kl.AddOrder(order.OrderTypeNormal)
+	v.Advance(2 * time.Minute)
+	kl.AddOrder(order.OrderTypeNormal)
+
+	if got := kl.Orders.GetOrder(1002).Number; got != "KL01-1001" {
+		t.Errorf("Expected the number to restart on the next virtual day, got %s", got)
+	}
+}
@@ -0,0 +1,133 @@
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Output formats supported by Write.
+const (
+	FormatText = "text"
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// ErrUnknownFormat is returned by Write for an unsupported format.
+var ErrUnknownFormat = errors.New("unknown report format")
+
+// Write renders r to w in the given format.
+func (r Report) Write(w io.Writer, format string) error {
+	switch format {
+	case FormatText:
+		return r.WriteText(w)
+	case FormatCSV:
+		return r.WriteCSV(w)
+	case FormatJSON:
+		return r.WriteJSON(w)
+	}
+	return fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+}
+
+// WriteText renders r as a human-readable report.
+func (r Report) WriteText(w io.Writer) error {
+	p := &printer{w: w}
+	p.printf("End-of-Day Report (%s - %s)\n", r.From.Format(time.DateTime), r.To.Format(time.DateTime))
+
+	p.printf("\nOrders:\n")
+	for _, t := range r.Types {
+		p.printf("- %s: %d created, %d completed, %d cancelled, %d failed, %d pending\n",
+			t.Type, t.Created, t.Completed, t.Cancelled, t.Failed, t.Pending)
+	}
+	p.printf("- Requeued: %d\n", r.Requeues)
+	p.printf("- SLA misses (> %v): %d\n", r.SLA, r.SLAMisses)
+
+	p.printf("\nTimes (p50 / p90 / p99):\n")
+	p.printf("- Wait: %v / %v / %v (%d orders)\n", round(r.Wait.P50), round(r.Wait.P90), round(r.Wait.P99), r.Wait.Count)
+	p.printf("- Cook: %v / %v / %v (%d orders)\n", round(r.Cook.P50), round(r.Cook.P90), round(r.Cook.P99), r.Cook.Count)
+
+	p.printf("\nBots:\n")
+	for _, b := range r.Bots {
+		p.printf("- Bot #%s: %d completed, busy %v (%.0f%% utilisation)\n",
+			b.BotID, b.Completed, round(b.Busy), b.Utilisation*100)
+	}
+
+	p.printf("\nHourly Throughput:\n")
+	for _, h := range r.Hourly {
+		p.printf("- %s: %d completed\n", h.Hour.Format("2006-01-02 15:00"), h.Completed)
+	}
+	return p.err
+}
+
+// WriteCSV renders r as metric,dimension,value rows, suitable for spreadsheets.
+// Durations are in seconds.
+func (r Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	row := func(metric, dimension string, value any) {
+		_ = cw.Write([]string{metric, dimension, fmt.Sprint(value)})
+	}
+	seconds := func(d time.Duration) string {
+		return strconv.FormatFloat(d.Seconds(), 'f', 3, 64)
+	}
+
+	row("metric", "dimension", "value")
+	row("from", "", r.From.Format(time.RFC3339))
+	row("to", "", r.To.Format(time.RFC3339))
+	for _, t := range r.Types {
+		row("created", string(t.Type), t.Created)
+		row("completed", string(t.Type), t.Completed)
+		row("cancelled", string(t.Type), t.Cancelled)
+		row("failed", string(t.Type), t.Failed)
+		row("pending", string(t.Type), t.Pending)
+	}
+	for _, pc := range []struct {
+		name string
+		p    Percentiles
+	}{{"wait", r.Wait}, {"cook", r.Cook}} {
+		row(pc.name+"_seconds", "p50", seconds(pc.p.P50))
+		row(pc.name+"_seconds", "p90", seconds(pc.p.P90))
+		row(pc.name+"_seconds", "p99", seconds(pc.p.P99))
+	}
+	for _, b := range r.Bots {
+		row("bot_completed", b.BotID, b.Completed)
+		row("bot_busy_seconds", b.BotID, seconds(b.Busy))
+		row("bot_utilisation", b.BotID, strconv.FormatFloat(b.Utilisation, 'f', 4, 64))
+	}
+	row("requeues", "", r.Requeues)
+	for _, h := range r.Hourly {
+		row("hourly_completed", h.Hour.Format(time.RFC3339), h.Completed)
+	}
+	row("sla_seconds", "", seconds(r.SLA))
+	row("sla_misses", "", r.SLAMisses)
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSON renders r as an indented JSON document. Durations are in nanoseconds.
+func (r Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// printer writes formatted text, remembering the first error.
+type printer struct {
+	w   io.Writer
+	err error
+}
+
+func (p *printer) printf(format string, args ...any) {
+	if p.err == nil {
+		_, p.err = fmt.Fprintf(p.w, format, args...)
+	}
+}
+
+// round trims durations to a readable precision for the text report.
+func round(d time.Duration) time.Duration {
+	return d.Round(10 * time.Millisecond)
+}
@@ -0,0 +1,309 @@
+// Package report builds the end-of-day report of a restaurant from the audit
+// trail of its orders: per-type counts, wait and cook time percentiles, bot
+// utilisation, requeues, hourly throughput and SLA misses.
+package report
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/feedme/order-controller/internal/bot"
+	"github.com/feedme/order-controller/internal/order"
+)
+
+// DefaultSLA is the longest an order may take from creation to completion
+// before it counts as an SLA miss. Pre-orders are timed from their release
+// into the queue.
+const DefaultSLA = 2 * time.Minute
+
+// Report is the end-of-day summary of a run.
+type Report struct {
+	// From and To span the run: the first order's creation and the last transition.
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+
+	Types []TypeStats `json:"types"`
+	// Wait is the time from creation until a bot first picked the order up.
+	Wait Percentiles `json:"wait"`
+	// Cook is the total time completed orders spent being cooked, leaving out
+	// time the cooking bot was paused.
+	Cook Percentiles `json:"cook"`
+	Bots []BotStats  `json:"bots"`
+	// Requeues counts how often an order was interrupted and returned to the queue.
+	Requeues int         `json:"requeues"`
+	Hourly   []HourStats `json:"hourly"`
+
+	SLA time.Duration `json:"sla"`
+	// SLAMisses counts completed orders that took longer than SLA, plus
+	// unfinished orders already older than SLA at the end of the run.
+	SLAMisses int `json:"sla_misses"`
+}
+
+// TypeStats counts the orders of one type by outcome.
+type TypeStats struct {
+	Type      order.OrderTypeEnum `json:"type"`
+	Created   int                 `json:"created"`
+	Completed int                 `json:"completed"`
+	Cancelled int                 `json:"cancelled"`
+	Failed    int                 `json:"failed"`
+	Pending   int                 `json:"pending"`
+}
+
+// Percentiles summarises a distribution of durations.
+type Percentiles struct {
+	Count int           `json:"count"`
+	P50   time.Duration `json:"p50"`
+	P90   time.Duration `json:"p90"`
+	P99   time.Duration `json:"p99"`
+}
+
+// BotStats describes the work done by one bot.
+type BotStats struct {
+	BotID     string `json:"bot_id"`
+	Completed int    `json:"completed"`
+	// Busy is the time the bot spent cooking; time it was PAUSED or in
+	// MAINTENANCE while holding an order does not count.
+	Busy time.Duration `json:"busy"`
+	// Utilisation is Busy as a fraction of the run's length.
+	Utilisation float64 `json:"utilisation"`
+}
+
+// HourStats counts the orders completed during one hour.
+type HourStats struct {
+	Hour      time.Time `json:"hour"`
+	Completed int       `json:"completed"`
+}
+
+// Build computes the report for the given orders. Each order's History must be
+// its complete audit trail; workers are the bots' status changes over the same
+// period, used to leave out the time a bot holding an order was paused. sla of
+// zero uses DefaultSLA.
+func Build(orders []*order.Order, workers []order.WorkerTransition, sla time.Duration) Report {
+	if sla <= 0 {
+		sla = DefaultSLA
+	}
+	r := Report{SLA: sla}
+
+	// The run ends at the last recorded transition.
+	histories := make([][]order.StatusTransition, len(orders))
+	for i, o := range orders {
+		histories[i] = o.History()
+		if r.From.IsZero() || o.CreatedAt.Before(r.From) {
+			r.From = o.CreatedAt
+		}
+		for _, t := range histories[i] {
+			if t.At.After(r.To) {
+				r.To = t.At
+			}
+		}
+	}
+
+	paused := suspensions(workers, r.To)
+	types := make(map[order.OrderTypeEnum]*TypeStats)
+	bots := make(map[string]*BotStats)
+	hours := make(map[time.Time]int)
+	var waits, cooks []time.Duration
+
+	for i, o := range orders {
+		history := histories[i]
+		ts, ok := types[o.Type]
+		if !ok {
+			ts = &TypeStats{Type: o.Type}
+			types[o.Type] = ts
+		}
+		ts.Created++
+
+		var (
+			cook     time.Duration
+			pickedUp bool
+			status   = order.OrderStatusPending
+			finished time.Time
+			// queued is when the order became eligible for cooking; for a
+			// pre-order that is its release from the holding area.
+			queued = o.CreatedAt
+		)
+		for j, t := range history {
+			status = t.To
+			if t.From == order.OrderStatusProcessing && t.To == order.OrderStatusPending {
+				r.Requeues++
+			}
+			if t.From == order.OrderStatusScheduled && t.To == order.OrderStatusPending {
+				queued = t.At
+			}
+			if t.To == order.OrderStatusComplete {
+				// Later pickup-shelf transitions do not move the completion time.
+				finished = t.At
+			}
+			if t.To != order.OrderStatusProcessing {
+				continue
+			}
+			if !pickedUp {
+				pickedUp = true
+				waits = append(waits, t.At.Sub(queued))
+			}
+
+			// The cooking interval ends at the next transition, or at the end
+			// of the run, and stops while the bot is paused.
+			end := r.To
+			if j+1 < len(history) {
+				end = history[j+1].At
+			}
+			botID, byBot := order.BotIDFromActor(t.Actor)
+			cooked := end.Sub(t.At)
+			if byBot {
+				cooked -= overlap(paused[botID], t.At, end)
+			}
+			cook += cooked
+			if byBot {
+				bs := botStats(bots, botID)
+				bs.Busy += cooked
+				if j+1 < len(history) && history[j+1].To == order.OrderStatusComplete {
+					bs.Completed++
+				}
+			}
+		}
+		switch status {
+		case order.OrderStatusComplete, order.OrderStatusReady, order.OrderStatusCollected, order.OrderStatusExpired:
+			// Orders that went on to the pickup shelf were completed too.
+			ts.Completed++
+			cooks = append(cooks, cook)
+			hours[finished.Truncate(time.Hour)]++
+			if finished.Sub(queued) > sla {
+				r.SLAMisses++
+			}
+		case order.OrderStatusCancelled:
+			ts.Cancelled++
+		case order.OrderStatusFailed:
+			ts.Failed++
+		case order.OrderStatusScheduled:
+			// Not due yet, so it cannot be late.
+			ts.Pending++
+		default:
+			ts.Pending++
+			if r.To.Sub(queued) > sla {
+				r.SLAMisses++
+			}
+		}
+	}
+
+	r.Wait = percentiles(waits)
+	r.Cook = percentiles(cooks)
+
+	for _, ts := range types {
+		r.Types = append(r.Types, *ts)
+	}
+	sort.Slice(r.Types, func(i, j int) bool {
+		return order.PriorityMap[r.Types[i].Type] > order.PriorityMap[r.Types[j].Type]
+	})
+
+	span := r.To.Sub(r.From)
+	for _, bs := range bots {
+		if span > 0 {
+			bs.Utilisation = float64(bs.Busy) / float64(span)
+		}
+		r.Bots = append(r.Bots, *bs)
+	}
+	sort.Slice(r.Bots, func(i, j int) bool { return r.Bots[i].BotID < r.Bots[j].BotID })
+
+	for hour, n := range hours {
+		r.Hourly = append(r.Hourly, HourStats{Hour: hour, Completed: n})
+	}
+	sort.Slice(r.Hourly, func(i, j int) bool { return r.Hourly[i].Hour.Before(r.Hourly[j].Hour) })
+
+	return r
+}
+
+// FromRepository builds the report for every order held by repo. Orders evicted
+// by a retention policy are not included.
+func FromRepository(repo order.Repository, sla time.Duration) Report {
+	page := repo.Query(order.Query{})
+	orders := make([]*order.Order, 0, len(page.Orders))
+	for _, snap := range page.Orders {
+		if o := repo.Get(snap.ID); o != nil {
+			orders = append(orders, o)
+		}
+	}
+	var workers []order.WorkerTransition
+	if j, ok := repo.(order.WorkerJournal); ok {
+		workers = j.WorkerHistory()
+	}
+	return Build(orders, workers, sla)
+}
+
+// span is a period of time, from start until end.
+type span struct {
+	start, end time.Time
+}
+
+// suspensions returns, per worker, the periods it was PAUSED or in
+// MAINTENANCE. A worker still suspended at the end of the run stays so until end.
+func suspensions(workers []order.WorkerTransition, end time.Time) map[string][]span {
+	sorted := append([]order.WorkerTransition(nil), workers...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].At.Before(sorted[j].At) })
+
+	spans := make(map[string][]span)
+	since := make(map[string]time.Time)
+	for _, t := range sorted {
+		start, suspended := since[t.WorkerID]
+		switch {
+		case isSuspended(t.To) && !suspended:
+			since[t.WorkerID] = t.At
+		case !isSuspended(t.To) && suspended:
+			spans[t.WorkerID] = append(spans[t.WorkerID], span{start, t.At})
+			delete(since, t.WorkerID)
+		}
+	}
+	for id, start := range since {
+		spans[id] = append(spans[id], span{start, end})
+	}
+	return spans
+}
+
+func isSuspended(status string) bool {
+	return status == string(bot.BotStatusPaused) || status == string(bot.BotStatusMaintenance)
+}
+
+// overlap returns how much of the period from start until end the spans cover.
+// The spans must not overlap each other.
+func overlap(spans []span, start, end time.Time) time.Duration {
+	var d time.Duration
+	for _, sp := range spans {
+		from, to := sp.start, sp.end
+		if from.Before(start) {
+			from = start
+		}
+		if to.After(end) {
+			to = end
+		}
+		if to.After(from) {
+			d += to.Sub(from)
+		}
+	}
+	return d
+}
+
+func botStats(bots map[string]*BotStats, id string) *BotStats {
+	bs, ok := bots[id]
+	if !ok {
+		bs = &BotStats{BotID: id}
+		bots[id] = bs
+	}
+	return bs
+}
+
+// percentiles computes nearest-rank percentiles of ds.
+func percentiles(ds []time.Duration) Percentiles {
+	p := Percentiles{Count: len(ds)}
+	if len(ds) == 0 {
+		return p
+	}
+	sorted := append([]time.Duration(nil), ds...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	rank := func(q float64) time.Duration {
+		i := int(math.Ceil(q*float64(len(sorted)))) - 1
+		return sorted[max(0, i)]
+	}
+	p.P50, p.P90, p.P99 = rank(0.50), rank(0.90), rank(0.99)
+	return p
+}
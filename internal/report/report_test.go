@@ -0,0 +1,154 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/feedme/order-controller/internal/order"
+)
+
+var base = time.Date(2026, 1, 2, 9, 59, 0, 0, time.UTC)
+
+// at formats the time s seconds after base as the journal does.
+func at(s int) string { return base.Add(time.Duration(s) * time.Second).Format(time.RFC3339Nano) }
+
+// worker returns a journal line for a bot status change s seconds after base.
+func worker(id, from, to string, s int) string {
+	return `{"op":"worker","worker":{"WorkerID":"` + id + `","From":"` + from + `","To":"` + to + `","At":"` + at(s) + `"}}`
+}
+
+// journal writes a journal with fixed timestamps (seconds after base),
+// followed by the extra lines:
+//   - 1001 VIP created 0s, bot A 10-40s complete
+//   - 1002 Normal created 0s, bot B 20-30s, requeued, bot A 60-100s complete
+//   - 1003 Normal created 5s, cancelled 50s
+//   - 1004 Normal created 200s, still pending
+func journal(t *testing.T, extra ...string) order.Repository {
+	t.Helper()
+	add := func(id int, typ string, prio, s int) string {
+		return `{"op":"add","order":{"id":` + strconv.Itoa(id) + `,"number":"","type":"` + typ + `","priority":` + strconv.Itoa(prio) + `,"created_at":"` + at(s) + `","affinity":{}}}`
+	}
+	tr := func(id int, from, to, actor string, s int) string {
+		return `{"op":"transition","transition":{"OrderID":` + strconv.Itoa(id) + `,"From":"` + from + `","To":"` + to + `","Actor":"` + actor + `","At":"` + at(s) + `"}}`
+	}
+	lines := []string{
+		add(1001, "VIP", 20, 0),
+		add(1002, "Normal", 10, 0),
+		add(1003, "Normal", 10, 5),
+		tr(1001, "PENDING", "PROCESSING", "bot:A", 10),
+		tr(1002, "PENDING", "PROCESSING", "bot:B", 20),
+		tr(1002, "PROCESSING", "PENDING", "system", 30),
+		tr(1001, "PROCESSING", "COMPLETE", "bot:A", 40),
+		tr(1003, "PENDING", "CANCELLED", "user", 50),
+		tr(1002, "PENDING", "PROCESSING", "bot:A", 60),
+		tr(1002, "PROCESSING", "COMPLETE", "bot:A", 100),
+		add(1004, "Normal", 10, 200),
+	}
+	lines = append(lines, extra...)
+
+	path := filepath.Join(t.TempDir(), "orders.jsonl")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	repo, err := order.OpenFileRepository(path)
+	if err != nil {
+		t.Fatalf("OpenFileRepository: %v", err)
+	}
+	t.Cleanup(func() { repo.Close() })
+	return repo
+}
+
+func TestBuild(t *testing.T) {
+	r := FromRepository(journal(t), time.Minute)
+
+	if len(r.Types) != 2 || r.Types[0].Type != order.OrderTypeVIP {
+		t.Fatalf("Expected VIP then Normal type stats, got %+v", r.Types)
+	}
+	normal := r.Types[1]
+	if normal.Created != 3 || normal.Completed != 1 || normal.Cancelled != 1 || normal.Pending != 1 {
+		t.Errorf("Unexpected Normal stats: %+v", normal)
+	}
+
+	if r.Wait.Count != 2 || r.Wait.P50 != 10*time.Second || r.Wait.P99 != 20*time.Second {
+		t.Errorf("Unexpected wait percentiles: %+v", r.Wait)
+	}
+	if r.Cook.Count != 2 || r.Cook.P50 != 30*time.Second || r.Cook.P90 != 50*time.Second {
+		t.Errorf("Unexpected cook percentiles: %+v", r.Cook)
+	}
+	if r.Requeues != 1 {
+		t.Errorf("Expected 1 requeue, got %d", r.Requeues)
+	}
+
+	if len(r.Bots) != 2 {
+		t.Fatalf("Expected 2 bots, got %+v", r.Bots)
+	}
+	if a := r.Bots[0]; a.BotID != "A" || a.Completed != 2 || a.Busy != 70*time.Second || a.Utilisation != 70.0/200 {
+		t.Errorf("Unexpected bot A stats: %+v", a)
+	}
+	if b := r.Bots[1]; b.BotID != "B" || b.Completed != 0 || b.Busy != 10*time.Second {
+		t.Errorf("Unexpected bot B stats: %+v", b)
+	}
+
+	// 1001 finished in the 09:00 hour, 1002 in the 10:00 hour
+	if len(r.Hourly) != 2 || r.Hourly[0].Completed != 1 || r.Hourly[1].Completed != 1 {
+		t.Errorf("Unexpected hourly throughput: %+v", r.Hourly)
+	}
+	// 1002 took 100s; 1004 is pending but not yet older than the SLA
+	if r.SLAMisses != 1 {
+		t.Errorf("Expected 1 SLA miss, got %d", r.SLAMisses)
+	}
+}
+
+func TestBuildLeavesOutPausedTime(t *testing.T) {
+	// Bot A is paused 20-25s while cooking 1001 and in maintenance 70-80s
+	// while cooking 1002; bot B is paused 35-45s while holding nothing.
+	r := FromRepository(journal(t,
+		worker("A", "PROCESSING", "PAUSED", 20),
+		worker("A", "PAUSED", "PROCESSING", 25),
+		worker("B", "IDLE", "PAUSED", 35),
+		worker("B", "PAUSED", "IDLE", 45),
+		worker("A", "PROCESSING", "MAINTENANCE", 70),
+		worker("A", "MAINTENANCE", "PROCESSING", 80),
+	), time.Minute)
+
+	if a := r.Bots[0]; a.BotID != "A" || a.Busy != 55*time.Second {
+		t.Errorf("Expected bot A busy for 55s, got %+v", a)
+	}
+	if b := r.Bots[1]; b.BotID != "B" || b.Busy != 10*time.Second {
+		t.Errorf("Expected bot B busy for 10s, got %+v", b)
+	}
+	// 1001 cooked 25s; 1002 cooked 10s on B and 30s on A
+	if r.Cook.P50 != 25*time.Second || r.Cook.P90 != 40*time.Second {
+		t.Errorf("Unexpected cook percentiles: %+v", r.Cook)
+	}
+}
+
+func TestWriteFormats(t *testing.T) {
+	r := FromRepository(journal(t), time.Minute)
+
+	var text, csv, js bytes.Buffer
+	if err := r.Write(&text, FormatText); err != nil || !strings.Contains(text.String(), "Bot #A: 2 completed") {
+		t.Errorf("Unexpected text report (err %v):\n%s", err, text.String())
+	}
+	if err := r.Write(&csv, FormatCSV); err != nil || !strings.Contains(csv.String(), "sla_misses,,1\n") {
+		t.Errorf("Unexpected CSV report (err %v):\n%s", err, csv.String())
+	}
+	if err := r.Write(&js, FormatJSON); err != nil {
+		t.Fatalf("WriteJSON: %v", err)
+	}
+	var decoded Report
+	if err := json.Unmarshal(js.Bytes(), &decoded); err != nil || decoded.Requeues != 1 {
+		t.Errorf("Unexpected JSON report (err %v): %+v", err, decoded)
+	}
+
+	if err := r.Write(&text, "xml"); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("Expected ErrUnknownFormat, got %v", err)
+	}
+}
@@ -0,0 +1,224 @@
+// Package result writes a machine-readable record of a simulation run: one
+// record per published event followed by a final summary, as JSON Lines or CSV.
+// It complements the free-text scripts/result.txt with stable fields for CI and
+// regression diffs.
+package result
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/feedme/order-controller/internal/bot"
+	"github.com/feedme/order-controller/internal/clock"
+	"github.com/feedme/order-controller/internal/event"
+	"github.com/feedme/order-controller/internal/manager"
+	"github.com/feedme/order-controller/internal/order"
+)
+
+// Output formats. FormatText means no structured file is written and only
+// result.txt is kept, so NewRecorder does not accept it.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+)
+
+// SummaryEvent is the event name of the final summary record.
+const SummaryEvent = "SUMMARY"
+
+// TimeLayout is the HH:MM:SS stamp shared with result.txt.
+const TimeLayout = "15:04:05"
+
+// bufferSize is the recorder's event buffer; the bus drops events when it is full.
+const bufferSize = 1024
+
+// ErrUnknownFormat is returned for an unsupported output format.
+var ErrUnknownFormat = errors.New("unknown output format")
+
+// Record is one line of the structured result file. Fields that do not apply
+// to an event are left empty.
+type Record struct {
+	Time        string           `json:"time"`
+	Timestamp   time.Time        `json:"timestamp"`
+	Event       string           `json:"event"`
+	OrderID     int              `json:"order_id,omitempty"`
+	OrderNumber string           `json:"order_number,omitempty"`
+	OrderType   string           `json:"order_type,omitempty"`
+	Priority    int              `json:"priority,omitempty"`
+	Status      string           `json:"status,omitempty"`
+	BotID       string           `json:"bot_id,omitempty"`
+	Detail      string           `json:"detail,omitempty"`
+	Summary     *manager.Summary `json:"summary,omitempty"`
+}
+
+// csvHeader lists the CSV columns in order. The summary is flattened into one
+// SUMMARY row per field, with the field name in status and its value in detail.
+var csvHeader = []string{"time", "timestamp", "event", "order_id", "order_number", "order_type", "priority", "status", "bot_id", "detail"}
+
+// Recorder subscribes to an event bus and writes every event it receives.
+type Recorder struct {
+	enc    *json.Encoder
+	csv    *csv.Writer
+	bus    *event.EventBus
+	clock  clock.Clock
+	events chan event.Event
+	done   chan struct{}
+	// mu guards the writers and err.
+	mu  sync.Mutex
+	err error
+}
+
+// Option configures a Recorder at construction time.
+type Option func(*Recorder)
+
+// WithClock sets the clock that stamps the summary record, e.g. the clock the
+// recorded manager runs on. The default is clock.Real.
+func WithClock(c clock.Clock) Option {
+	return func(r *Recorder) {
+		r.clock = c
+	}
+}
+
+// DefaultPath returns where the CLI writes the structured result file for a format.
+func DefaultPath(format string) string {
+	if format == FormatCSV {
+		return "scripts/result.csv"
+	}
+	return "scripts/result.jsonl"
+}
+
+// NewRecorder starts recording the events published on bus to w in the given
+// format (FormatJSON or FormatCSV).
+func NewRecorder(w io.Writer, format string, bus *event.EventBus, opts ...Option) (*Recorder, error) {
+	r := &Recorder{bus: bus, clock: clock.Real, done: make(chan struct{})}
+	for _, opt := range opts {
+		opt(r)
+	}
+	switch format {
+	case FormatJSON:
+		r.enc = json.NewEncoder(w)
+	case FormatCSV:
+		r.csv = csv.NewWriter(w)
+		r.writeCSV(csvHeader)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+	}
+
+	r.events = bus.SubscribeAll(bufferSize)
+	go r.run()
+	return r, nil
+}
+
+func (r *Recorder) run() {
+	defer close(r.done)
+	for ev := range r.events {
+		r.write(FromEvent(ev))
+	}
+}
+
+// Close stops recording, writes the final summary record and flushes the
+// output. It returns the first write error.
+func (r *Recorder) Close(summary manager.Summary) error {
+	r.bus.UnsubscribeAll(r.events)
+	<-r.done
+
+	now := r.clock.Now()
+	r.write(Record{Time: now.Format(TimeLayout), Timestamp: now, Event: SummaryEvent, Summary: &summary})
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.csv != nil {
+		r.csv.Flush()
+		if err := r.csv.Error(); err != nil && r.err == nil {
+			r.err = err
+		}
+	}
+	return r.err
+}
+
+// FromEvent converts a bus event into a record.
+func FromEvent(ev event.Event) Record {
+	rec := Record{Time: ev.At.Format(TimeLayout), Timestamp: ev.At, Event: string(ev.Type)}
+	switch data := ev.Data.(type) {
+	case order.OrderSnapshot:
+		setOrder(&rec, data)
+	case bot.StatusTransition:
+		rec.BotID = data.BotID
+		rec.Status = string(data.To)
+		rec.Detail = data.Reason
+	case manager.Preemption:
+		setOrder(&rec, data.Displaced)
+		rec.BotID = data.BotID
+		rec.Detail = fmt.Sprintf("preempted by order %d", data.PreemptedBy)
+	case manager.Modification:
+		setOrder(&rec, data.Order)
+		rec.Detail = data.Diff.String()
+	}
+	return rec
+}
+
+func setOrder(rec *Record, o order.OrderSnapshot) {
+	rec.OrderID = o.ID
+	rec.OrderNumber = o.Number
+	rec.OrderType = string(o.Type)
+	rec.Priority = o.Priority
+	rec.Status = string(o.Status)
+}
+
+func (r *Recorder) write(rec Record) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.enc != nil {
+		if err := r.enc.Encode(rec); err != nil && r.err == nil {
+			r.err = err
+		}
+		return
+	}
+
+	if rec.Summary != nil {
+		for _, field := range summaryFields(*rec.Summary) {
+			r.writeCSV([]string{rec.Time, rec.Timestamp.Format(time.RFC3339Nano), rec.Event, "", "", "", "", field[0], "", field[1]})
+		}
+		return
+	}
+	orderID, priority := "", ""
+	if rec.OrderID != 0 {
+		orderID = strconv.Itoa(rec.OrderID)
+		priority = strconv.Itoa(rec.Priority)
+	}
+	r.writeCSV([]string{rec.Time, rec.Timestamp.Format(time.RFC3339Nano), rec.Event, orderID, rec.OrderNumber,
+		rec.OrderType, priority, rec.Status, rec.BotID, rec.Detail})
+}
+
+// writeCSV writes one CSV row. The caller must hold r.mu.
+func (r *Recorder) writeCSV(row []string) {
+	if err := r.csv.Write(row); err != nil && r.err == nil {
+		r.err = err
+	}
+}
+
+// summaryFields returns the summary as (name, value) pairs using its JSON names.
+func summaryFields(s manager.Summary) [][2]string {
+	return [][2]string{
+		{"store_id", s.StoreID},
+		{"total_orders", strconv.Itoa(s.TotalOrders)},
+		{"vip_orders", strconv.Itoa(s.VIPOrders)},
+		{"normal_orders", strconv.Itoa(s.NormalOrders)},
+		{"completed_orders", strconv.Itoa(s.CompletedOrders)},
+		{"active_bots", strconv.Itoa(s.ActiveBots)},
+		{"paused_bots", strconv.Itoa(s.PausedBots)},
+		{"maintenance_bots", strconv.Itoa(s.MaintenanceBots)},
+		{"stalled_bots", strconv.Itoa(s.StalledBots)},
+		{"pending_orders", strconv.Itoa(s.PendingOrders)},
+		{"scheduled_orders", strconv.Itoa(s.ScheduledOrders)},
+		{"ready_orders", strconv.Itoa(s.ReadyOrders)},
+		{"wasted_orders", strconv.Itoa(s.WastedOrders)},
+	}
+}
@@ -0,0 +1,138 @@
+package result
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/feedme/order-controller/internal/bot"
+	"github.com/feedme/order-controller/internal/clock"
+	"github.com/feedme/order-controller/internal/event"
+	"github.com/feedme/order-controller/internal/manager"
+	"github.com/feedme/order-controller/internal/order"
+)
+
+var at = time.Date(2026, 1, 2, 9, 30, 15, 0, time.Local)
+
+func publishRun(bus *event.EventBus) {
+	o := order.NewOrder(1001, order.OrderTypeVIP, order.ActorUser).Snapshot()
+	o.Number = "KL01-1001"
+	bus.Publish(event.Event{Type: event.OrderCreated, Data: o, At: at})
+	bus.Publish(event.Event{Type: event.BotStatusChanged, At: at.Add(time.Second), Data: bot.StatusTransition{
+		BotID: "042", From: bot.BotStatusIdle, To: bot.BotStatusProcessing, Reason: "picked up order 1001",
+	}})
+}
+
+func TestJSONRecorder(t *testing.T) {
+	bus := event.NewEventBus()
+	var buf bytes.Buffer
+	r, err := NewRecorder(&buf, FormatJSON, bus)
+	if err != nil {
+		t.Fatalf("NewRecorder: %v", err)
+	}
+	publishRun(bus)
+	if err := r.Close(manager.Summary{TotalOrders: 1, VIPOrders: 1}); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	var records []Record
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			t.Fatalf("Invalid JSON line %q: %v", scanner.Text(), err)
+		}
+		records = append(records, rec)
+	}
+	if len(records) != 3 {
+		t.Fatalf("Expected 3 records, got %d", len(records))
+	}
+
+	created := records[0]
+	if created.Event != string(event.OrderCreated) || created.Time != "09:30:15" || created.OrderID != 1001 ||
+		created.OrderNumber != "KL01-1001" || created.Status != string(order.OrderStatusPending) {
+		t.Errorf("Unexpected order record: %+v", created)
+	}
+	if b := records[1]; b.BotID != "042" || b.Status != string(bot.BotStatusProcessing) || b.Time != "09:30:16" {
+		t.Errorf("Unexpected bot record: %+v", b)
+	}
+	if s := records[2]; s.Event != SummaryEvent || s.Summary == nil || s.Summary.TotalOrders != 1 {
+		t.Errorf("Unexpected summary record: %+v", s)
+	}
+}
+
+func TestCSVRecorder(t *testing.T) {
+	bus := event.NewEventBus()
+	var buf bytes.Buffer
+	r, err := NewRecorder(&buf, FormatCSV, bus)
+	if err != nil {
+		t.Fatalf("NewRecorder: %v", err)
+	}
+	publishRun(bus)
+	if err := r.Close(manager.Summary{TotalOrders: 1}); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("Invalid CSV: %v", err)
+	}
+	// header, two events, one row per summary field
+	if len(rows) != 1+2+len(summaryFields(manager.Summary{})) {
+		t.Fatalf("Unexpected row count %d:\n%v", len(rows), rows)
+	}
+	if rows[1][0] != "09:30:15" || rows[1][2] != string(event.OrderCreated) || rows[1][3] != "1001" {
+		t.Errorf("Unexpected order row: %v", rows[1])
+	}
+	if total := rows[4]; total[2] != SummaryEvent || total[7] != "total_orders" || total[9] != "1" {
+		t.Errorf("Unexpected summary row: %v", total)
+	}
+}
+
+func TestUnknownFormat(t *testing.T) {
+	if _, err := NewRecorder(&bytes.Buffer{}, FormatText, event.NewEventBus()); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("Expected ErrUnknownFormat, got %v", err)
+	}
+}
+
+func TestCSVSummaryCoversEveryField(t *testing.T) {
+	var fields map[string]interface{}
+	data, _ := json.Marshal(manager.Summary{StoreID: "KL01"})
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+	names := make(map[string]bool)
+	for _, f := range summaryFields(manager.Summary{}) {
+		names[f[0]] = true
+	}
+	for name := range fields {
+		if !names[name] {
+			t.Errorf("Expected %s in the CSV summary", name)
+		}
+	}
+}
+
+func TestSummaryStampedByRecorderClock(t *testing.T) {
+	v := clock.NewVirtual(at.Add(time.Hour))
+	bus := event.NewEventBus(event.WithClock(v))
+	var buf bytes.Buffer
+	r, err := NewRecorder(&buf, FormatJSON, bus, WithClock(v))
+	if err != nil {
+		t.Fatalf("NewRecorder: %v", err)
+	}
+	if err := r.Close(manager.Summary{}); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	var rec Record
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("Invalid JSON %q: %v", buf.String(), err)
+	}
+	if rec.Event != SummaryEvent || !rec.Timestamp.Equal(v.Now()) || rec.Time != "10:30:15" {
+		t.Errorf("Expected the summary stamped 10:30:15 by the virtual clock, got %+v", rec)
+	}
+}
@@ -1,21 +0,0 @@
-package utils
-
-import (
-	"math/rand"
-	"time"
-)
-
-func init() {
-	rand.Seed(time.Now().UnixNano())
-}
-
-var letters = []rune("123456789")
-
-// GenerateRandomID returns a random string of the specified length.
-func GenerateRandomID(n int) string {
-	b := make([]rune, n)
-	for i := range b {
-		b[i] = letters[rand.Intn(len(letters))]
-	}
-	return string(b)
-}
@@ -4,10 +4,13 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sync"
 )
 
 var (
 	logFile io.Writer
+	// logMu guards logFile and keeps lines logged concurrently from interleaving.
+	logMu sync.Mutex
 )
 
 func init() {
@@ -21,20 +24,34 @@ func init() {
 	logFile = io.MultiWriter(os.Stdout, f)
 }
 
+// SetOutput redirects all log output to w, e.g. io.Discard for load tests.
+func SetOutput(w io.Writer) {
+	logMu.Lock()
+	defer logMu.Unlock()
+	logFile = w
+}
+
 // Log formats and prints a message with the current localized timestamp.
 func Log(format string, a ...interface{}) {
 	message := fmt.Sprintf(format, a...)
-	fmt.Fprintf(logFile, "[%s] %s\n", GetCurrentTimestamp(), message)
+	output("[%s] %s\n", GetCurrentTimestamp(), message)
 }
 
 // LogRaw formats and prints a message WITHOUT a timestamp.
 func LogRaw(format string, a ...interface{}) {
 	message := fmt.Sprintf(format, a...)
-	fmt.Fprintf(logFile, "%s\n", message)
+	output("%s\n", message)
 }
 
 // LogError formats and prints an error message.
 func LogError(format string, a ...interface{}) {
 	message := fmt.Sprintf(format, a...)
-	fmt.Fprintf(logFile, "[%s] ERROR: %s\n", GetCurrentTimestamp(), message)
+	output("[%s] ERROR: %s\n", GetCurrentTimestamp(), message)
+}
+
+// output writes one formatted line to the current log output.
+func output(format string, a ...interface{}) {
+	logMu.Lock()
+	defer logMu.Unlock()
+	fmt.Fprintf(logFile, format, a...)
 }
@@ -0,0 +1,98 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/feedme/order-controller/internal/event"
+)
+
+// registerRequest is the body of POST /webhooks.
+type registerRequest struct {
+	URL    string            `json:"url"`
+	Secret string            `json:"secret"`
+	Events []event.EventType `json:"events"`
+}
+
+// NewAdminHandler returns the admin API of s:
+//
+//	GET    /webhooks                                            list endpoints
+//	POST   /webhooks                                            register an endpoint
+//	DELETE /webhooks/{id}                                       unregister an endpoint
+//	GET    /webhooks/{id}/attempts                              latest delivery attempts
+//	GET    /webhooks/{id}/dead-letters                          failed deliveries
+//	POST   /webhooks/{id}/dead-letters/{delivery}/redeliver     queue a failed delivery again
+func NewAdminHandler(s *Service) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /webhooks", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, s.Endpoints())
+	})
+	mux.HandleFunc("POST /webhooks", func(w http.ResponseWriter, r *http.Request) {
+		var req registerRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		ep, err := s.Register(req.URL, req.Secret, req.Events...)
+		if err != nil {
+			writeError(w, statusFor(err), err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, ep)
+	})
+	mux.HandleFunc("DELETE /webhooks/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if err := s.Unregister(r.PathValue("id")); err != nil {
+			writeError(w, statusFor(err), err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("GET /webhooks/{id}/attempts", func(w http.ResponseWriter, r *http.Request) {
+		attempts, err := s.Attempts(r.PathValue("id"))
+		if err != nil {
+			writeError(w, statusFor(err), err)
+			return
+		}
+		writeJSON(w, http.StatusOK, attempts)
+	})
+	mux.HandleFunc("GET /webhooks/{id}/dead-letters", func(w http.ResponseWriter, r *http.Request) {
+		dead, err := s.DeadLetters(r.PathValue("id"))
+		if err != nil {
+			writeError(w, statusFor(err), err)
+			return
+		}
+		writeJSON(w, http.StatusOK, dead)
+	})
+	mux.HandleFunc("POST /webhooks/{id}/dead-letters/{delivery}/redeliver", func(w http.ResponseWriter, r *http.Request) {
+		if err := s.Redeliver(r.PathValue("id"), r.PathValue("delivery")); err != nil {
+			writeError(w, statusFor(err), err)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+	return mux
+}
+
+// statusFor maps a Service error to an HTTP status.
+func statusFor(err error) int {
+	switch {
+	case errors.Is(err, ErrEndpointNotFound), errors.Is(err, ErrDeliveryNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrInvalidEndpoint):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrQueueFull):
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
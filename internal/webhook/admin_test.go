@@ -0,0 +1,56 @@
+package webhook
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/feedme/order-controller/internal/event"
+)
+
+func TestAdminHandler(t *testing.T) {
+	s := New(event.NewEventBus())
+	defer s.Stop()
+	h := NewAdminHandler(s)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+
+	rec := do(http.MethodPost, "/webhooks", `{"url":"https://partner.example.com/hook","secret":"s3cret","events":["ORDER_COMPLETED"]}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", rec.Code, rec.Body)
+	}
+	if strings.Contains(rec.Body.String(), "s3cret") {
+		t.Error("Expected the secret not to be shown")
+	}
+	var ep Endpoint
+	if err := json.Unmarshal(rec.Body.Bytes(), &ep); err != nil {
+		t.Fatal(err)
+	}
+
+	var list []Endpoint
+	rec = do(http.MethodGet, "/webhooks", "")
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil || len(list) != 1 || list[0].ID != ep.ID {
+		t.Fatalf("Expected the endpoint listed, got %s (%v)", rec.Body, err)
+	}
+	if rec := do(http.MethodGet, "/webhooks/"+ep.ID+"/attempts", ""); rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != "[]" {
+		t.Errorf("Expected no attempts, got %d: %s", rec.Code, rec.Body)
+	}
+	if rec := do(http.MethodPost, "/webhooks/"+ep.ID+"/dead-letters/dlv-9/redeliver", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown delivery, got %d", rec.Code)
+	}
+	if rec := do(http.MethodPost, "/webhooks", `{"url":"not a url","secret":"x"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid endpoint, got %d", rec.Code)
+	}
+	if rec := do(http.MethodDelete, "/webhooks/"+ep.ID, ""); rec.Code != http.StatusNoContent {
+		t.Errorf("Expected 204, got %d", rec.Code)
+	}
+	if rec := do(http.MethodGet, "/webhooks/"+ep.ID+"/dead-letters", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 after unregistering, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,96 @@
+package webhook
+
+import (
+	"time"
+
+	"github.com/feedme/order-controller/internal/bot"
+	"github.com/feedme/order-controller/internal/event"
+	"github.com/feedme/order-controller/internal/order"
+)
+
+// payload is the JSON body of a delivery.
+type payload struct {
+	ID      string          `json:"id"`
+	Type    event.EventType `json:"type"`
+	StoreID string          `json:"store_id,omitempty"`
+	At      time.Time       `json:"at"`
+	Data    interface{}     `json:"data"`
+}
+
+// PartnerData is implemented by event data that knows how it is shared with
+// partners. Event data of other types is only delivered if it is an order
+// snapshot or a bot transition; anything else is sent as null, so nothing is
+// shared with partners by accident.
+type PartnerData interface {
+	PartnerData() interface{}
+}
+
+// OrderData is an order as shared with partners. It leaves out the
+// customer's contact details.
+type OrderData struct {
+	ID          int                   `json:"id"`
+	Number      string                `json:"number"`
+	Type        order.OrderTypeEnum   `json:"type"`
+	Priority    int                   `json:"priority"`
+	Status      order.OrderStatusEnum `json:"status"`
+	Items       []ItemData            `json:"items"`
+	CreatedAt   time.Time             `json:"created_at"`
+	ProcessedAt time.Time             `json:"processed_at"`
+	CompletedAt time.Time             `json:"completed_at"`
+	NotBefore   time.Time             `json:"not_before"`
+	Progress    float64               `json:"progress"`
+}
+
+// ItemData is one line of an order as shared with partners.
+type ItemData struct {
+	Name     string `json:"name"`
+	Quantity int    `json:"quantity"`
+}
+
+// BotData is a bot status change as shared with partners.
+type BotData struct {
+	BotID  string            `json:"bot_id"`
+	From   bot.BotStatusEnum `json:"from"`
+	To     bot.BotStatusEnum `json:"to"`
+	Reason string            `json:"reason"`
+	At     time.Time         `json:"at"`
+}
+
+// NewOrderData returns the partner view of an order.
+func NewOrderData(s order.OrderSnapshot) OrderData {
+	return OrderData{
+		ID:          s.ID,
+		Number:      s.Number,
+		Type:        s.Type,
+		Priority:    s.Priority,
+		Status:      s.Status,
+		Items:       NewItemData(s.Items),
+		CreatedAt:   s.CreatedAt,
+		ProcessedAt: s.ProcessedAt,
+		CompletedAt: s.CompletedAt,
+		NotBefore:   s.NotBefore,
+		Progress:    s.Progress,
+	}
+}
+
+// NewItemData returns the partner view of an order's items.
+func NewItemData(items []order.Item) []ItemData {
+	data := make([]ItemData, len(items))
+	for i, it := range items {
+		data[i] = ItemData{Name: it.Name, Quantity: it.Quantity}
+	}
+	return data
+}
+
+// partnerData returns what partners are sent for the data of an event.
+func partnerData(data interface{}) interface{} {
+	switch d := data.(type) {
+	case PartnerData:
+		return d.PartnerData()
+	case order.OrderSnapshot:
+		return NewOrderData(d)
+	case bot.StatusTransition:
+		return BotData{BotID: d.BotID, From: d.From, To: d.To, Reason: d.Reason, At: d.At}
+	}
+	return nil
+}
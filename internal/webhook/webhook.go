@@ -0,0 +1,537 @@
+// Package webhook delivers order and bot lifecycle events to partner systems,
+// such as delivery aggregators or a loyalty service. Partners register an
+// endpoint for the event types they care about; every delivery is a JSON
+// payload signed with the endpoint's secret, retried with exponential backoff
+// and kept on the endpoint's dead-letter list if it still fails.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/feedme/order-controller/internal/clock"
+	"github.com/feedme/order-controller/internal/event"
+	"github.com/feedme/order-controller/internal/utils"
+)
+
+// Headers sent with every delivery.
+const (
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	// HeaderSignature carries "sha256=" and the hex HMAC-SHA256 of the
+	// timestamp, a dot and the body, keyed with the endpoint's secret.
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// Defaults used unless overridden by options.
+const (
+	DefaultQueueSize      = 256
+	DefaultHistorySize    = 100
+	DefaultDeadLetterSize = 1000
+)
+
+// RetryPolicy controls how often a failed delivery is tried again. The wait
+// before each retry doubles, starting at Backoff and capped at MaxBackoff.
+type RetryPolicy struct {
+	// Attempts is the total number of tries; values below one mean one.
+	Attempts   int
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// Delay returns how long to wait after the given failed attempt, counting from one.
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	d := p.Backoff
+	for i := 1; i < attempt && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	return d
+}
+
+// DefaultRetryPolicy tries a delivery five times, waiting 1s, 2s, 4s and 8s.
+var DefaultRetryPolicy = RetryPolicy{Attempts: 5, Backoff: time.Second, MaxBackoff: time.Minute}
+
+var (
+	// ErrInvalidEndpoint is returned by Register for a malformed URL or an
+	// empty secret.
+	ErrInvalidEndpoint = errors.New("invalid webhook endpoint")
+	// ErrEndpointNotFound is returned for an unknown endpoint ID.
+	ErrEndpointNotFound = errors.New("webhook endpoint not found")
+	// ErrDeliveryNotFound is returned by Redeliver for a delivery that is not
+	// on the endpoint's dead-letter list.
+	ErrDeliveryNotFound = errors.New("dead-lettered delivery not found")
+	// ErrQueueFull is returned by Redeliver when the endpoint's queue is full.
+	ErrQueueFull = errors.New("webhook queue full")
+)
+
+// Endpoint is a partner URL registered for some event types.
+type Endpoint struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+	// Secret keys the payload signatures. It is never shown by the admin API.
+	Secret string `json:"-"`
+	// Events lists the event types delivered; empty means every type.
+	Events    []event.EventType `json:"events"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// wants reports whether the endpoint is registered for events of type t.
+func (e Endpoint) wants(t event.EventType) bool {
+	return len(e.Events) == 0 || slices.Contains(e.Events, t)
+}
+
+// Delivery is one event on its way to one endpoint.
+type Delivery struct {
+	ID         string          `json:"id"`
+	EndpointID string          `json:"endpoint_id"`
+	Event      event.EventType `json:"event"`
+	Payload    json.RawMessage `json:"payload"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// Attempt records one try at sending a delivery.
+type Attempt struct {
+	DeliveryID string          `json:"delivery_id"`
+	Event      event.EventType `json:"event"`
+	// Number counts the tries of the delivery, starting at one.
+	Number int       `json:"number"`
+	At     time.Time `json:"at"`
+	// StatusCode is zero if no response was received.
+	StatusCode int    `json:"status_code,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// DeadLetter is a delivery given up after its last attempt failed.
+type DeadLetter struct {
+	Delivery  Delivery  `json:"delivery"`
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"last_error"`
+	FailedAt  time.Time `json:"failed_at"`
+}
+
+// endpoint is a registered Endpoint with its delivery queue and history.
+type endpoint struct {
+	Endpoint
+	// seq orders endpoints by registration.
+	seq    int
+	queue  chan Delivery
+	cancel context.CancelFunc
+	// attempts and dead are guarded by the Service's mu; dead holds at most
+	// the Service's deadLetterSize entries, dropping the oldest.
+	attempts []Attempt
+	dead     []DeadLetter
+}
+
+// Service feeds events from an event bus to the registered endpoints. Each
+// endpoint has its own queue and worker, so a slow partner delays no other
+// and nothing waits on a partner while publishing.
+type Service struct {
+	bus            *event.EventBus
+	client         *http.Client
+	retry          RetryPolicy
+	clock          clock.Clock
+	storeID        string
+	queueSize      int
+	historySize    int
+	deadLetterSize int
+
+	events chan event.Event
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	// endpoints, the sequence numbers and stopped are guarded by mu.
+	endpoints    map[string]*endpoint
+	nextEndpoint int
+	nextEvent    int
+	nextDelivery int
+	stopped      bool
+	mu           sync.Mutex
+}
+
+// Option configures a Service at construction time.
+type Option func(*Service)
+
+// WithClient sets the HTTP client used for deliveries. The default has a 10
+// second timeout.
+func WithClient(c *http.Client) Option {
+	return func(s *Service) {
+		s.client = c
+	}
+}
+
+// WithRetryPolicy sets how failed deliveries are retried. The default is
+// DefaultRetryPolicy.
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(s *Service) {
+		s.retry = p
+	}
+}
+
+// WithClock sets the clock used to stamp deliveries and time retries. The
+// default is clock.Real.
+func WithClock(c clock.Clock) Option {
+	return func(s *Service) {
+		s.clock = c
+	}
+}
+
+// WithStoreID names the restaurant in every payload.
+func WithStoreID(id string) Option {
+	return func(s *Service) {
+		s.storeID = id
+	}
+}
+
+// WithQueueSize sets how many deliveries may wait per endpoint. Events for an
+// endpoint whose queue is full are dead-lettered at once. The default is
+// DefaultQueueSize.
+func WithQueueSize(n int) Option {
+	return func(s *Service) {
+		s.queueSize = n
+	}
+}
+
+// WithHistorySize sets how many of its latest attempts are kept per endpoint.
+// The default is DefaultHistorySize.
+func WithHistorySize(n int) Option {
+	return func(s *Service) {
+		s.historySize = n
+	}
+}
+
+// WithDeadLetterSize sets how many failed deliveries are kept per endpoint for
+// redelivery. Once the list is full the oldest is dropped. The default is
+// DefaultDeadLetterSize.
+func WithDeadLetterSize(n int) Option {
+	return func(s *Service) {
+		s.deadLetterSize = n
+	}
+}
+
+// New returns a Service for the events of bus. Call Start to begin delivering.
+func New(bus *event.EventBus, opts ...Option) *Service {
+	s := &Service{
+		bus:            bus,
+		client:         &http.Client{Timeout: 10 * time.Second},
+		retry:          DefaultRetryPolicy,
+		clock:          clock.Real,
+		queueSize:      DefaultQueueSize,
+		historySize:    DefaultHistorySize,
+		deadLetterSize: DefaultDeadLetterSize,
+		endpoints:      make(map[string]*endpoint),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	return s
+}
+
+// Start subscribes to the event bus.
+func (s *Service) Start() {
+	s.events = s.bus.SubscribeAll(1024)
+	s.wg.Add(1)
+	go s.loop()
+}
+
+// Stop unsubscribes from the event bus and stops every endpoint's worker.
+// Deliveries in progress are dead-lettered; queued ones are dropped.
+func (s *Service) Stop() {
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		return
+	}
+	s.stopped = true
+	s.mu.Unlock()
+
+	if s.events != nil {
+		s.bus.UnsubscribeAll(s.events)
+	}
+	s.cancel()
+	s.wg.Wait()
+}
+
+// Register adds an endpoint receiving the given event types, or every type if
+// none are given.
+func (s *Service) Register(rawURL, secret string, events ...event.EventType) (Endpoint, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return Endpoint{}, fmt.Errorf("%w: URL %q", ErrInvalidEndpoint, rawURL)
+	}
+	if secret == "" {
+		return Endpoint{}, fmt.Errorf("%w: empty secret", ErrInvalidEndpoint)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextEndpoint++
+	ctx, cancel := context.WithCancel(s.ctx)
+	ep := &endpoint{
+		Endpoint: Endpoint{
+			ID:        fmt.Sprintf("wh-%d", s.nextEndpoint),
+			URL:       rawURL,
+			Secret:    secret,
+			Events:    append([]event.EventType{}, events...),
+			CreatedAt: s.clock.Now(),
+		},
+		seq:    s.nextEndpoint,
+		queue:  make(chan Delivery, s.queueSize),
+		cancel: cancel,
+	}
+	s.endpoints[ep.ID] = ep
+	s.wg.Add(1)
+	go s.run(ctx, ep)
+	utils.Log("Webhook %s registered for %s", ep.ID, rawURL)
+	return ep.Endpoint, nil
+}
+
+// Unregister removes an endpoint, dropping its queued deliveries and history.
+func (s *Service) Unregister(id string) error {
+	s.mu.Lock()
+	ep, ok := s.endpoints[id]
+	delete(s.endpoints, id)
+	s.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrEndpointNotFound, id)
+	}
+	ep.cancel()
+	utils.Log("Webhook %s unregistered", id)
+	return nil
+}
+
+// Endpoints returns the registered endpoints in registration order.
+func (s *Service) Endpoints() []Endpoint {
+	s.mu.Lock()
+	eps := make([]*endpoint, 0, len(s.endpoints))
+	for _, ep := range s.endpoints {
+		eps = append(eps, ep)
+	}
+	s.mu.Unlock()
+
+	slices.SortFunc(eps, func(a, b *endpoint) int { return a.seq - b.seq })
+	list := make([]Endpoint, len(eps))
+	for i, ep := range eps {
+		list[i] = ep.Endpoint
+	}
+	return list
+}
+
+// Attempts returns the latest delivery attempts of an endpoint, oldest first.
+func (s *Service) Attempts(id string) ([]Attempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ep, ok := s.endpoints[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrEndpointNotFound, id)
+	}
+	return append([]Attempt{}, ep.attempts...), nil
+}
+
+// DeadLetters returns the deliveries an endpoint gave up on, oldest first.
+func (s *Service) DeadLetters(id string) ([]DeadLetter, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ep, ok := s.endpoints[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrEndpointNotFound, id)
+	}
+	return append([]DeadLetter{}, ep.dead...), nil
+}
+
+// Redeliver takes a delivery off an endpoint's dead-letter list and queues it
+// again, with a fresh set of attempts.
+func (s *Service) Redeliver(endpointID, deliveryID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ep, ok := s.endpoints[endpointID]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrEndpointNotFound, endpointID)
+	}
+	i := slices.IndexFunc(ep.dead, func(dl DeadLetter) bool { return dl.Delivery.ID == deliveryID })
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrDeliveryNotFound, deliveryID)
+	}
+	select {
+	case ep.queue <- ep.dead[i].Delivery:
+	default:
+		return fmt.Errorf("%w: %s", ErrQueueFull, endpointID)
+	}
+	ep.dead = slices.Delete(ep.dead, i, i+1)
+	return nil
+}
+
+// Sign returns the signature header value for a body sent at the given Unix
+// timestamp. Partners recompute it to check a delivery is genuine.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is the valid signature of body sent at
+// timestamp, comparing in constant time.
+func Verify(secret, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// loop queues every event for the endpoints registered for its type.
+func (s *Service) loop() {
+	defer s.wg.Done()
+	for ev := range s.events {
+		s.enqueue(ev)
+	}
+}
+
+// enqueue builds the payload of ev once and queues a delivery per endpoint.
+// It never blocks: a delivery that does not fit is dead-lettered.
+func (s *Service) enqueue(ev event.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var body []byte
+	for _, ep := range s.endpoints {
+		if !ep.wants(ev.Type) {
+			continue
+		}
+		if body == nil {
+			s.nextEvent++
+			var err error
+			body, err = json.Marshal(payload{
+				ID:      fmt.Sprintf("evt-%d", s.nextEvent),
+				Type:    ev.Type,
+				StoreID: s.storeID,
+				At:      ev.At,
+				Data:    partnerData(ev.Data),
+			})
+			if err != nil {
+				utils.LogError("Cannot encode %s webhook payload: %v", ev.Type, err)
+				return
+			}
+		}
+		s.nextDelivery++
+		d := Delivery{
+			ID:         fmt.Sprintf("dlv-%d", s.nextDelivery),
+			EndpointID: ep.ID,
+			Event:      ev.Type,
+			Payload:    body,
+			CreatedAt:  s.clock.Now(),
+		}
+		select {
+		case ep.queue <- d:
+		default:
+			s.deadLetterLocked(ep, DeadLetter{Delivery: d, LastError: ErrQueueFull.Error(), FailedAt: d.CreatedAt})
+		}
+	}
+}
+
+// run delivers an endpoint's queue in order until the endpoint is removed.
+func (s *Service) run(ctx context.Context, ep *endpoint) {
+	defer s.wg.Done()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case d := <-ep.queue:
+			s.deliver(ctx, ep, d)
+		}
+	}
+}
+
+// deliver sends d, retrying with backoff, and dead-letters it if every
+// attempt fails.
+func (s *Service) deliver(ctx context.Context, ep *endpoint, d Delivery) {
+	attempts := max(s.retry.Attempts, 1)
+	for n := 1; ; n++ {
+		a := s.attempt(ctx, ep.Endpoint, d, n)
+		s.record(ep, a)
+		if a.Error == "" {
+			return
+		}
+		if n < attempts && ctx.Err() == nil {
+			timer := s.clock.NewTimer(s.retry.Delay(n))
+			select {
+			case <-timer.Chan():
+				continue
+			case <-ctx.Done():
+				timer.Stop()
+			}
+		}
+		utils.LogError("Webhook %s gave up on %s (%s) after %d attempts: %s", ep.ID, d.ID, d.Event, n, a.Error)
+		s.mu.Lock()
+		s.deadLetterLocked(ep, DeadLetter{Delivery: d, Attempts: n, LastError: a.Error, FailedAt: s.clock.Now()})
+		s.mu.Unlock()
+		return
+	}
+}
+
+// attempt makes one signed POST of d to the endpoint.
+func (s *Service) attempt(ctx context.Context, ep Endpoint, d Delivery, n int) Attempt {
+	a := Attempt{DeliveryID: d.ID, Event: d.Event, Number: n, At: s.clock.Now()}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ep.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		a.Error = err.Error()
+		return a
+	}
+	timestamp := strconv.FormatInt(a.At.Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderDelivery, d.ID)
+	req.Header.Set(HeaderEvent, string(d.Event))
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(ep.Secret, timestamp, d.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		a.Error = err.Error()
+		return a
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	a.StatusCode = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		a.Error = resp.Status
+	}
+	return a
+}
+
+// record adds an attempt to the endpoint's history, dropping the oldest once
+// the history is full.
+func (s *Service) record(ep *endpoint, a Attempt) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ep.attempts = append(ep.attempts, a)
+	if over := len(ep.attempts) - s.historySize; over > 0 {
+		ep.attempts = slices.Delete(ep.attempts, 0, over)
+	}
+}
+
+// deadLetterLocked adds a failed delivery to the endpoint's dead-letter list,
+// dropping the oldest once the list is full. The caller must hold s.mu.
+func (s *Service) deadLetterLocked(ep *endpoint, dl DeadLetter) {
+	ep.dead = append(ep.dead, dl)
+	if over := len(ep.dead) - max(s.deadLetterSize, 1); over > 0 {
+		for _, dropped := range ep.dead[:over] {
+			utils.LogError("Webhook %s dropped dead-lettered %s (%s)", ep.ID, dropped.Delivery.ID, dropped.Delivery.Event)
+		}
+		ep.dead = slices.Delete(ep.dead, 0, over)
+	}
+}
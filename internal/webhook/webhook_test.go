@@ -0,0 +1,220 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/feedme/order-controller/internal/clock"
+	"github.com/feedme/order-controller/internal/event"
+	"github.com/feedme/order-controller/internal/order"
+)
+
+var start = time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+
+// received is a request as seen by a partner server.
+type received struct {
+	header http.Header
+	body   []byte
+}
+
+// newPartner starts a server that answers with the status returned by status
+// and passes every request it gets to the returned channel.
+func newPartner(t *testing.T, status func() int) (*httptest.Server, <-chan received) {
+	t.Helper()
+	got := make(chan received, 16)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got <- received{header: r.Header, body: body}
+		w.WriteHeader(status())
+	}))
+	t.Cleanup(srv.Close)
+	return srv, got
+}
+
+func next(t *testing.T, got <-chan received) received {
+	t.Helper()
+	select {
+	case r := <-got:
+		return r
+	case <-time.After(time.Second):
+		t.Fatal("Timeout waiting for a delivery")
+	}
+	return received{}
+}
+
+// waitFor polls cond until it holds or a second has passed.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timeout waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestDeliversSignedPayloadsForRegisteredEvents(t *testing.T) {
+	bus := event.NewEventBus(event.WithClock(clock.NewVirtual(start)))
+	srv, got := newPartner(t, func() int { return http.StatusOK })
+	s := New(bus, WithClient(srv.Client()), WithStoreID("KL01"))
+	s.Start()
+	defer s.Stop()
+
+	ep, err := s.Register(srv.URL, "s3cret", event.OrderCompleted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bus.Publish(event.Event{Type: event.OrderCreated, Data: order.OrderSnapshot{ID: 1001}})
+	bus.Publish(event.Event{Type: event.OrderCompleted, Data: order.OrderSnapshot{
+		ID:      1001,
+		Number:  "KL01-1001",
+		Contact: order.Contact{Name: "Aisyah", Phone: "+60123456789"},
+	}})
+
+	r := next(t, got)
+	if r.header.Get(HeaderEvent) != string(event.OrderCompleted) {
+		t.Fatalf("Expected only ORDER_COMPLETED to be delivered, got %s", r.header.Get(HeaderEvent))
+	}
+	if !Verify("s3cret", r.header.Get(HeaderTimestamp), r.body, r.header.Get(HeaderSignature)) {
+		t.Error("Expected a valid signature")
+	}
+	if Verify("other", r.header.Get(HeaderTimestamp), r.body, r.header.Get(HeaderSignature)) {
+		t.Error("Expected the signature to depend on the secret")
+	}
+
+	var p struct {
+		Type    event.EventType
+		StoreID string `json:"store_id"`
+		At      time.Time
+		Data    OrderData
+	}
+	if err := json.Unmarshal(r.body, &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Type != event.OrderCompleted || p.StoreID != "KL01" || !p.At.Equal(start) || p.Data.Number != "KL01-1001" {
+		t.Errorf("Unexpected payload: %+v", p)
+	}
+	if strings.Contains(string(r.body), "+60123456789") || strings.Contains(string(r.body), "Aisyah") {
+		t.Errorf("Expected the customer's contact details left out, got %s", r.body)
+	}
+
+	waitFor(t, "the attempt to be recorded", func() bool {
+		attempts, _ := s.Attempts(ep.ID)
+		return len(attempts) == 1
+	})
+	attempts, _ := s.Attempts(ep.ID)
+	if a := attempts[0]; a.Number != 1 || a.StatusCode != http.StatusOK || a.Error != "" {
+		t.Errorf("Unexpected attempt: %+v", a)
+	}
+}
+
+func TestFailedDeliveryIsRetriedThenDeadLettered(t *testing.T) {
+	v := clock.NewVirtual(start)
+	bus := event.NewEventBus(event.WithClock(v))
+	var healthy atomic.Bool
+	srv, got := newPartner(t, func() int {
+		if healthy.Load() {
+			return http.StatusOK
+		}
+		return http.StatusBadGateway
+	})
+	s := New(bus, WithClient(srv.Client()), WithClock(v),
+		WithRetryPolicy(RetryPolicy{Attempts: 3, Backoff: time.Second, MaxBackoff: time.Minute}))
+	s.Start()
+	defer s.Stop()
+
+	ep, err := s.Register(srv.URL, "s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bus.Publish(event.Event{Type: event.OrderCancelled, Data: order.OrderSnapshot{ID: 1001}})
+
+	first := next(t, got)
+	for _, backoff := range []time.Duration{time.Second, 2 * time.Second} {
+		waitFor(t, "a retry to be scheduled", func() bool { return v.PendingTimers() == 1 })
+		v.Advance(backoff)
+		if r := next(t, got); r.header.Get(HeaderDelivery) != first.header.Get(HeaderDelivery) {
+			t.Fatalf("Expected a retry of the same delivery, got %s", r.header.Get(HeaderDelivery))
+		}
+	}
+
+	waitFor(t, "the delivery to be dead-lettered", func() bool {
+		dead, _ := s.DeadLetters(ep.ID)
+		return len(dead) == 1
+	})
+	dead, _ := s.DeadLetters(ep.ID)
+	if dl := dead[0]; dl.Attempts != 3 || dl.LastError != "502 Bad Gateway" || dl.Delivery.Event != event.OrderCancelled {
+		t.Errorf("Unexpected dead letter: %+v", dl)
+	}
+	if attempts, _ := s.Attempts(ep.ID); len(attempts) != 3 {
+		t.Errorf("Expected 3 attempts recorded, got %d", len(attempts))
+	}
+
+	healthy.Store(true)
+	if err := s.Redeliver(ep.ID, dead[0].Delivery.ID); err != nil {
+		t.Fatal(err)
+	}
+	next(t, got)
+	if dead, _ := s.DeadLetters(ep.ID); len(dead) != 0 {
+		t.Errorf("Expected the redelivered delivery off the dead-letter list, got %+v", dead)
+	}
+	if err := s.Redeliver(ep.ID, dead[0].Delivery.ID); !errors.Is(err, ErrDeliveryNotFound) {
+		t.Errorf("Expected ErrDeliveryNotFound for a redelivered delivery, got %v", err)
+	}
+}
+
+func TestRegisterValidatesEndpoint(t *testing.T) {
+	s := New(event.NewEventBus())
+	defer s.Stop()
+
+	if _, err := s.Register("ftp://partner.example.com", "s3cret"); !errors.Is(err, ErrInvalidEndpoint) {
+		t.Errorf("Expected ErrInvalidEndpoint for a non-HTTP URL, got %v", err)
+	}
+	if _, err := s.Register("https://partner.example.com", ""); !errors.Is(err, ErrInvalidEndpoint) {
+		t.Errorf("Expected ErrInvalidEndpoint for an empty secret, got %v", err)
+	}
+	ep, err := s.Register("https://partner.example.com", "s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Unregister(ep.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Attempts(ep.ID); !errors.Is(err, ErrEndpointNotFound) {
+		t.Errorf("Expected ErrEndpointNotFound after Unregister, got %v", err)
+	}
+}
+
+func TestDeadLettersDropOldestWhenFull(t *testing.T) {
+	s := New(event.NewEventBus(), WithDeadLetterSize(2))
+	defer s.Stop()
+	ep, err := s.Register("https://partner.example.com", "s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.mu.Lock()
+	for _, id := range []string{"dlv-1", "dlv-2", "dlv-3"} {
+		s.deadLetterLocked(s.endpoints[ep.ID], DeadLetter{Delivery: Delivery{ID: id}})
+	}
+	s.mu.Unlock()
+
+	dead, _ := s.DeadLetters(ep.ID)
+	if len(dead) != 2 || dead[0].Delivery.ID != "dlv-2" || dead[1].Delivery.ID != "dlv-3" {
+		t.Errorf("Expected the two latest dead letters kept, got %+v", dead)
+	}
+}
+
+func TestPartnerDataLeavesOutUnknownEventData(t *testing.T) {
+	if d := partnerData(struct{ Phone string }{"+60123456789"}); d != nil {
+		t.Errorf("Expected no data for an unknown type, got %+v", d)
+	}
+}
@@ -1,2 +1,2 @@
 #!/bin/bash
-go test ./internal/...
+go test -race ./internal/...